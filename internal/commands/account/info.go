@@ -141,14 +141,14 @@ func printAccount(out io.Writer, value interface{}) error {
 }
 
 func getCurrentLimit(current, limit int) string {
-	if limit == 9999 {
+	if limit == hub.UnlimitedLimit {
 		return ansi.Emphasise("unlimited")
 	}
 	return fmt.Sprintf("%v/%v", current, limit)
 }
 
 func getLimit(limit int) string {
-	if limit == 9999 {
+	if limit == hub.UnlimitedLimit {
 		return ansi.Emphasise("unlimited")
 	}
 	return fmt.Sprintf("%v", limit)
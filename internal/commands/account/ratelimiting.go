@@ -44,6 +44,7 @@ func newRateLimitingCmd(streams command.Streams, hubClient *hub.Client, parent s
 	var opts rateLimitingOptions
 	cmd := &cobra.Command{
 		Use:                   rateLimitingName,
+		Aliases:               []string{"rate-limit"},
 		Short:                 "Print the rate limiting information",
 		Args:                  cli.NoArgs,
 		DisableFlagsInUseLine: true,
@@ -76,7 +77,7 @@ func runRateLimiting(streams command.Streams, hubClient *hub.Client, opts rateLi
 
 func printRateLimit(rl *hub.RateLimits) func(io.Writer, interface{}) error {
 	return func(out io.Writer, _ interface{}) error {
-		if rl == nil {
+		if rl == nil || rl.Unlimited {
 			fmt.Fprintln(out, ansi.Emphasise("Unlimited"))
 			return nil
 		}
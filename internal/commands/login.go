@@ -37,7 +37,13 @@ const (
 	loginName = "login"
 )
 
+type loginOptions struct {
+	login.Options
+	device bool
+}
+
 func newLoginCmd(streams command.Streams, store credentials.Store, hubClient *hub.Client) *cobra.Command {
+	var opts loginOptions
 	cmd := &cobra.Command{
 		Use:                   loginName + " [USERNAME]",
 		Short:                 "Login to the Hub",
@@ -47,11 +53,22 @@ func newLoginCmd(streams command.Streams, store credentials.Store, hubClient *hu
 			metrics.Send("root", loginName)
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
-			username := ""
-			if len(args) > 0 {
-				username = args[0]
+			var err error
+			if opts.device {
+				if len(args) > 0 {
+					return errors.New("a username can't be used with --device, the account approving the login is used")
+				}
+				if opts.TwoFactorCode != "" {
+					return errors.New("--2fa-code can't be used with --device")
+				}
+				err = login.RunDeviceLogin(cmd.Context(), streams, hubClient, store)
+			} else {
+				username := ""
+				if len(args) > 0 {
+					username = args[0]
+				}
+				err = login.RunLogin(cmd.Context(), streams, hubClient, store, username, opts.Options)
 			}
-			err := login.RunLogin(cmd.Context(), streams, hubClient, store, username)
 			if err != nil {
 				if errors.Is(err, errdef.ErrCanceled) {
 					return nil
@@ -63,5 +80,7 @@ func newLoginCmd(streams command.Streams, store credentials.Store, hubClient *hu
 			return nil
 		},
 	}
+	cmd.Flags().StringVar(&opts.TwoFactorCode, "2fa-code", "", "2FA code of the account, for non interactive logins")
+	cmd.Flags().BoolVar(&opts.device, "device", false, "Login from a browser using a device code")
 	return cmd
 }
@@ -40,6 +40,7 @@ func NewRepoCmd(streams command.Streams, hubClient *hub.Client) *cobra.Command {
 	cmd.AddCommand(
 		newListCmd(streams, hubClient, repoName),
 		newRmCmd(streams, hubClient, repoName),
+		newSetVisibilityCmd(streams, hubClient, repoName),
 	)
 	return cmd
 }
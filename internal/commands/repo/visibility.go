@@ -0,0 +1,69 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package repo
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/spf13/cobra"
+
+	"github.com/docker/hub-tool/internal/ansi"
+	"github.com/docker/hub-tool/internal/metrics"
+	"github.com/docker/hub-tool/pkg/hub"
+)
+
+const (
+	setVisibilityName = "set-visibility"
+)
+
+func newSetVisibilityCmd(streams command.Streams, hubClient *hub.Client, parent string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   setVisibilityName + " NAMESPACE/REPOSITORY public|private",
+		Short:                 "Make a repository public or private",
+		Args:                  cli.ExactArgs(2),
+		DisableFlagsInUseLine: true,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			metrics.Send(parent, setVisibilityName)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runSetVisibility(streams, hubClient, args[0], args[1])
+		},
+	}
+	return cmd
+}
+
+func runSetVisibility(streams command.Streams, hubClient *hub.Client, repository, visibility string) error {
+	if !strings.Contains(repository, "/") {
+		return fmt.Errorf("repository name must include username or organization name, example: hub-tool repo set-visibility username/repository private")
+	}
+	var private bool
+	switch strings.ToLower(visibility) {
+	case "private":
+		private = true
+	case "public":
+	default:
+		return fmt.Errorf("unknown visibility %q: should be either \"public\" or \"private\"", visibility)
+	}
+	if err := hubClient.SetRepositoryVisibility(repository, private); err != nil {
+		return err
+	}
+	fmt.Fprintf(streams.Out(), ansi.Emphasise("Repository %q is now %s")+"\n", repository, strings.ToLower(visibility))
+	return nil
+}
@@ -32,6 +32,7 @@ import (
 	"github.com/docker/hub-tool/internal/commands/repo"
 	"github.com/docker/hub-tool/internal/commands/tag"
 	"github.com/docker/hub-tool/internal/commands/token"
+	"github.com/docker/hub-tool/internal/commands/webhook"
 	"github.com/docker/hub-tool/internal/login"
 	"github.com/docker/hub-tool/pkg/credentials"
 	"github.com/docker/hub-tool/pkg/hub"
@@ -80,14 +81,12 @@ func NewRootCmd(streams command.Streams, hubClient *hub.Client, store credential
 Please login to Docker Hub using the "hub-tool login" command.`))
 			}
 
-			if cmd.Annotations["sudo"] == "true" {
-				if err := tryLogin(cmd.Context(), streams, hubClient, ac, store); err != nil {
-					return err
+			if cmd.Annotations["sudo"] == "true" || ac.TokenExpired() {
+				// Device logins don't store a password, their token is
+				// renewed with the refresh token instead
+				if ac.Password == "" {
+					return tryRefresh(cmd.Context(), hubClient, ac, store)
 				}
-				return nil
-			}
-
-			if ac.TokenExpired() {
 				return tryLogin(cmd.Context(), streams, hubClient, ac, store)
 			}
 			return nil
@@ -113,6 +112,7 @@ Please login to Docker Hub using the "hub-tool login" command.`))
 		org.NewOrgCmd(streams, hubClient),
 		repo.NewRepoCmd(streams, hubClient),
 		tag.NewTagCmd(streams, hubClient),
+		webhook.NewWebhookCmd(streams, hubClient),
 		newVersionCmd(streams),
 	)
 	return cmd
@@ -155,3 +155,19 @@ func tryLogin(ctx context.Context, streams command.Streams, hubClient *hub.Clien
 		RefreshToken: refreshToken,
 	})
 }
+
+func tryRefresh(ctx context.Context, hubClient *hub.Client, ac *credentials.Auth, store credentials.Store) error {
+	token, refreshToken, err := hubClient.RefreshDeviceToken(ctx, ac.RefreshToken)
+	if err != nil {
+		return fmt.Errorf("%w, please login again using the \"hub-tool login --device\" command", err)
+	}
+	if err := hubClient.Update(hub.WithHubToken(token)); err != nil {
+		return err
+	}
+
+	return store.Store(credentials.Auth{
+		Username:     ac.Username,
+		Token:        token,
+		RefreshToken: refreshToken,
+	})
+}
@@ -0,0 +1,107 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package token
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/spf13/cobra"
+
+	"github.com/docker/hub-tool/internal/ansi"
+	"github.com/docker/hub-tool/internal/format"
+	"github.com/docker/hub-tool/internal/format/tabwriter"
+	"github.com/docker/hub-tool/internal/metrics"
+	"github.com/docker/hub-tool/pkg/hub"
+)
+
+const (
+	auditName = "audit"
+)
+
+type auditOptions struct {
+	format.Option
+	staleDays int
+}
+
+func newAuditCmd(streams command.Streams, hubClient *hub.Client, parent string) *cobra.Command {
+	var opts auditOptions
+	cmd := &cobra.Command{
+		Use:                   auditName + " [OPTIONS]",
+		Short:                 "Flag the stale, never used and inactive Personal Access Tokens",
+		Args:                  cli.NoArgs,
+		DisableFlagsInUseLine: true,
+		Annotations: map[string]string{
+			"sudo": "true",
+		},
+		PreRun: func(cmd *cobra.Command, args []string) {
+			metrics.Send(parent, auditName)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runAudit(streams, hubClient, opts)
+		},
+	}
+	cmd.Flags().IntVar(&opts.staleDays, "stale-days", 90, "Flag the tokens not used for this many days")
+	opts.AddFormatFlag(cmd.Flags())
+	return cmd
+}
+
+func runAudit(streams command.Streams, hubClient *hub.Client, opts auditOptions) error {
+	if opts.staleDays <= 0 {
+		return fmt.Errorf("invalid --stale-days %d: must be positive", opts.staleDays)
+	}
+	findings, err := hubClient.AuditTokens(time.Duration(opts.staleDays) * 24 * time.Hour)
+	if err != nil {
+		return err
+	}
+	if err := opts.Print(streams.Out(), findings, printFindings); err != nil {
+		return err
+	}
+	// Like a linter, fail when something was flagged so scripts can react
+	if len(findings) > 0 {
+		return fmt.Errorf("%d token findings", len(findings))
+	}
+	return nil
+}
+
+func printFindings(out io.Writer, values interface{}) error {
+	findings := values.([]hub.TokenFinding)
+	if len(findings) == 0 {
+		fmt.Fprintln(out, ansi.Info("No token flagged"))
+		return nil
+	}
+	tw := tabwriter.New(out, "    ")
+	for _, header := range []string{"DESCRIPTION", "UUID", "REASON", "LAST USED"} {
+		tw.Column(ansi.Header(header), len(header))
+	}
+	tw.Line()
+	for _, finding := range findings {
+		for _, value := range []string{
+			finding.Token.Description,
+			finding.Token.UUID.String(),
+			string(finding.Reason),
+			getLastUsed(finding.Token.LastUsed),
+		} {
+			tw.Column(value, len(value))
+		}
+		tw.Line()
+	}
+	return tw.Flush()
+}
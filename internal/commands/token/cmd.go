@@ -44,8 +44,10 @@ func NewTokenCmd(streams command.Streams, hubClient *hub.Client) *cobra.Command
 		newInspectCmd(streams, hubClient, tokenName),
 		newListCmd(streams, hubClient, tokenName),
 		newActivateCmd(streams, hubClient, tokenName),
+		newAuditCmd(streams, hubClient, tokenName),
 		newDeactivateCmd(streams, hubClient, tokenName),
 		newRmCmd(streams, hubClient, tokenName),
+		newUsageCmd(streams, hubClient, tokenName),
 	)
 	return cmd
 }
@@ -17,6 +17,7 @@
 package token
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"time"
@@ -35,24 +36,27 @@ import (
 
 const (
 	lsName = "ls"
+	// descriptionMaxWidth is the width long descriptions are clipped to
+	// when --trunc is set
+	descriptionMaxWidth = 40
 )
 
 var (
 	defaultColumns = []column{
-		{"DESCRIPTION", func(t hub.Token) (string, int) { return t.Description, len(t.Description) }},
-		{"UUID", func(t hub.Token) (string, int) { return t.UUID.String(), len(t.UUID.String()) }},
-		{"LAST USED", func(t hub.Token) (string, int) {
+		{"DESCRIPTION", descriptionMaxWidth, func(t hub.Token) (string, int) { return t.Description, len(t.Description) }},
+		{"UUID", 0, func(t hub.Token) (string, int) { return t.UUID.String(), len(t.UUID.String()) }},
+		{"LAST USED", 0, func(t hub.Token) (string, int) {
 			s := "Never"
 			if !t.LastUsed.IsZero() {
 				s = fmt.Sprintf("%s ago", units.HumanDuration(time.Since(t.LastUsed)))
 			}
 			return s, len(s)
 		}},
-		{"CREATED", func(t hub.Token) (string, int) {
+		{"CREATED", 0, func(t hub.Token) (string, int) {
 			s := units.HumanDuration(time.Since(t.CreatedAt))
 			return s, len(s)
 		}},
-		{"ACTIVE", func(t hub.Token) (string, int) {
+		{"ACTIVE", 0, func(t hub.Token) (string, int) {
 			s := fmt.Sprintf("%v", t.IsActive)
 			return s, len(s)
 		}},
@@ -61,12 +65,15 @@ var (
 
 type column struct {
 	header string
-	value  func(t hub.Token) (string, int)
+	// maxWidth clips the value in the table output, 0 means never clipped
+	maxWidth int
+	value    func(t hub.Token) (string, int)
 }
 
 type listOptions struct {
 	format.Option
-	all bool
+	all   bool
+	trunc bool
 }
 
 func newListCmd(streams command.Streams, hubClient *hub.Client, parent string) *cobra.Command {
@@ -88,6 +95,7 @@ func newListCmd(streams command.Streams, hubClient *hub.Client, parent string) *
 		},
 	}
 	cmd.Flags().BoolVar(&opts.all, "all", false, "Fetch all available tokens")
+	cmd.Flags().BoolVar(&opts.trunc, "trunc", false, "Clip long descriptions in the table output")
 	opts.AddFormatFlag(cmd.Flags())
 	return cmd
 }
@@ -100,12 +108,15 @@ func runList(streams command.Streams, hubClient *hub.Client, opts listOptions) e
 	}
 	tokens, total, err := hubClient.GetTokens()
 	if err != nil {
-		return err
+		if !errors.Is(err, hub.ErrIncompleteResults) {
+			return err
+		}
+		fmt.Fprintln(streams.Err(), ansi.Warn(err.Error()))
 	}
-	return opts.Print(streams.Out(), tokens, printTokens(total))
+	return opts.Print(streams.Out(), tokens, printTokens(total, opts.trunc))
 }
 
-func printTokens(total int) format.PrettyPrinter {
+func printTokens(total int, trunc bool) format.PrettyPrinter {
 	return func(out io.Writer, values interface{}) error {
 		tokens := values.([]hub.Token)
 		tw := tabwriter.New(out, "    ")
@@ -117,6 +128,9 @@ func printTokens(total int) format.PrettyPrinter {
 		for _, token := range tokens {
 			for _, column := range defaultColumns {
 				value, width := column.value(token)
+				if trunc {
+					value, width = truncate(value, width, column.maxWidth)
+				}
 				tw.Column(value, width)
 			}
 			tw.Line()
@@ -131,3 +145,15 @@ func printTokens(total int) format.PrettyPrinter {
 		return nil
 	}
 }
+
+func truncate(value string, width, maxWidth int) (string, int) {
+	if maxWidth == 0 || width <= maxWidth {
+		return value, width
+	}
+	runes := []rune(value)
+	if len(runes) <= maxWidth {
+		return value, width
+	}
+	clipped := string(runes[:maxWidth-3]) + "..."
+	return clipped, len(clipped)
+}
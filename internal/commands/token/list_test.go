@@ -0,0 +1,114 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package token
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/spf13/pflag"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/hub-tool/pkg/hub"
+)
+
+func newTestListOptions(t *testing.T, format string) listOptions {
+	var opts listOptions
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	opts.AddFormatFlag(flags)
+	assert.NilError(t, flags.Set("format", format))
+	return opts
+}
+
+func TestListJSONFormat(t *testing.T) {
+	tokens := []hub.Token{{
+		UUID:        uuid.MustParse("6b2e8c4a-1f0e-4c8e-9a4f-000000000001"),
+		Description: "ci",
+		IsActive:    true,
+		Scopes:      []string{"repo:read"},
+	}}
+	opts := newTestListOptions(t, "json")
+	out := bytes.NewBuffer(nil)
+	assert.NilError(t, opts.Print(out, tokens, printTokens(1, false)))
+
+	var decoded []map[string]interface{}
+	assert.NilError(t, json.Unmarshal(out.Bytes(), &decoded))
+	assert.Equal(t, len(decoded), 1)
+	assert.Equal(t, decoded[0]["UUID"], "6b2e8c4a-1f0e-4c8e-9a4f-000000000001")
+	assert.Equal(t, decoded[0]["IsActive"], true)
+	assert.DeepEqual(t, decoded[0]["Scopes"], []interface{}{"repo:read"})
+}
+
+func TestListJSONFormatEmpty(t *testing.T) {
+	var tokens []hub.Token
+	opts := newTestListOptions(t, "json")
+	out := bytes.NewBuffer(nil)
+	assert.NilError(t, opts.Print(out, tokens, printTokens(0, false)))
+	assert.Equal(t, out.String(), "[]\n")
+}
+
+func TestListTemplateFormat(t *testing.T) {
+	tokens := []hub.Token{{Description: "first"}, {Description: "second", IsActive: true}}
+	opts := newTestListOptions(t, "{{.Description}} {{.IsActive}}")
+	out := bytes.NewBuffer(nil)
+	assert.NilError(t, opts.Print(out, tokens, printTokens(2, false)))
+	assert.Equal(t, out.String(), "first false\nsecond true\n")
+}
+
+func TestListInvalidTemplateFormat(t *testing.T) {
+	opts := newTestListOptions(t, "{{.Description")
+	err := opts.Print(bytes.NewBuffer(nil), []hub.Token{}, printTokens(0, false))
+	assert.ErrorContains(t, err, `invalid format template "{{.Description"`)
+}
+
+func TestListUnsupportedFormat(t *testing.T) {
+	opts := newTestListOptions(t, "jsn")
+	out := bytes.NewBuffer(nil)
+	err := opts.Print(out, []hub.Token{{Description: "first"}}, printTokens(1, false))
+	assert.ErrorContains(t, err, `unsupported format type: "jsn"`)
+	assert.Equal(t, out.String(), "")
+}
+
+func TestListTruncatesLongDescriptions(t *testing.T) {
+	description := strings.Repeat("a", 60)
+	tokens := []hub.Token{{
+		UUID:        uuid.MustParse("6b2e8c4a-1f0e-4c8e-9a4f-000000000001"),
+		Description: description,
+	}}
+
+	out := bytes.NewBuffer(nil)
+	assert.NilError(t, printTokens(1, false)(out, tokens))
+	assert.Assert(t, strings.Contains(out.String(), description), "descriptions are not clipped by default")
+
+	out.Reset()
+	assert.NilError(t, printTokens(1, true)(out, tokens))
+	assert.Assert(t, !strings.Contains(out.String(), description))
+	assert.Assert(t, strings.Contains(out.String(), strings.Repeat("a", 37)+"..."))
+	assert.Assert(t, strings.Contains(out.String(), "6b2e8c4a-1f0e-4c8e-9a4f-000000000001"))
+}
+
+func TestListTruncDoesNotAffectFormat(t *testing.T) {
+	description := strings.Repeat("a", 60)
+	tokens := []hub.Token{{Description: description}}
+	opts := newTestListOptions(t, "{{.Description}}")
+	out := bytes.NewBuffer(nil)
+	assert.NilError(t, opts.Print(out, tokens, printTokens(1, true)))
+	assert.Equal(t, out.String(), description+"\n")
+}
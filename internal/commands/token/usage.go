@@ -0,0 +1,105 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package token
+
+import (
+	"io"
+	"strconv"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/spf13/cobra"
+
+	"github.com/docker/hub-tool/internal/ansi"
+	"github.com/docker/hub-tool/internal/format"
+	"github.com/docker/hub-tool/internal/format/tabwriter"
+	"github.com/docker/hub-tool/internal/metrics"
+	"github.com/docker/hub-tool/pkg/hub"
+)
+
+const (
+	usageName = "usage"
+)
+
+var (
+	usageColumns = []struct {
+		header string
+		value  func(e hub.UsageEntry) string
+	}{
+		{"USER AGENT", func(e hub.UsageEntry) string { return orUnknown(e.CreatorUA) }},
+		{"IP", func(e hub.UsageEntry) string { return orUnknown(e.CreatorIP) }},
+		{"TOKENS", func(e hub.UsageEntry) string { return strconv.Itoa(e.Count) }},
+		{"LAST USED", func(e hub.UsageEntry) string { return getLastUsed(e.LastUsed) }},
+	}
+)
+
+type usageOptions struct {
+	format.Option
+}
+
+func newUsageCmd(streams command.Streams, hubClient *hub.Client, parent string) *cobra.Command {
+	var opts usageOptions
+	cmd := &cobra.Command{
+		Use:                   usageName + " [OPTIONS]",
+		Short:                 "Summarize where the Personal Access Tokens were created from",
+		Args:                  cli.NoArgs,
+		DisableFlagsInUseLine: true,
+		Annotations: map[string]string{
+			"sudo": "true",
+		},
+		PreRun: func(cmd *cobra.Command, args []string) {
+			metrics.Send(parent, usageName)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runUsage(streams, hubClient, opts)
+		},
+	}
+	opts.AddFormatFlag(cmd.Flags())
+	return cmd
+}
+
+func runUsage(streams command.Streams, hubClient *hub.Client, opts usageOptions) error {
+	entries, err := hubClient.TokenUsageReport()
+	if err != nil {
+		return err
+	}
+	return opts.Print(streams.Out(), entries, printUsage)
+}
+
+func printUsage(out io.Writer, values interface{}) error {
+	entries := values.([]hub.UsageEntry)
+	tw := tabwriter.New(out, "    ")
+	for _, column := range usageColumns {
+		tw.Column(ansi.Header(column.header), len(column.header))
+	}
+	tw.Line()
+	for _, entry := range entries {
+		for _, column := range usageColumns {
+			value := column.value(entry)
+			tw.Column(value, len(value))
+		}
+		tw.Line()
+	}
+	return tw.Flush()
+}
+
+func orUnknown(s string) string {
+	if s == "" {
+		return "Unknown"
+	}
+	return s
+}
@@ -0,0 +1,58 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package webhook
+
+import (
+	"fmt"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/spf13/cobra"
+
+	"github.com/docker/hub-tool/internal/ansi"
+	"github.com/docker/hub-tool/internal/metrics"
+	"github.com/docker/hub-tool/pkg/hub"
+)
+
+const (
+	createName = "create"
+)
+
+func newCreateCmd(streams command.Streams, hubClient *hub.Client, parent string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   createName + " NAMESPACE/REPOSITORY NAME URL",
+		Short:                 "Create a webhook called on each push to a repository",
+		Args:                  cli.ExactArgs(3),
+		DisableFlagsInUseLine: true,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			metrics.Send(parent, createName)
+		},
+		RunE: func(_ *cobra.Command, args []string) error {
+			return runCreate(streams, hubClient, args[0], args[1], args[2])
+		},
+	}
+	return cmd
+}
+
+func runCreate(streams command.Streams, hubClient *hub.Client, repository, name, url string) error {
+	webhook, err := hubClient.CreateWebhook(repository, name, url)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(streams.Out(), ansi.Emphasise("Webhook created"), webhook.ID)
+	return nil
+}
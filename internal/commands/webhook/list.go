@@ -0,0 +1,106 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package webhook
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/spf13/cobra"
+
+	"github.com/docker/hub-tool/internal/ansi"
+	"github.com/docker/hub-tool/internal/format"
+	"github.com/docker/hub-tool/internal/format/tabwriter"
+	"github.com/docker/hub-tool/internal/metrics"
+	"github.com/docker/hub-tool/pkg/hub"
+)
+
+const (
+	lsName = "ls"
+)
+
+var (
+	defaultColumns = []column{
+		{"ID", func(w hub.Webhook) (string, int) {
+			s := strconv.Itoa(w.ID)
+			return s, len(s)
+		}},
+		{"NAME", func(w hub.Webhook) (string, int) { return w.Name, len(w.Name) }},
+		{"URL", func(w hub.Webhook) (string, int) { return w.URL, len(w.URL) }},
+		{"ACTIVE", func(w hub.Webhook) (string, int) {
+			s := fmt.Sprintf("%v", w.Active)
+			return s, len(s)
+		}},
+	}
+)
+
+type column struct {
+	header string
+	value  func(w hub.Webhook) (string, int)
+}
+
+type listOptions struct {
+	format.Option
+}
+
+func newListCmd(streams command.Streams, hubClient *hub.Client, parent string) *cobra.Command {
+	var opts listOptions
+	cmd := &cobra.Command{
+		Use:                   lsName + " [OPTIONS] NAMESPACE/REPOSITORY",
+		Aliases:               []string{"list"},
+		Short:                 "List the webhooks of a repository",
+		Args:                  cli.ExactArgs(1),
+		DisableFlagsInUseLine: true,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			metrics.Send(parent, lsName)
+		},
+		RunE: func(_ *cobra.Command, args []string) error {
+			return runList(streams, hubClient, opts, args[0])
+		},
+	}
+	opts.AddFormatFlag(cmd.Flags())
+	return cmd
+}
+
+func runList(streams command.Streams, hubClient *hub.Client, opts listOptions, repository string) error {
+	webhooks, err := hubClient.GetWebhooks(repository)
+	if err != nil {
+		return err
+	}
+	return opts.Print(streams.Out(), webhooks, printWebhooks)
+}
+
+func printWebhooks(out io.Writer, values interface{}) error {
+	webhooks := values.([]hub.Webhook)
+	tw := tabwriter.New(out, "    ")
+	for _, column := range defaultColumns {
+		tw.Column(ansi.Header(column.header), len(column.header))
+	}
+
+	tw.Line()
+	for _, webhook := range webhooks {
+		for _, column := range defaultColumns {
+			value, width := column.value(webhook)
+			tw.Column(value, width)
+		}
+		tw.Line()
+	}
+	return tw.Flush()
+}
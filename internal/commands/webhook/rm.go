@@ -0,0 +1,62 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package webhook
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/docker/cli/cli"
+	"github.com/docker/cli/cli/command"
+	"github.com/spf13/cobra"
+
+	"github.com/docker/hub-tool/internal/ansi"
+	"github.com/docker/hub-tool/internal/metrics"
+	"github.com/docker/hub-tool/pkg/hub"
+)
+
+const (
+	rmName = "rm"
+)
+
+func newRmCmd(streams command.Streams, hubClient *hub.Client, parent string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   rmName + " NAMESPACE/REPOSITORY WEBHOOK_ID",
+		Short:                 "Delete a webhook of a repository",
+		Args:                  cli.ExactArgs(2),
+		DisableFlagsInUseLine: true,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			metrics.Send(parent, rmName)
+		},
+		RunE: func(_ *cobra.Command, args []string) error {
+			return runRm(streams, hubClient, args[0], args[1])
+		},
+	}
+	return cmd
+}
+
+func runRm(streams command.Streams, hubClient *hub.Client, repository, webhookID string) error {
+	id, err := strconv.Atoi(webhookID)
+	if err != nil {
+		return fmt.Errorf("invalid webhook ID %q", webhookID)
+	}
+	if err := hubClient.DeleteWebhook(repository, id); err != nil {
+		return err
+	}
+	fmt.Fprintln(streams.Out(), ansi.Emphasise("Webhook deleted"), id)
+	return nil
+}
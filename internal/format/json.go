@@ -20,6 +20,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"reflect"
+	"strings"
+	"text/template"
 
 	"github.com/spf13/pflag"
 )
@@ -34,7 +37,7 @@ type PrettyPrinter func(io.Writer, interface{}) error
 
 //AddFormatFlag add the format flag to a command
 func (o *Option) AddFormatFlag(flags *pflag.FlagSet) {
-	flags.StringVar(&o.format, "format", "", `Print values using a custom format ("json")`)
+	flags.StringVar(&o.format, "format", "", `Print values using a custom format ("json" or a Go template)`)
 }
 
 //Print outputs values depending the given format
@@ -45,11 +48,21 @@ func (o *Option) Print(out io.Writer, values interface{}, prettyPrinter PrettyPr
 	case "json":
 		return printJSON(out, values)
 	default:
+		// Only a value with an action is a template, so a typo like "jsn"
+		// isn't silently printed as is
+		if strings.Contains(o.format, "{{") {
+			return printTemplate(out, o.format, values)
+		}
 		return fmt.Errorf("unsupported format type: %q", o.format)
 	}
 }
 
 func printJSON(out io.Writer, values interface{}) error {
+	// Marshal empty lists as [] rather than null so the output can always be
+	// iterated over by tools like jq
+	if v := reflect.ValueOf(values); v.Kind() == reflect.Slice && v.IsNil() {
+		values = reflect.MakeSlice(v.Type(), 0, 0).Interface()
+	}
 	data, err := json.MarshalIndent(values, "", "  ")
 	if err != nil {
 		return err
@@ -57,3 +70,30 @@ func printJSON(out io.Writer, values interface{}) error {
 	_, err = fmt.Fprintln(out, string(data))
 	return err
 }
+
+//printTemplate executes the Go template once per element when values is a
+//list, and once for the whole value otherwise
+func printTemplate(out io.Writer, format string, values interface{}) error {
+	tmpl, err := template.New("format").Parse(format)
+	if err != nil {
+		return fmt.Errorf("invalid format template %q: %w", format, err)
+	}
+	v := reflect.ValueOf(values)
+	if v.Kind() != reflect.Slice {
+		return executeTemplate(out, tmpl, values)
+	}
+	for i := 0; i < v.Len(); i++ {
+		if err := executeTemplate(out, tmpl, v.Index(i).Interface()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func executeTemplate(out io.Writer, tmpl *template.Template, value interface{}) error {
+	if err := tmpl.Execute(out, value); err != nil {
+		return fmt.Errorf("failed to execute format template: %w", err)
+	}
+	_, err := fmt.Fprintln(out)
+	return err
+}
@@ -36,8 +36,15 @@ import (
 	"github.com/docker/hub-tool/pkg/hub"
 )
 
+// Options of a password login
+type Options struct {
+	// TwoFactorCode is the 2FA code of the account, prompted for when empty
+	// and the account has 2FA activated
+	TwoFactorCode string
+}
+
 // RunLogin logs the user and asks for the 2FA code if needed
-func RunLogin(ctx context.Context, streams command.Streams, hubClient *hub.Client, store credentials.Store, candidateUsername string) error {
+func RunLogin(ctx context.Context, streams command.Streams, hubClient *hub.Client, store credentials.Store, candidateUsername string, opts Options) error {
 	username := candidateUsername
 	if username == "" {
 		var err error
@@ -50,7 +57,11 @@ func RunLogin(ctx context.Context, streams command.Streams, hubClient *hub.Clien
 		return err
 	}
 
-	token, refreshToken, err := Login(ctx, streams, hubClient, username, password)
+	twoFactorCodeProvider := hub.TwoFactorCode(opts.TwoFactorCode)
+	if opts.TwoFactorCode == "" {
+		twoFactorCodeProvider = promptTwoFactorCode(ctx, streams)
+	}
+	token, refreshToken, err := hubClient.Login(username, password, twoFactorCodeProvider)
 	if err != nil {
 		return err
 	}
@@ -67,19 +78,50 @@ func RunLogin(ctx context.Context, streams command.Streams, hubClient *hub.Clien
 	})
 }
 
+// RunDeviceLogin logs the user in from a browser using a device code
+func RunDeviceLogin(ctx context.Context, streams command.Streams, hubClient *hub.Client, store credentials.Store) error {
+	token, refreshToken, err := hubClient.LoginWithDeviceCode(ctx, func(code *hub.DeviceCode) {
+		fmt.Fprintf(streams.Out(), "Open %s in your browser and enter the code %s\n", code.VerificationURI, ansi.Emphasise(code.UserCode))
+		fmt.Fprintln(streams.Out(), ansi.Info("Waiting for the login to be approved..."))
+	})
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			return errdef.ErrCanceled
+		}
+		return err
+	}
+
+	if err := hubClient.Update(hub.WithHubToken(token)); err != nil {
+		return err
+	}
+	account, err := hubClient.WhoAmI()
+	if err != nil {
+		return err
+	}
+
+	return store.Store(credentials.Auth{
+		Username:     account.Name,
+		Token:        token,
+		RefreshToken: refreshToken,
+	})
+}
+
 // Login runs login and optionnaly the 2FA
 func Login(ctx context.Context, streams command.Streams, hubClient *hub.Client, username string, password string) (string, string, error) {
-	return hubClient.Login(username, password, func() (string, error) {
+	return hubClient.Login(username, password, promptTwoFactorCode(ctx, streams))
+}
+
+func promptTwoFactorCode(ctx context.Context, streams command.Streams) func() (string, error) {
+	return func() (string, error) {
 		return readClearText(ctx, streams, "2FA required, please provide the 6 digit code: ")
-	})
+	}
 }
 
 func readClearText(ctx context.Context, streams command.Streams, prompt string) (string, error) {
 	userIn := make(chan string, 1)
 	go func() {
 		fmt.Fprint(streams.Out(), ansi.Info(prompt))
-		reader := bufio.NewReader(streams.In())
-		input, _ := reader.ReadString('\n')
+		input, _ := readLine(streams.In())
 		userIn <- strings.TrimSpace(input)
 	}()
 	input := ""
@@ -104,9 +146,17 @@ func readPassword(streams command.Streams) (string, error) {
 	// - https://mintty.github.io/ (compatibility)
 	// Linux will hit this if you attempt `cat | docker login`, and Windows
 	// will hit this if you attempt docker login from mintty where stdin
-	// is a pipe, not a character based console.
+	// is a pipe, not a character based console. The password is then read
+	// from the first line of stdin, for non interactive logins.
 	if !streams.In().IsTerminal() {
-		return "", errors.Errorf("cannot perform an interactive login from a non TTY device")
+		password, err := readLine(streams.In())
+		if err != nil {
+			return "", err
+		}
+		if password == "" {
+			return "", errors.Errorf("password required")
+		}
+		return password, nil
 	}
 
 	oldState, err := term.SaveState(in.FD())
@@ -131,6 +181,29 @@ func readPassword(streams command.Streams) (string, error) {
 	return password, nil
 }
 
+// readLine reads a single line without buffering, so the following lines are
+// left for the next reads, like the 2FA code piped after the password
+func readLine(in io.Reader) (string, error) {
+	var line []byte
+	b := make([]byte, 1)
+	for {
+		n, err := in.Read(b)
+		if n > 0 {
+			if b[0] == '\n' {
+				break
+			}
+			line = append(line, b[0])
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	return strings.TrimRight(string(line), "\r"), nil
+}
+
 func readInput(in io.Reader, out io.Writer) string {
 	reader := bufio.NewReader(in)
 	line, _, err := reader.ReadLine()
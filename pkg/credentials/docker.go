@@ -0,0 +1,82 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package credentials
+
+import (
+	"os"
+	"sync"
+
+	"github.com/docker/cli/cli/config"
+	dockercredentials "github.com/docker/cli/cli/config/credentials"
+	clitypes "github.com/docker/cli/cli/config/types"
+)
+
+// NewDockerConfigStore creates a credentials store backed by the Docker
+// config file and its credential helpers. The config file is read from the
+// DOCKER_CONFIG directory, defaulting to ~/.docker.
+func NewDockerConfigStore() (Store, error) {
+	configFile, err := config.Load(os.Getenv("DOCKER_CONFIG"))
+	if err != nil {
+		return nil, err
+	}
+	return NewStore(configFile.GetCredentialsStore), nil
+}
+
+// NewMemoryStore creates a credentials store only keeping the credentials in
+// memory, for when no Docker config is available
+func NewMemoryStore() Store {
+	return &store{
+		s: &memoryStore{auths: map[string]clitypes.AuthConfig{}},
+	}
+}
+
+type memoryStore struct {
+	auths map[string]clitypes.AuthConfig
+	lock  sync.Mutex
+}
+
+var _ dockercredentials.Store = &memoryStore{}
+
+func (m *memoryStore) Erase(serverAddress string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	delete(m.auths, serverAddress)
+	return nil
+}
+
+func (m *memoryStore) Get(serverAddress string) (clitypes.AuthConfig, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.auths[serverAddress], nil
+}
+
+func (m *memoryStore) GetAll() (map[string]clitypes.AuthConfig, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	auths := make(map[string]clitypes.AuthConfig, len(m.auths))
+	for k, v := range m.auths {
+		auths[k] = v
+	}
+	return auths, nil
+}
+
+func (m *memoryStore) Store(authConfig clitypes.AuthConfig) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.auths[authConfig.ServerAddress] = authConfig
+	return nil
+}
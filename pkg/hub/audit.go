@@ -0,0 +1,233 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// auditReportVersion is the version of the JSON document written by
+// AuditReport.MarshalJSON, bumped on incompatible changes
+const auditReportVersion = 1
+
+// AuditReport gathers the tokens, members and repositories of an organization
+// for a compliance review. It never holds any secret.
+type AuditReport struct {
+	Organization string
+	GeneratedAt  time.Time
+	Tokens       []AuditToken
+	Members      []AuditMember
+	Repositories []AuditRepository
+}
+
+// AuditToken is an active personal access token of the audit report
+type AuditToken struct {
+	UUID        string
+	Description string
+	Scopes      []string
+	CreatedAt   time.Time
+	LastUsed    time.Time
+	// Age is the time elapsed since the token creation when the report was
+	// generated
+	Age time.Duration
+}
+
+// AuditMember is a member of the audited organization
+type AuditMember struct {
+	Username string
+	FullName string
+	Role     string
+	Teams    []string
+}
+
+// AuditRepository is a repository of the audited organization
+type AuditRepository struct {
+	Name      string
+	IsPrivate bool
+}
+
+// GenerateAuditReport concurrently gathers the active tokens of the current
+// user, the members of the organization with their role and the repositories
+// of the organization with their privacy.
+func (c *Client) GenerateAuditReport(org string) (*AuditReport, error) {
+	report := &AuditReport{Organization: org, GeneratedAt: time.Now().UTC()}
+	eg, _ := errgroup.WithContext(context.Background())
+	eg.Go(func() error {
+		tokens, _, err := c.getTokens(true)
+		if err != nil {
+			return err
+		}
+		for _, token := range tokens {
+			if !token.IsActive {
+				continue
+			}
+			report.Tokens = append(report.Tokens, AuditToken{
+				UUID:        token.UUID.String(),
+				Description: token.Description,
+				Scopes:      token.Scopes,
+				CreatedAt:   token.CreatedAt,
+				LastUsed:    token.LastUsed,
+				Age:         report.GeneratedAt.Sub(token.CreatedAt),
+			})
+		}
+		return nil
+	})
+	eg.Go(func() error {
+		members, err := c.auditMembers(org)
+		if err != nil {
+			return err
+		}
+		report.Members = members
+		return nil
+	})
+	eg.Go(func() error {
+		repositories, err := c.getAllRepositories(org)
+		if err != nil {
+			return err
+		}
+		for _, repository := range repositories {
+			report.Repositories = append(report.Repositories, AuditRepository{
+				Name:      repository.Name,
+				IsPrivate: repository.IsPrivate,
+			})
+		}
+		sort.Slice(report.Repositories, func(i, j int) bool {
+			return report.Repositories[i].Name < report.Repositories[j].Name
+		})
+		return nil
+	})
+	if err := eg.Wait(); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func (c *Client) auditMembers(org string) ([]AuditMember, error) {
+	var (
+		members []Member
+		teams   []Team
+	)
+	eg, _ := errgroup.WithContext(context.Background())
+	eg.Go(func() error {
+		var err error
+		members, err = c.GetMembers(org)
+		return err
+	})
+	eg.Go(func() error {
+		var err error
+		teams, err = c.GetTeams(org)
+		return err
+	})
+	if err := eg.Wait(); err != nil {
+		return nil, err
+	}
+
+	memberTeams := map[string][]Team{}
+	for _, team := range teams {
+		for _, member := range team.Members {
+			memberTeams[member.Username] = append(memberTeams[member.Username], team)
+		}
+	}
+	auditMembers := make([]AuditMember, 0, len(members))
+	for _, member := range members {
+		auditMember := AuditMember{
+			Username: member.Username,
+			FullName: member.FullName,
+			Role:     getRole(memberTeams[member.Username]),
+		}
+		for _, team := range memberTeams[member.Username] {
+			auditMember.Teams = append(auditMember.Teams, team.Name)
+		}
+		sort.Strings(auditMember.Teams)
+		auditMembers = append(auditMembers, auditMember)
+	}
+	sort.Slice(auditMembers, func(i, j int) bool {
+		return auditMembers[i].Username < auditMembers[j].Username
+	})
+	return auditMembers, nil
+}
+
+// MarshalJSON writes the report as a versioned JSON document, with RFC 3339
+// UTC dates, ages in days and never used tokens without a last use date.
+func (r AuditReport) MarshalJSON() ([]byte, error) {
+	type auditToken struct {
+		UUID        string     `json:"uuid"`
+		Description string     `json:"description"`
+		Scopes      []string   `json:"scopes"`
+		CreatedAt   time.Time  `json:"created_at"`
+		LastUsed    *time.Time `json:"last_used"`
+		AgeDays     int        `json:"age_days"`
+	}
+	type auditMember struct {
+		Username string   `json:"username"`
+		FullName string   `json:"full_name"`
+		Role     string   `json:"role"`
+		Teams    []string `json:"teams"`
+	}
+	type auditRepository struct {
+		Name      string `json:"name"`
+		IsPrivate bool   `json:"is_private"`
+	}
+	document := struct {
+		Version      int               `json:"version"`
+		Organization string            `json:"organization"`
+		GeneratedAt  time.Time         `json:"generated_at"`
+		Tokens       []auditToken      `json:"tokens"`
+		Members      []auditMember     `json:"members"`
+		Repositories []auditRepository `json:"repositories"`
+	}{
+		Version:      auditReportVersion,
+		Organization: r.Organization,
+		GeneratedAt:  r.GeneratedAt.UTC(),
+		Tokens:       []auditToken{},
+		Members:      []auditMember{},
+		Repositories: []auditRepository{},
+	}
+	for _, token := range r.Tokens {
+		t := auditToken{
+			UUID:        token.UUID,
+			Description: token.Description,
+			Scopes:      token.Scopes,
+			CreatedAt:   token.CreatedAt.UTC(),
+			AgeDays:     int(token.Age.Hours() / 24),
+		}
+		if t.Scopes == nil {
+			t.Scopes = []string{}
+		}
+		if !token.LastUsed.IsZero() {
+			lastUsed := token.LastUsed.UTC()
+			t.LastUsed = &lastUsed
+		}
+		document.Tokens = append(document.Tokens, t)
+	}
+	for _, member := range r.Members {
+		m := auditMember{Username: member.Username, FullName: member.FullName, Role: member.Role, Teams: member.Teams}
+		if m.Teams == nil {
+			m.Teams = []string{}
+		}
+		document.Members = append(document.Members, m)
+	}
+	for _, repository := range r.Repositories {
+		document.Repositories = append(document.Repositories, auditRepository(repository))
+	}
+	return json.Marshal(document)
+}
@@ -0,0 +1,99 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestGenerateAuditReport(t *testing.T) {
+	createdAt := time.Now().Add(-72 * time.Hour).UTC().Truncate(time.Second)
+	lastUsed := time.Now().Add(-time.Hour).UTC().Truncate(time.Second)
+	mux := http.NewServeMux()
+	mux.Handle(TokensURL, serveTokens(t,
+		hubTokenResult{UUID: "6b2e8c4a-1f0e-4c8e-9a4f-000000000001", TokenLabel: "ci", IsActive: true, CreatedAt: createdAt, LastUsed: lastUsed, Token: "secret-value", Scopes: []string{ScopeRepoWrite}},
+		hubTokenResult{UUID: "6b2e8c4a-1f0e-4c8e-9a4f-000000000002", TokenLabel: "unused", IsActive: true, CreatedAt: createdAt},
+		hubTokenResult{UUID: "6b2e8c4a-1f0e-4c8e-9a4f-000000000003", TokenLabel: "revoked", IsActive: false, CreatedAt: createdAt},
+	))
+	mux.HandleFunc(fmt.Sprintf(MembersURL, "org"), func(w http.ResponseWriter, r *http.Request) {
+		assert.NilError(t, json.NewEncoder(w).Encode(hubMemberResponse{
+			Count:   2,
+			Results: []hubMemberResult{{UserName: "bob", FullName: "Bob"}, {UserName: "alice", FullName: "Alice"}},
+		}))
+	})
+	mux.HandleFunc(fmt.Sprintf(GroupsURL, "org"), func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, r.URL.Path, fmt.Sprintf(GroupsURL, "org"))
+		assert.NilError(t, json.NewEncoder(w).Encode(hubGroupResponse{
+			Count:   2,
+			Results: []hubGroupResult{{Name: "owners"}, {Name: "developers"}},
+		}))
+	})
+	mux.HandleFunc(fmt.Sprintf(MembersPerTeamURL, "org", "owners"), func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"username": "alice"}]`)
+	})
+	mux.HandleFunc(fmt.Sprintf(MembersPerTeamURL, "org", "developers"), func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"username": "alice"}, {"username": "bob"}]`)
+	})
+	mux.HandleFunc(RepositoriesURL+"org", func(w http.ResponseWriter, r *http.Request) {
+		assert.NilError(t, json.NewEncoder(w).Encode(hubRepositoryResponse{
+			Count:   2,
+			Results: []hubRepositoryResult{{Name: "web", IsPrivate: false}, {Name: "api", IsPrivate: true}},
+		}))
+	})
+	client := newTestClient(t, mux)
+
+	report, err := client.GenerateAuditReport("org")
+	assert.NilError(t, err)
+	assert.Equal(t, report.Organization, "org")
+
+	assert.Equal(t, len(report.Tokens), 2, "inactive tokens should be left out")
+	assert.Equal(t, report.Tokens[0].Description, "ci")
+	assert.Assert(t, report.Tokens[0].Age >= 72*time.Hour)
+	assert.Assert(t, report.Tokens[1].LastUsed.IsZero())
+
+	assert.DeepEqual(t, report.Members, []AuditMember{
+		{Username: "alice", FullName: "Alice", Role: "Owner", Teams: []string{"developers", "owners"}},
+		{Username: "bob", FullName: "Bob", Role: "Member", Teams: []string{"developers"}},
+	})
+	assert.DeepEqual(t, report.Repositories, []AuditRepository{
+		{Name: "org/api", IsPrivate: true},
+		{Name: "org/web", IsPrivate: false},
+	})
+
+	data, err := json.Marshal(report)
+	assert.NilError(t, err)
+	assert.Assert(t, !strings.Contains(string(data), "secret-value"), "the report must not contain any secret")
+	var document struct {
+		Version int `json:"version"`
+		Tokens  []struct {
+			LastUsed *time.Time `json:"last_used"`
+			AgeDays  int        `json:"age_days"`
+		} `json:"tokens"`
+	}
+	assert.NilError(t, json.Unmarshal(data, &document))
+	assert.Equal(t, document.Version, 1)
+	assert.Assert(t, document.Tokens[0].LastUsed.Equal(lastUsed))
+	assert.Equal(t, document.Tokens[0].AgeDays, 3)
+	assert.Assert(t, document.Tokens[1].LastUsed == nil)
+}
@@ -0,0 +1,161 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	// AuditLogsURL path to the Hub API listing the audit events of an
+	// organization
+	AuditLogsURL = "/v2/auditlogs/%s/"
+)
+
+// AuditEvent is an action recorded in the audit log of an organization
+type AuditEvent struct {
+	// Actor is the user who performed the action
+	Actor string
+	// Action is the kind of the action, e.g. "repo.tag.push"
+	Action string
+	// Resource is the object of the action, e.g. a repository
+	Resource    string
+	Description string
+	Timestamp   time.Time
+}
+
+// AuditLogOp is an option of GetAuditLogs
+type AuditLogOp func(*auditLogOptions) error
+
+type auditLogOptions struct {
+	maxResults int
+}
+
+// WithMaxAuditEvents stops fetching audit events once max of them were
+// fetched, to bound the memory used by large audit logs
+func WithMaxAuditEvents(max int) AuditLogOp {
+	return func(opts *auditLogOptions) error {
+		if max <= 0 {
+			return fmt.Errorf("invalid maximum number of audit events %d, must be positive", max)
+		}
+		opts.maxResults = max
+		return nil
+	}
+}
+
+// GetAuditLogs returns the audit events of an organization which happened
+// between from and to, the most recent first. A zero from or to leaves that
+// side of the range open. Only the first page is fetched unless the client
+// fetches all the elements.
+func (c *Client) GetAuditLogs(organization string, from, to time.Time, ops ...AuditLogOp) ([]AuditEvent, error) {
+	var opts auditLogOptions
+	for _, op := range ops {
+		if err := op(&opts); err != nil {
+			return nil, err
+		}
+	}
+	if organization == "" {
+		return nil, errors.New("organization is required to read audit logs")
+	}
+	if !from.IsZero() && !to.IsZero() && to.Before(from) {
+		return nil, fmt.Errorf("invalid audit log range: %s is before %s", to.Format(time.RFC3339), from.Format(time.RFC3339))
+	}
+
+	var events []AuditEvent
+	for page := 1; ; page++ {
+		u, err := auditLogsURL(c.domain, organization, from, to, page, c.pageSizeOrDefault())
+		if err != nil {
+			return nil, err
+		}
+		pageEvents, err := c.getAuditLogsPage(u)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, pageEvents...)
+		if opts.maxResults > 0 && len(events) >= opts.maxResults {
+			return events[:opts.maxResults], nil
+		}
+		// The audit logs API doesn't give the total count, a short page is
+		// the last one
+		if !c.fetchAllElements || len(pageEvents) < c.pageSizeOrDefault() {
+			return events, nil
+		}
+	}
+}
+
+func auditLogsURL(domain, organization string, from, to time.Time, page, pageSize int) (string, error) {
+	u, err := url.Parse(domain + fmt.Sprintf(AuditLogsURL, organization))
+	if err != nil {
+		return "", err
+	}
+	q := url.Values{}
+	q.Add("page_size", fmt.Sprintf("%v", pageSize))
+	q.Add("page", strconv.Itoa(page))
+	if !from.IsZero() {
+		q.Add("from", from.UTC().Format(time.RFC3339))
+	}
+	if !to.IsZero() {
+		q.Add("to", to.UTC().Format(time.RFC3339))
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+func (c *Client) getAuditLogsPage(url string) ([]AuditEvent, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	response, err := c.doRequest(req, withHubToken(c.token))
+	if err != nil {
+		return nil, err
+	}
+	var hubResponse hubAuditLogResponse
+	if err := json.Unmarshal(response, &hubResponse); err != nil {
+		return nil, err
+	}
+	events := make([]AuditEvent, len(hubResponse.Logs))
+	for i, log := range hubResponse.Logs {
+		events[i] = AuditEvent{
+			Actor:       log.Actor,
+			Action:      log.Action,
+			Resource:    log.Name,
+			Description: log.ActionDescription,
+			Timestamp:   log.Timestamp,
+		}
+	}
+	return events, nil
+}
+
+type hubAuditLogResponse struct {
+	Logs []hubAuditLog `json:"logs"`
+}
+
+type hubAuditLog struct {
+	Account           string    `json:"account"`
+	Action            string    `json:"action"`
+	Name              string    `json:"name"`
+	Actor             string    `json:"actor"`
+	Timestamp         time.Time `json:"timestamp"`
+	ActionDescription string    `json:"action_description"`
+}
@@ -0,0 +1,107 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+// serveAuditLogs serves total events, itemsPerPage per page
+func serveAuditLogs(t *testing.T, total int, pages *int) http.Handler {
+	t.Helper()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, r.URL.Path, fmt.Sprintf(AuditLogsURL, "org"))
+		*pages++
+		page, err := strconv.Atoi(r.URL.Query().Get("page"))
+		assert.NilError(t, err)
+		var response hubAuditLogResponse
+		for i := (page - 1) * itemsPerPage; i < total && i < page*itemsPerPage; i++ {
+			response.Logs = append(response.Logs, hubAuditLog{Actor: "alice", Action: "repo.tag.push", Name: fmt.Sprintf("org/img-%d", i)})
+		}
+		assert.NilError(t, json.NewEncoder(w).Encode(response))
+	})
+}
+
+func TestGetAuditLogsTimeRange(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, r.URL.Query().Get("from"), "2021-02-01T00:00:00Z")
+		assert.Equal(t, r.URL.Query().Get("to"), "2021-02-28T13:30:00Z")
+		fmt.Fprint(w, `{"logs": [{"account": "org", "action": "repo.create", "name": "org/img", "actor": "alice",
+			"timestamp": "2021-02-19T01:34:35Z", "action_description": "created the repository img"}]}`)
+	}))
+
+	from := time.Date(2021, 2, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2021, 2, 28, 14, 30, 0, 0, time.FixedZone("CET", 3600))
+	events, err := client.GetAuditLogs("org", from, to)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, events, []AuditEvent{{
+		Actor:       "alice",
+		Action:      "repo.create",
+		Resource:    "org/img",
+		Description: "created the repository img",
+		Timestamp:   time.Date(2021, 2, 19, 1, 34, 35, 0, time.UTC),
+	}})
+
+	_, err = client.GetAuditLogs("org", to, from)
+	assert.ErrorContains(t, err, "invalid audit log range")
+}
+
+func TestGetAuditLogsOpenRange(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, hasFrom := r.URL.Query()["from"]
+		_, hasTo := r.URL.Query()["to"]
+		assert.Assert(t, !hasFrom && !hasTo)
+		fmt.Fprint(w, `{"logs": []}`)
+	}))
+
+	events, err := client.GetAuditLogs("org", time.Time{}, time.Time{})
+	assert.NilError(t, err)
+	assert.Equal(t, len(events), 0)
+}
+
+func TestGetAuditLogsPagination(t *testing.T) {
+	pages := 0
+	client := newTestClient(t, serveAuditLogs(t, 2*itemsPerPage+1, &pages))
+
+	events, err := client.GetAuditLogs("org", time.Time{}, time.Time{})
+	assert.NilError(t, err)
+	assert.Equal(t, len(events), itemsPerPage)
+	assert.Equal(t, pages, 1)
+
+	pages = 0
+	assert.NilError(t, client.Update(WithAllElements()))
+	events, err = client.GetAuditLogs("org", time.Time{}, time.Time{})
+	assert.NilError(t, err)
+	assert.Equal(t, len(events), 2*itemsPerPage+1)
+	assert.Equal(t, pages, 3)
+
+	pages = 0
+	events, err = client.GetAuditLogs("org", time.Time{}, time.Time{}, WithMaxAuditEvents(itemsPerPage+10))
+	assert.NilError(t, err)
+	assert.Equal(t, len(events), itemsPerPage+10)
+	assert.Equal(t, pages, 2, "no page should be fetched past the cap")
+
+	_, err = client.GetAuditLogs("org", time.Time{}, time.Time{}, WithMaxAuditEvents(0))
+	assert.Error(t, err, "invalid maximum number of audit events 0, must be positive")
+}
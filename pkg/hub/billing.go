@@ -0,0 +1,57 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+//Billing summarizes the billing state of an organization. The next billing
+//date isn't part of it as the Hub API doesn't document an endpoint exposing it.
+type Billing struct {
+	Plan      Plan
+	SeatsUsed int
+}
+
+//GetBillingSummary returns the organization plan and its seat usage. Only the
+//owners of the organization are allowed to read the plan, others get a
+//forbidden error (see IsForbiddenError).
+func (c *Client) GetBillingSummary(org string) (*Billing, error) {
+	account, err := c.GetOrganizationInfo(org)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := c.GetHubPlan(account.ID)
+	if err != nil {
+		return nil, err
+	}
+	seats, err := c.GetMembersCount(org)
+	if err != nil {
+		return nil, err
+	}
+	return &Billing{
+		Plan:      *plan,
+		SeatsUsed: seats,
+	}, nil
+}
+
+//OrgSeatUsage returns the number of seats used by the members of an
+//organization and the number of seats of its plan, UnlimitedLimit if the plan
+//doesn't limit them, from its billing summary (see GetBillingSummary).
+func (c *Client) OrgSeatUsage(org string) (int, int, error) {
+	billing, err := c.GetBillingSummary(org)
+	if err != nil {
+		return 0, 0, err
+	}
+	return billing.SeatsUsed, billing.Plan.Limits.Seats, nil
+}
@@ -0,0 +1,94 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func serveBilling(t *testing.T, plan string, planStatus int) http.Handler {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc(fmt.Sprintf(OrganizationInfoURL, "acme"), func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id": "acme-id", "orgname": "acme"}`)
+	})
+	mux.HandleFunc(fmt.Sprintf(HubPlanURL, "acme-id"), func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(planStatus)
+		fmt.Fprintf(w, `{"name": %q, "seats": 5}`, plan)
+	})
+	mux.HandleFunc(fmt.Sprintf(MembersURL, "acme"), func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"count": 3}`)
+	})
+	return mux
+}
+
+func TestGetBillingSummary(t *testing.T) {
+	for _, plan := range []string{TeamPlan, FreePlan} {
+		client := newTestClient(t, serveBilling(t, plan, http.StatusOK))
+
+		billing, err := client.GetBillingSummary("acme")
+		assert.NilError(t, err)
+		assert.Equal(t, billing.Plan.Name, plan)
+		assert.Equal(t, billing.Plan.Limits.Seats, 5)
+		assert.Equal(t, billing.SeatsUsed, 3)
+	}
+}
+
+func TestGetBillingSummaryForbidden(t *testing.T) {
+	client := newTestClient(t, serveBilling(t, TeamPlan, http.StatusForbidden))
+
+	_, err := client.GetBillingSummary("acme")
+	assert.Assert(t, IsForbiddenError(err))
+}
+
+func TestOrgSeatUsage(t *testing.T) {
+	client := newTestClient(t, serveBilling(t, TeamPlan, http.StatusOK))
+
+	used, total, err := client.OrgSeatUsage("acme")
+	assert.NilError(t, err)
+	assert.Equal(t, used, 3)
+	assert.Equal(t, total, 5)
+}
+
+func TestOrgSeatUsageLimits(t *testing.T) {
+	planStatus, seats := http.StatusOK, UnlimitedLimit
+	mux := http.NewServeMux()
+	mux.HandleFunc(fmt.Sprintf(OrganizationInfoURL, "acme"), func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id": "acme-id", "orgname": "acme"}`)
+	})
+	mux.HandleFunc(fmt.Sprintf(HubPlanURL, "acme-id"), func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(planStatus)
+		fmt.Fprintf(w, `{"name": "team", "seats": %d}`, seats)
+	})
+	mux.HandleFunc(fmt.Sprintf(MembersURL, "acme"), func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"count": 42}`)
+	})
+	client := newTestClient(t, mux)
+
+	used, total, err := client.OrgSeatUsage("acme")
+	assert.NilError(t, err)
+	assert.Equal(t, used, 42)
+	assert.Equal(t, total, UnlimitedLimit)
+
+	planStatus = http.StatusForbidden
+	_, _, err = client.OrgSeatUsage("acme")
+	assert.Assert(t, IsForbiddenError(err), "non owners should get a forbidden error")
+}
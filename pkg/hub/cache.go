@@ -0,0 +1,104 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WithCache keeps the responses of the token reads, GetTokens and GetToken
+// included, in memory for ttl. The calls changing tokens evict the cached
+// responses. By default nothing is cached.
+func WithCache(ttl time.Duration) ClientOp {
+	return func(c *Client) error {
+		if ttl <= 0 {
+			return fmt.Errorf("invalid cache TTL %s: must be positive", ttl)
+		}
+		c.cache = &responseCache{ttl: ttl, entries: map[string]cachedResponse{}}
+		return nil
+	}
+}
+
+type cachedResponse struct {
+	body      []byte
+	expiresAt time.Time
+}
+
+type responseCache struct {
+	ttl     time.Duration
+	entries map[string]cachedResponse
+	lock    sync.Mutex
+}
+
+func (r *responseCache) get(key string) ([]byte, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	entry, ok := r.entries[key]
+	if !ok || !time.Now().Before(entry.expiresAt) {
+		return nil, false
+	}
+	return entry.body, true
+}
+
+func (r *responseCache) set(key string, body []byte) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	now := time.Now()
+	for k, entry := range r.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(r.entries, k)
+		}
+	}
+	r.entries[key] = cachedResponse{body: body, expiresAt: now.Add(r.ttl)}
+}
+
+// invalidate evicts the responses of the URLs starting with prefix, which
+// must include the client domain
+func (r *responseCache) invalidate(prefix string) {
+	if r == nil {
+		return
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for k := range r.entries {
+		if strings.HasPrefix(k, prefix) {
+			delete(r.entries, k)
+		}
+	}
+}
+
+// doCachedRequest is doRequest, answering GET requests from the cache when
+// it is enabled. Only successful responses are cached.
+func (c *Client) doCachedRequest(req *http.Request, reqOps ...RequestOp) ([]byte, error) {
+	if c.cache == nil || req.Method != http.MethodGet {
+		return c.doRequest(req, reqOps...)
+	}
+	key := req.URL.String()
+	if body, ok := c.cache.get(key); ok {
+		return body, nil
+	}
+	body, err := c.doRequest(req, reqOps...)
+	if err != nil {
+		return nil, err
+	}
+	c.cache.set(key, body)
+	return body, nil
+}
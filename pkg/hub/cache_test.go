@@ -0,0 +1,127 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+var cachedTokenUUID = testTokenUUID(1)
+
+func serveCachedTokens(t *testing.T, requests *map[string]int) http.Handler {
+	var lock sync.Mutex
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		(*requests)[r.Method+" "+r.URL.Path]++
+		lock.Unlock()
+		result := hubTokenResult{UUID: cachedTokenUUID, TokenLabel: "ci"}
+		switch {
+		case r.Method == http.MethodDelete:
+			w.WriteHeader(http.StatusAccepted)
+		case r.URL.Path == TokensURL:
+			assert.NilError(t, json.NewEncoder(w).Encode(hubTokenResponse{Count: 1, Results: []hubTokenResult{result}}))
+		default:
+			assert.NilError(t, json.NewEncoder(w).Encode(result))
+		}
+	})
+}
+
+func TestWithCache(t *testing.T) {
+	requests := map[string]int{}
+	client := newTestClient(t, serveCachedTokens(t, &requests))
+	assert.NilError(t, client.Update(WithCache(time.Minute)))
+
+	for i := 0; i < 3; i++ {
+		tokens, _, err := client.GetTokens()
+		assert.NilError(t, err)
+		assert.Equal(t, len(tokens), 1)
+		token, err := client.GetToken(cachedTokenUUID)
+		assert.NilError(t, err)
+		assert.Equal(t, token.Description, "ci")
+	}
+	tokenPath := TokensURL + "/" + cachedTokenUUID
+	assert.DeepEqual(t, requests, map[string]int{"GET " + TokensURL: 1, "GET " + tokenPath: 1})
+
+	assert.NilError(t, client.RemoveToken(cachedTokenUUID))
+	_, _, err := client.GetTokens()
+	assert.NilError(t, err)
+	_, err = client.GetToken(cachedTokenUUID)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, requests, map[string]int{"GET " + TokensURL: 2, "GET " + tokenPath: 2, "DELETE " + tokenPath: 1})
+}
+
+func TestWithCacheInvalidatedUnderBasePath(t *testing.T) {
+	requests := map[string]int{}
+	client := newTestClient(t, http.StripPrefix("/hub", serveCachedTokens(t, &requests)))
+	assert.NilError(t, client.Update(WithDomain(client.domain+"/hub"), WithCache(time.Minute)))
+
+	_, _, err := client.GetTokens()
+	assert.NilError(t, err)
+	assert.NilError(t, client.RemoveToken(cachedTokenUUID))
+	_, _, err = client.GetTokens()
+	assert.NilError(t, err)
+	assert.Equal(t, requests["GET "+TokensURL], 2)
+}
+
+func TestWithCacheExpires(t *testing.T) {
+	requests := map[string]int{}
+	client := newTestClient(t, serveCachedTokens(t, &requests))
+	assert.NilError(t, client.Update(WithCache(time.Millisecond)))
+
+	_, _, err := client.GetTokens()
+	assert.NilError(t, err)
+	time.Sleep(5 * time.Millisecond)
+	_, _, err = client.GetTokens()
+	assert.NilError(t, err)
+	assert.Equal(t, requests["GET "+TokensURL], 2)
+}
+
+func TestWithCacheConcurrentReads(t *testing.T) {
+	requests := map[string]int{}
+	client := newTestClient(t, serveCachedTokens(t, &requests))
+	assert.NilError(t, client.Update(WithCache(time.Minute)))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.GetToken(cachedTokenUUID)
+			assert.Check(t, err)
+		}()
+	}
+	wg.Wait()
+	_, err := NewClient(WithCache(0))
+	assert.ErrorContains(t, err, "invalid cache TTL 0s")
+}
+
+func TestNoCacheByDefault(t *testing.T) {
+	requests := map[string]int{}
+	client := newTestClient(t, serveCachedTokens(t, &requests))
+
+	for i := 0; i < 2; i++ {
+		_, _, err := client.GetTokens()
+		assert.NilError(t, err)
+	}
+	assert.Equal(t, requests["GET "+TokensURL], 2)
+}
@@ -0,0 +1,101 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// Capabilities lists the optional token features supported by the connected
+// Hub API
+type Capabilities struct {
+	// TokenExpiration is true if tokens can be created with an expiration
+	TokenExpiration bool
+	// TokenScopes is true if tokens can be created with scopes
+	TokenScopes bool
+	// TokenAllowedIPs is true if tokens can be restricted to IP ranges
+	TokenAllowedIPs bool
+}
+
+// Capabilities probes the features supported by the Hub API, from the fields
+// it accepts when creating a token. The probe is opt-in: once it succeeded,
+// the result is cached and the token creation fails early with
+// ErrUnsupportedByServer if it relies on a feature the Hub API doesn't list.
+// A Hub API which doesn't describe the fields it accepts is assumed to
+// support them all.
+func (c *Client) Capabilities() (Capabilities, error) {
+	c.capabilitiesLock.Lock()
+	defer c.capabilitiesLock.Unlock()
+	if c.capabilities != nil {
+		return *c.capabilities, nil
+	}
+	req, err := http.NewRequest("OPTIONS", c.domain+TokensURL, nil)
+	if err != nil {
+		return Capabilities{}, err
+	}
+	response, err := c.doRequest(req, withHubToken(c.token))
+	if err != nil {
+		return Capabilities{}, fmt.Errorf("failed to probe the Hub API capabilities: %w", err)
+	}
+	var metadata hubOptionsResponse
+	if err := json.Unmarshal(response, &metadata); err != nil {
+		return Capabilities{}, err
+	}
+	fields, ok := metadata.Actions["POST"]
+	if !ok {
+		c.capabilities = &Capabilities{TokenExpiration: true, TokenScopes: true, TokenAllowedIPs: true}
+		return *c.capabilities, nil
+	}
+	_, expiration := fields["expires_at"]
+	_, scopes := fields["scopes"]
+	_, allowedIPs := fields["allowed_ips"]
+	c.capabilities = &Capabilities{
+		TokenExpiration: expiration,
+		TokenScopes:     scopes,
+		TokenAllowedIPs: allowedIPs,
+	}
+	return *c.capabilities, nil
+}
+
+// checkTokenCapabilities makes sure the Hub API supports the optional
+// features of the token request. Nothing is checked until the capabilities
+// were probed, the Hub API then rejects the fields itself.
+func (c *Client) checkTokenCapabilities(request hubTokenRequest) error {
+	c.capabilitiesLock.Lock()
+	capabilities := c.capabilities
+	c.capabilitiesLock.Unlock()
+	if capabilities == nil {
+		return nil
+	}
+	switch {
+	case request.ExpiresAt != nil && !capabilities.TokenExpiration:
+		return fmt.Errorf("%w: token expiration", ErrUnsupportedByServer)
+	case len(request.Scopes) > 0 && !capabilities.TokenScopes:
+		return fmt.Errorf("%w: token scopes", ErrUnsupportedByServer)
+	case len(request.AllowedIPs) > 0 && !capabilities.TokenAllowedIPs:
+		return fmt.Errorf("%w: token allowed IPs", ErrUnsupportedByServer)
+	}
+	return nil
+}
+
+// hubOptionsResponse is the metadata returned by the Hub API on OPTIONS
+// requests, describing the fields accepted by each method
+type hubOptionsResponse struct {
+	Actions map[string]map[string]json.RawMessage `json:"actions"`
+}
@@ -0,0 +1,108 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestCapabilities(t *testing.T) {
+	probes, creations := 0, 0
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "OPTIONS":
+			probes++
+			fmt.Fprint(w, `{"name": "Api Token List", "actions": {"POST": {"token_label": {"type": "string"}, "scopes": {"type": "list"}}}}`)
+		case http.MethodPost:
+			creations++
+			fmt.Fprint(w, `{"uuid": "6b2e8c4a-1f0e-4c8e-9a4f-000000000001"}`)
+		default:
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+
+	// Nothing is probed unless asked for
+	_, err := client.CreateToken("ci", WithExpiration(time.Now().Add(time.Hour)))
+	assert.NilError(t, err)
+	assert.Equal(t, probes, 0)
+
+	capabilities, err := client.Capabilities()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, capabilities, Capabilities{TokenScopes: true})
+	_, err = client.Capabilities()
+	assert.NilError(t, err)
+	assert.Equal(t, probes, 1, "capabilities should be cached")
+
+	// Once probed, the creations relying on a missing feature fail early
+	_, err = client.CreateToken("ci", WithExpiration(time.Now().Add(time.Hour)))
+	assert.Assert(t, errors.Is(err, ErrUnsupportedByServer))
+	assert.ErrorContains(t, err, "token expiration")
+	_, err = client.CreateToken("ci", WithAllowedIPs("192.0.2.0/24"))
+	assert.Assert(t, errors.Is(err, ErrUnsupportedByServer))
+	_, err = client.CreateToken("ci", WithScopes(ScopeRepoRead))
+	assert.NilError(t, err)
+	assert.Equal(t, creations, 2, "unsupported creations should not be sent")
+}
+
+func TestCapabilitiesUnknown(t *testing.T) {
+	testCases := []struct {
+		name    string
+		options http.HandlerFunc
+	}{
+		{name: "method not allowed", options: func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}},
+		{name: "no fields metadata", options: func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{}`)
+		}},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == "OPTIONS" {
+					testCase.options(w, r)
+					return
+				}
+				fmt.Fprint(w, `{"uuid": "6b2e8c4a-1f0e-4c8e-9a4f-000000000001"}`)
+			}))
+
+			// A failed or unknown probe doesn't prevent the creation
+			_, _ = client.Capabilities()
+			_, err := client.CreateToken("ci",
+				WithScopes(ScopeRepoRead),
+				WithExpiration(time.Now().Add(time.Hour)),
+				WithAllowedIPs("192.0.2.0/24"),
+			)
+			assert.NilError(t, err)
+		})
+	}
+}
+
+func TestCapabilitiesProbeFailure(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}))
+
+	_, err := client.Capabilities()
+	assert.ErrorContains(t, err, "failed to probe the Hub API capabilities")
+}
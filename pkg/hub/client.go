@@ -20,16 +20,21 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/docker/docker/api/types"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/docker/hub-tool/internal"
+	"github.com/docker/hub-tool/pkg/credentials"
 )
 
 const (
@@ -40,23 +45,67 @@ const (
 	// SecondFactorDetailMessage returned by login if 2FA is enabled
 	SecondFactorDetailMessage = "Require secondary authentication on MFA enabled account"
 
+	// itemsPerPage is the default size of the pages, also the largest the
+	// Hub API accepts
 	itemsPerPage = 100
+	// defaultConcurrentPages is the number of pages fetched concurrently
+	// when WithConcurrentPages is used without a limit
+	defaultConcurrentPages = 4
 )
 
 // Client sends authenticated calls to the Hub API
 type Client struct {
 	AuthConfig types.AuthConfig
 	Ctx        context.Context
+	// DescriptionValidator, when set, is called with the description of the
+	// tokens before creating or updating them. An error aborts the operation.
+	DescriptionValidator func(string) error
+	// Events, when set, receives a ClientEvent for every API call. Events are
+	// sent without blocking: when the channel is full (or unbuffered with no
+	// receiver ready) the event is dropped, so a slow consumer never stalls
+	// the API calls. Use a buffered channel sized for the expected bursts.
+	Events chan<- ClientEvent
 
 	client           *http.Client
 	domain           string
+	registry         string
 	token            string
 	refreshToken     string
+	deviceAuthDomain string
 	password         string
+	credentialStore  credentials.Store
 	account          string
 	fetchAllElements bool
+	dryRun           bool
+	concurrentPages  int
+	pageSize         int
+	cache            *responseCache
+	onPage           func(fetched, total int)
+	requestLogger    func(RequestInfo)
+	tracer           Tracer
+	trace            io.Writer
+	traceLock        sync.Mutex
+	ipResolver       IPResolver
 	in               io.Reader
 	out              io.Writer
+
+	registryTokens     map[string]registryToken
+	registryTokensLock sync.Mutex
+	whoAmI             *Account
+	whoAmILock         sync.Mutex
+	warnings           []DeprecationWarning
+	warningsLock       sync.Mutex
+	capabilities       *Capabilities
+	capabilitiesLock   sync.Mutex
+	latencies          *latencyRecorder
+
+	timeout            time.Duration
+	maxRetries         int
+	retryBackoff       time.Duration
+	retryableStatuses  map[int]bool
+	minRequestInterval time.Duration
+	nextRequest        time.Time
+	rateLimitLock      sync.Mutex
 }
 
 type twoFactorResponse struct {
@@ -87,8 +136,9 @@ func NewClient(ops ...ClientOp) (*Client, error) {
 	hubInstance := getInstance()
 
 	client := &Client{
-		client: http.DefaultClient,
-		domain: hubInstance.APIHubBaseURL,
+		client:   http.DefaultClient,
+		domain:   hubInstance.APIHubBaseURL,
+		registry: registryURL(hubInstance.RegistryInfo.Name),
 	}
 	for _, op := range ops {
 		if err := op(client); err != nil {
@@ -117,6 +167,62 @@ func WithAllElements() ClientOp {
 	}
 }
 
+// WithPageSize sets the number of elements fetched per page by the list
+// calls, between 1 and 100. Fewer elements per page make the first page
+// faster to fetch, more make fetching all the elements take fewer requests.
+func WithPageSize(size int) ClientOp {
+	return func(c *Client) error {
+		if err := validatePageSize(size); err != nil {
+			return err
+		}
+		c.pageSize = size
+		return nil
+	}
+}
+
+func validatePageSize(size int) error {
+	if size < 1 || size > itemsPerPage {
+		return fmt.Errorf("invalid page size %d: must be between 1 and %d", size, itemsPerPage)
+	}
+	return nil
+}
+
+// pageSizeOrDefault is the page size set with WithPageSize, itemsPerPage
+// otherwise
+func (c *Client) pageSizeOrDefault() int {
+	if c.pageSize == 0 {
+		return itemsPerPage
+	}
+	return c.pageSize
+}
+
+// WithConcurrentPages makes the client fetch the pages of the tokens
+// concurrently, with at most limit requests in flight, once the first page
+// revealed how many there are. A zero limit uses defaultConcurrentPages.
+func WithConcurrentPages(limit int) ClientOp {
+	return func(c *Client) error {
+		if limit < 0 {
+			return fmt.Errorf("invalid concurrent pages limit %d: must be positive", limit)
+		}
+		if limit == 0 {
+			limit = defaultConcurrentPages
+		}
+		c.concurrentPages = limit
+		return nil
+	}
+}
+
+// WithPageCallback sets a function called after each page fetched by the list
+// methods, with the number of elements fetched so far and the total announced
+// by the Hub API, e.g. to display the progress of a long fetch. Lists fetched
+// concurrently, like the teams of the organizations, may call it concurrently.
+func WithPageCallback(onPage func(fetched, total int)) ClientOp {
+	return func(c *Client) error {
+		c.onPage = onPage
+		return nil
+	}
+}
+
 // WithContext set the client context
 func WithContext(ctx context.Context) ClientOp {
 	return func(c *Client) error {
@@ -154,6 +260,9 @@ func WithHubAccount(account string) ClientOp {
 func WithHubToken(token string) ClientOp {
 	return func(c *Client) error {
 		c.token = token
+		c.whoAmI = nil
+		// The cached responses belong to the previous account
+		c.cache.invalidate("")
 		return nil
 	}
 }
@@ -174,14 +283,67 @@ func WithPassword(password string) ClientOp {
 	}
 }
 
-// WithHTTPClient sets the *http.Client for the client
+// WithHTTPClient sets the *http.Client for the client, e.g. configured with a
+// proxy or a custom CA bundle. http.DefaultClient is used by default.
 func WithHTTPClient(client *http.Client) ClientOp {
 	return func(c *Client) error {
+		if client == nil {
+			return errors.New("invalid HTTP client: nil")
+		}
 		c.client = client
 		return nil
 	}
 }
 
+// WithTransport sets the transport used to send the requests, keeping the
+// other settings of the HTTP client
+func WithTransport(transport http.RoundTripper) ClientOp {
+	return func(c *Client) error {
+		if transport == nil {
+			return errors.New("invalid HTTP transport: nil")
+		}
+		client := *c.client
+		client.Transport = transport
+		c.client = &client
+		return nil
+	}
+}
+
+// WithConnectionPool sizes the pool of idle connections kept by the client.
+// Without this option, the Go defaults apply: 100 idle connections, 2 per
+// host, closed after 90 seconds of inactivity.
+func WithConnectionPool(maxIdle, maxIdlePerHost int, idleTimeout time.Duration) ClientOp {
+	return func(c *Client) error {
+		if maxIdle < 0 || maxIdlePerHost < 0 || idleTimeout < 0 {
+			return fmt.Errorf("invalid connection pool settings: limits and timeout must be positive")
+		}
+		transport, err := cloneTransport(c.client)
+		if err != nil {
+			return err
+		}
+		transport.MaxIdleConns = maxIdle
+		transport.MaxIdleConnsPerHost = maxIdlePerHost
+		transport.IdleConnTimeout = idleTimeout
+		client := *c.client
+		client.Transport = transport
+		c.client = &client
+		return nil
+	}
+}
+
+// cloneTransport returns a copy of the client transport, so it can be tuned
+// without altering the transport shared with other clients
+func cloneTransport(client *http.Client) (*http.Transport, error) {
+	switch transport := client.Transport.(type) {
+	case nil:
+		return http.DefaultTransport.(*http.Transport).Clone(), nil
+	case *http.Transport:
+		return transport.Clone(), nil
+	default:
+		return nil, fmt.Errorf("unsupported HTTP transport %T, an *http.Transport is required", transport)
+	}
+}
+
 func withHubToken(token string) RequestOp {
 	return func(req *http.Request) error {
 		req.Header["Authorization"] = []string{fmt.Sprintf("Bearer %s", token)}
@@ -202,8 +364,18 @@ func WithSortingOrder(order string) RequestOp {
 	}
 }
 
+// TwoFactorCode returns a 2FA code provider for Login always answering code,
+// for the non interactive logins where the code is known up front
+func TwoFactorCode(code string) func() (string, error) {
+	return func() (string, error) {
+		return code, nil
+	}
+}
+
 // Login tries to authenticate, it will call the twoFactorCodeProvider if the
-// user has 2FA activated
+// user has 2FA activated. Without a provider ErrTwoFactorRequired is
+// returned instead. The session is saved in the credentials store set with
+// WithCredentialStore.
 func (c *Client) Login(username string, password string, twoFactorCodeProvider func() (string, error)) (string, string, error) {
 	data, err := json.Marshal(types.AuthConfig{
 		Username: username,
@@ -235,6 +407,7 @@ func (c *Client) Login(username string, password string, twoFactorCodeProvider f
 		if err := json.Unmarshal(buf, &creds); err != nil {
 			return "", "", err
 		}
+		c.saveCredentials(username, password, creds.Token, "")
 		return creds.Token, "", nil
 	} else if resp.StatusCode == http.StatusUnauthorized {
 		response2FA := twoFactorResponse{}
@@ -245,7 +418,12 @@ func (c *Client) Login(username string, password string, twoFactorCodeProvider f
 		if response2FA.Detail != SecondFactorDetailMessage {
 			return "", "", fmt.Errorf(response2FA.Detail)
 		}
-		return c.getTwoFactorToken(response2FA.Login2FAToken, twoFactorCodeProvider)
+		token, refreshToken, err := c.getTwoFactorToken(response2FA.Login2FAToken, twoFactorCodeProvider)
+		if err != nil {
+			return "", "", err
+		}
+		c.saveCredentials(username, password, token, refreshToken)
+		return token, refreshToken, nil
 	}
 	if ok, err := extractError(buf, resp); ok {
 		return "", "", err
@@ -254,10 +432,17 @@ func (c *Client) Login(username string, password string, twoFactorCodeProvider f
 }
 
 func (c *Client) getTwoFactorToken(token string, twoFactorCodeProvider func() (string, error)) (string, string, error) {
+	if twoFactorCodeProvider == nil {
+		return "", "", ErrTwoFactorRequired
+	}
 	code, err := twoFactorCodeProvider()
 	if err != nil {
 		return "", "", err
 	}
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return "", "", ErrTwoFactorRequired
+	}
 
 	body2FA := twoFactorRequest{
 		Code:          code,
@@ -295,11 +480,18 @@ func (c *Client) getTwoFactorToken(token string, twoFactorCodeProvider func() (s
 
 		return creds.Token, creds.RefreshToken, nil
 	}
+	if ok, err := extractError(buf, resp); ok {
+		return "", "", err
+	}
 
 	return "", "", fmt.Errorf("failed to authenticate: bad status code %q: %s", resp.Status, string(buf))
 }
 
 func (c *Client) doRequest(req *http.Request, reqOps ...RequestOp) ([]byte, error) {
+	if skip, body, err := c.skipInDryRun(req); skip {
+		// The request body is echoed back as the response
+		return body, err
+	}
 	log.Debugf("HTTP %s on: %s", req.Method, req.URL)
 	log.Tracef("HTTP request: %+v", req)
 	resp, err := c.doRawRequest(req, reqOps...)
@@ -310,6 +502,7 @@ func (c *Client) doRequest(req *http.Request, reqOps ...RequestOp) ([]byte, erro
 		defer resp.Body.Close() //nolint:errcheck
 	}
 	log.Tracef("HTTP response: %+v", resp)
+	c.recordDeprecation(req, resp)
 
 	if resp.StatusCode == http.StatusNotFound {
 		return nil, &notFoundError{}
@@ -319,14 +512,13 @@ func (c *Client) doRequest(req *http.Request, reqOps ...RequestOp) ([]byte, erro
 		if resp.StatusCode == http.StatusForbidden {
 			return nil, &forbiddenError{}
 		}
-		buf, err := ioutil.ReadAll(resp.Body)
-		log.Debugf("bad status code %q: %s", resp.Status, buf)
-		if err == nil {
-			if ok, err := extractError(buf, resp); ok {
-				return nil, err
-			}
+		if resp.StatusCode == http.StatusTooManyRequests {
+			retryAfter, _ := parseRetryAfter(resp.Header.Get("Retry-After"))
+			return nil, &tooManyRequestsError{retryAfter: retryAfter}
 		}
-		return nil, fmt.Errorf("bad status code %q: %s", resp.Status, buf)
+		buf, _ := ioutil.ReadAll(resp.Body)
+		log.Debugf("bad status code %q: %s", resp.Status, buf)
+		return nil, newAPIError(resp, buf)
 	}
 	buf, err := ioutil.ReadAll(resp.Body)
 	log.Tracef("HTTP response body: %s", buf)
@@ -336,7 +528,7 @@ func (c *Client) doRequest(req *http.Request, reqOps ...RequestOp) ([]byte, erro
 	return buf, nil
 }
 
-func (c *Client) doRawRequest(req *http.Request, reqOps ...RequestOp) (*http.Response, error) {
+func (c *Client) doRawRequest(req *http.Request, reqOps ...RequestOp) (resp *http.Response, err error) {
 	req.Header["Accept"] = []string{"application/json"}
 	req.Header["Content-Type"] = []string{"application/json"}
 	req.Header["User-Agent"] = []string{fmt.Sprintf("hub-tool/%s", internal.Version)}
@@ -345,10 +537,52 @@ func (c *Client) doRawRequest(req *http.Request, reqOps ...RequestOp) (*http.Res
 			return nil, err
 		}
 	}
-	if c.Ctx != nil {
+	if c.Ctx != nil && req.Context() == context.Background() {
 		req = req.WithContext(c.Ctx)
 	}
-	return c.client.Do(req)
+	if c.tracer != nil {
+		var span Span
+		req, span = c.startSpan(req)
+		defer func() { endSpan(span, resp, err) }()
+	}
+	start := time.Now()
+	defer func() {
+		c.recordLatency(req, time.Since(start))
+		c.emitEvent(req, resp, err, start)
+	}()
+	for attempt := 0; ; attempt++ {
+		attemptStart := time.Now()
+		resp, err = c.send(req)
+		c.logRequest(req, resp, err, attempt+1, time.Since(attemptStart))
+		if attempt >= c.maxRetries || !c.shouldRetry(req, resp, err) {
+			return resp, err
+		}
+		delay := c.retryDelay(attempt, resp)
+		if resp != nil {
+			_, _ = io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close() //nolint:errcheck
+		}
+		log.Debugf("HTTP %s on %s failed, retrying in %s (%d/%d)", req.Method, req.URL, delay, attempt+1, c.maxRetries)
+		if err := rewindBody(req); err != nil {
+			return nil, err
+		}
+		if err := sleep(req.Context(), delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (c *Client) reportPage(fetched, total int) {
+	if c.onPage != nil {
+		c.onPage(fetched, total)
+	}
+}
+
+func registryURL(name string) string {
+	if strings.HasPrefix(name, "http://") || strings.HasPrefix(name, "https://") {
+		return strings.TrimSuffix(name, "/")
+	}
+	return "https://" + strings.TrimSuffix(name, "/")
 }
 
 func extractError(buf []byte, resp *http.Response) (bool, error) {
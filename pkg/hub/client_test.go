@@ -17,9 +17,13 @@
 package hub
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"gotest.tools/v3/assert"
 
@@ -39,3 +43,171 @@ func TestDoRequestAddsCustomUserAgent(t *testing.T) {
 	_, err = client.doRequest(req)
 	assert.NilError(t, err)
 }
+
+func TestWithConnectionPool(t *testing.T) {
+	client, err := NewClient(WithConnectionPool(200, 50, time.Minute))
+	assert.NilError(t, err)
+	transport, ok := client.client.Transport.(*http.Transport)
+	assert.Assert(t, ok)
+	assert.Equal(t, transport.MaxIdleConns, 200)
+	assert.Equal(t, transport.MaxIdleConnsPerHost, 50)
+	assert.Equal(t, transport.IdleConnTimeout, time.Minute)
+	assert.Assert(t, http.DefaultClient.Transport == nil, "the default client must be left untouched")
+	assert.Equal(t, http.DefaultTransport.(*http.Transport).MaxIdleConnsPerHost, 0)
+
+	_, err = NewClient(WithConnectionPool(-1, 0, 0))
+	assert.ErrorContains(t, err, "invalid connection pool settings")
+}
+
+type countingTransport struct {
+	requests int
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests++
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestWithHTTPClientAndTransport(t *testing.T) {
+	server := httptest.NewServer(serveTokens(t, hubTokenResult{UUID: "6b2e8c4a-1f0e-4c8e-9a4f-000000000001"}))
+	defer server.Close()
+
+	transport := &countingTransport{}
+	httpClient := &http.Client{Transport: transport, Timeout: time.Minute}
+	client, err := NewClient(WithHTTPClient(httpClient))
+	assert.NilError(t, err)
+	client.domain = server.URL
+	_, _, err = client.GetTokens()
+	assert.NilError(t, err)
+	assert.Equal(t, transport.requests, 1)
+
+	other := &countingTransport{}
+	assert.NilError(t, client.Update(WithTransport(other)))
+	_, _, err = client.GetTokens()
+	assert.NilError(t, err)
+	assert.Equal(t, other.requests, 1)
+	assert.Equal(t, client.client.Timeout, time.Minute, "the client settings should be kept")
+	assert.Assert(t, httpClient.Transport == transport, "the given client must be left untouched")
+
+	_, err = NewClient(WithHTTPClient(nil))
+	assert.Error(t, err, "invalid HTTP client: nil")
+	_, err = NewClient(WithTransport(nil))
+	assert.Error(t, err, "invalid HTTP transport: nil")
+}
+
+func TestClientEvents(t *testing.T) {
+	client := newTestClient(t, serveTokens(t, hubTokenResult{UUID: "6b2e8c4a-1f0e-4c8e-9a4f-000000000001"}))
+	events := make(chan ClientEvent, 1)
+	client.Events = events
+
+	_, _, err := client.GetTokens()
+	assert.NilError(t, err)
+
+	event := <-events
+	assert.Equal(t, event.Operation, http.MethodGet)
+	assert.Equal(t, event.Target, TokensURL)
+	assert.Equal(t, event.Status, http.StatusOK)
+	assert.NilError(t, event.Err)
+	assert.Assert(t, event.Duration > 0)
+}
+
+func TestClientEventsDoNotBlock(t *testing.T) {
+	client := newTestClient(t, serveTokens(t, hubTokenResult{UUID: "6b2e8c4a-1f0e-4c8e-9a4f-000000000001"}))
+	// Nobody reads this channel, events must be dropped
+	client.Events = make(chan ClientEvent)
+
+	_, _, err := client.GetTokens()
+	assert.NilError(t, err)
+}
+
+func serveTwoFactorLogin(t *testing.T, codes *[]string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v2/users/login", func(w http.ResponseWriter, r *http.Request) {
+		var auth map[string]string
+		assert.NilError(t, json.NewDecoder(r.Body).Decode(&auth))
+		assert.Equal(t, auth["username"], "user")
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprintf(w, `{"detail": %q, "login_2fa_token": "2fa-token"}`, SecondFactorDetailMessage)
+	})
+	mux.HandleFunc("/v2/users/2fa-login", func(w http.ResponseWriter, r *http.Request) {
+		var body twoFactorRequest
+		assert.NilError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, body.Login2FAToken, "2fa-token")
+		*codes = append(*codes, body.Code)
+		if body.Code != "123456" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"detail": "Incorrect authentication credentials"}`)
+			return
+		}
+		fmt.Fprint(w, `{"token": "session", "refresh_token": "refresh"}`)
+	})
+	return mux
+}
+
+func TestLoginWithTwoFactor(t *testing.T) {
+	var codes []string
+	client := newTestClient(t, serveTwoFactorLogin(t, &codes))
+
+	prompted := 0
+	token, refreshToken, err := client.Login("user", "password", func() (string, error) {
+		prompted++
+		return "123456\n", nil
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, token, "session")
+	assert.Equal(t, refreshToken, "refresh")
+	assert.Equal(t, prompted, 1)
+	assert.DeepEqual(t, codes, []string{"123456"})
+}
+
+func TestLoginWithTwoFactorCodeUpFront(t *testing.T) {
+	var codes []string
+	client := newTestClient(t, serveTwoFactorLogin(t, &codes))
+
+	token, _, err := client.Login("user", "password", TwoFactorCode("123456"))
+	assert.NilError(t, err)
+	assert.Equal(t, token, "session")
+
+	_, _, err = client.Login("user", "password", TwoFactorCode("000000"))
+	assert.ErrorContains(t, err, "Incorrect authentication credentials")
+	assert.DeepEqual(t, codes, []string{"123456", "000000"})
+}
+
+func TestLoginWithTwoFactorWithoutCode(t *testing.T) {
+	var codes []string
+	client := newTestClient(t, serveTwoFactorLogin(t, &codes))
+
+	_, _, err := client.Login("user", "password", nil)
+	assert.Assert(t, errors.Is(err, ErrTwoFactorRequired))
+	_, _, err = client.Login("user", "password", TwoFactorCode(""))
+	assert.Assert(t, errors.Is(err, ErrTwoFactorRequired))
+	assert.Equal(t, len(codes), 0)
+}
+
+func TestWithRequestLogger(t *testing.T) {
+	calls := 0
+	client := newTestClient(t, serveFailures(1, &calls))
+	var requests []RequestInfo
+	assert.NilError(t, client.Update(
+		WithRetries(2, time.Millisecond),
+		WithRequestLogger(func(info RequestInfo) { requests = append(requests, info) }),
+	))
+
+	req, err := http.NewRequest("GET", client.domain+TokensURL, nil)
+	assert.NilError(t, err)
+	_, err = client.doRequest(req, withHubToken("secret-token"))
+	assert.NilError(t, err)
+
+	assert.Equal(t, len(requests), 2)
+	for i, info := range requests {
+		assert.Equal(t, info.Attempt, i+1)
+		assert.Equal(t, info.Method, http.MethodGet)
+		assert.Equal(t, info.URL, client.domain+TokensURL)
+		assert.Equal(t, info.Header.Get("Authorization"), redacted)
+		assert.NilError(t, info.Err)
+		assert.Assert(t, info.Duration > 0)
+	}
+	assert.Equal(t, requests[0].StatusCode, http.StatusServiceUnavailable)
+	assert.Equal(t, requests[1].StatusCode, http.StatusOK)
+	assert.Equal(t, req.Header.Get("Authorization"), "Bearer secret-token", "the request must be left untouched")
+}
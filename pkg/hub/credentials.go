@@ -0,0 +1,73 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	log "github.com/sirupsen/logrus"
+
+	"github.com/docker/hub-tool/pkg/credentials"
+)
+
+// WithCredentialStore loads the Hub credentials saved in store, and saves in
+// it the session tokens Login mints. When store is nil or can't be read, the
+// credentials are only kept in memory.
+func WithCredentialStore(store credentials.Store) ClientOp {
+	return func(c *Client) error {
+		if store == nil {
+			store = credentials.NewMemoryStore()
+		}
+		auth, err := store.GetAuth()
+		if err != nil {
+			log.Warnf("Failed to read the credentials store, credentials will only be kept in memory: %s", err)
+			c.credentialStore = credentials.NewMemoryStore()
+			return nil
+		}
+		c.credentialStore = store
+		if auth.Username != "" {
+			c.AuthConfig.Username = auth.Username
+			c.account = auth.Username
+		}
+		if auth.Password != "" {
+			c.password = auth.Password
+		}
+		if auth.RefreshToken != "" {
+			c.refreshToken = auth.RefreshToken
+		}
+		if auth.Token != "" && !auth.TokenExpired() {
+			c.token = auth.Token
+			c.whoAmI = nil
+		}
+		return nil
+	}
+}
+
+// saveCredentials stores the credentials of a successful login in the
+// credentials store, when one is configured. A failure doesn't fail the
+// login, the session is then only kept in memory.
+func (c *Client) saveCredentials(username, password, token, refreshToken string) {
+	if c.credentialStore == nil {
+		return
+	}
+	if err := c.credentialStore.Store(credentials.Auth{
+		Username:     username,
+		Password:     password,
+		Token:        token,
+		RefreshToken: refreshToken,
+	}); err != nil {
+		log.Warnf("Failed to save the credentials, they will only be kept in memory: %s", err)
+	}
+}
@@ -0,0 +1,125 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+	"gotest.tools/v3/assert"
+
+	"github.com/docker/hub-tool/pkg/credentials"
+)
+
+func newTestSessionToken(t *testing.T, expiry time.Time) string {
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.HS256, Key: []byte("0123456789abcdef0123456789abcdef")}, nil)
+	assert.NilError(t, err)
+	token, err := jwt.Signed(signer).Claims(jwt.Claims{Expiry: jwt.NewNumericDate(expiry)}).CompactSerialize()
+	assert.NilError(t, err)
+	return token
+}
+
+func setTestDockerConfig(t *testing.T) string {
+	dir := t.TempDir()
+	previous, set := os.LookupEnv("DOCKER_CONFIG")
+	assert.NilError(t, os.Setenv("DOCKER_CONFIG", dir))
+	t.Cleanup(func() {
+		if set {
+			_ = os.Setenv("DOCKER_CONFIG", previous)
+		} else {
+			_ = os.Unsetenv("DOCKER_CONFIG")
+		}
+	})
+	return dir
+}
+
+func TestWithCredentialStoreSavesAndLoadsSession(t *testing.T) {
+	dir := setTestDockerConfig(t)
+	session := newTestSessionToken(t, time.Now().Add(time.Hour))
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v2/users/login", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"token": %q}`, session)
+	})
+	client := newTestClient(t, mux)
+	store, err := credentials.NewDockerConfigStore()
+	assert.NilError(t, err)
+	assert.NilError(t, client.Update(WithCredentialStore(store)))
+
+	_, _, err = client.Login("user", "password", nil)
+	assert.NilError(t, err)
+	config, err := ioutil.ReadFile(filepath.Join(dir, "config.json"))
+	assert.NilError(t, err)
+	assert.Assert(t, len(config) > 0)
+
+	store, err = credentials.NewDockerConfigStore()
+	assert.NilError(t, err)
+	loaded, err := NewClient(WithCredentialStore(store))
+	assert.NilError(t, err)
+	assert.Equal(t, loaded.account, "user")
+	assert.Equal(t, loaded.password, "password")
+	assert.Equal(t, loaded.token, session)
+}
+
+func TestWithCredentialStoreSkipsExpiredToken(t *testing.T) {
+	store := credentials.NewMemoryStore()
+	assert.NilError(t, store.Store(credentials.Auth{
+		Username:     "user",
+		Token:        newTestSessionToken(t, time.Now().Add(-time.Hour)),
+		RefreshToken: "refresh",
+	}))
+
+	client, err := NewClient(WithCredentialStore(store))
+	assert.NilError(t, err)
+	assert.Equal(t, client.account, "user")
+	assert.Equal(t, client.refreshToken, "refresh")
+	assert.Equal(t, client.token, "")
+}
+
+type failingStore struct{}
+
+func (failingStore) GetAuth() (*credentials.Auth, error) {
+	return nil, fmt.Errorf("credential helper not found")
+}
+
+func (failingStore) Store(credentials.Auth) error {
+	return fmt.Errorf("credential helper not found")
+}
+
+func (failingStore) Erase() error {
+	return fmt.Errorf("credential helper not found")
+}
+
+func TestWithCredentialStoreFallsBackToMemory(t *testing.T) {
+	client, err := NewClient(WithCredentialStore(failingStore{}))
+	assert.NilError(t, err)
+	client.saveCredentials("user", "password", "token", "refresh")
+
+	auth, err := client.credentialStore.GetAuth()
+	assert.NilError(t, err)
+	assert.Equal(t, auth.Token, "token")
+
+	client, err = NewClient(WithCredentialStore(nil))
+	assert.NilError(t, err)
+	assert.Assert(t, client.credentialStore != nil)
+}
@@ -0,0 +1,238 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// DeviceAuthDomain is the authorization server of the Docker accounts
+	DeviceAuthDomain = "https://login.docker.com"
+	// DeviceCodeURL path to the authorization server issuing device codes
+	DeviceCodeURL = "/oauth/device/code"
+	// DeviceTokenURL path to the authorization server exchanging a device
+	// code for a token
+	DeviceTokenURL = "/oauth/token"
+
+	deviceClientID   = "L4v0dmlNBpYUjGGab0C2JtgTgXr1Qz4d"
+	deviceAudience   = "https://hub.docker.com"
+	deviceScope      = "openid offline_access"
+	deviceGrantType  = "urn:ietf:params:oauth:grant-type:device_code"
+	refreshGrantType = "refresh_token"
+)
+
+var (
+	// ErrDeviceCodeExpired is returned when the user didn't approve the
+	// device login before its code expired
+	ErrDeviceCodeExpired = errors.New("the device code expired before the login was approved")
+	// ErrDeviceAccessDenied is returned when the user denied the device login
+	ErrDeviceAccessDenied = errors.New("the device login was denied")
+
+	// deviceIntervalUnit is the unit of the polling intervals returned by
+	// the authorization server, only changed by the tests
+	deviceIntervalUnit = time.Second
+)
+
+// DeviceCode is a pending device login. The user approves it by visiting
+// VerificationURI and entering UserCode, or by visiting
+// VerificationURIComplete.
+type DeviceCode struct {
+	UserCode                string
+	VerificationURI         string
+	VerificationURIComplete string
+	ExpiresAt               time.Time
+
+	deviceCode string
+	interval   time.Duration
+}
+
+// LoginWithDeviceCode logs in with the OAuth device authorization flow: a
+// device code is requested, prompt is called to show it to the user, then
+// the authorization server is polled until the user approves the login. The
+// returned token is a bearer token, to be used with WithHubToken.
+func (c *Client) LoginWithDeviceCode(ctx context.Context, prompt func(*DeviceCode)) (string, string, error) {
+	code, err := c.RequestDeviceCode(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	prompt(code)
+	return c.PollDeviceToken(ctx, code)
+}
+
+// RequestDeviceCode starts a device login
+func (c *Client) RequestDeviceCode(ctx context.Context) (*DeviceCode, error) {
+	form := url.Values{}
+	form.Set("client_id", deviceClientID)
+	form.Set("audience", deviceAudience)
+	form.Set("scope", deviceScope)
+	var response hubDeviceCodeResponse
+	status, err := c.postDeviceForm(ctx, DeviceCodeURL, form, &response)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("failed to request a device code: %s", response.errorDescription())
+	}
+	interval := time.Duration(response.Interval) * deviceIntervalUnit
+	if interval <= 0 {
+		// Default interval of the device flow specification
+		interval = 5 * deviceIntervalUnit
+	}
+	return &DeviceCode{
+		UserCode:                response.UserCode,
+		VerificationURI:         response.VerificationURI,
+		VerificationURIComplete: response.VerificationURIComplete,
+		ExpiresAt:               time.Now().Add(time.Duration(response.ExpiresIn) * time.Second),
+		deviceCode:              response.DeviceCode,
+		interval:                interval,
+	}, nil
+}
+
+// PollDeviceToken waits for the user to approve a device login and returns
+// the token and the refresh token. The polling slows down when the
+// authorization server asks for it, and stops once the code expires.
+func (c *Client) PollDeviceToken(ctx context.Context, code *DeviceCode) (string, string, error) {
+	form := url.Values{}
+	form.Set("client_id", deviceClientID)
+	form.Set("grant_type", deviceGrantType)
+	form.Set("device_code", code.deviceCode)
+	interval := code.interval
+	for {
+		if time.Now().Add(interval).After(code.ExpiresAt) {
+			return "", "", ErrDeviceCodeExpired
+		}
+		select {
+		case <-ctx.Done():
+			return "", "", ctx.Err()
+		case <-time.After(interval):
+		}
+
+		var response hubDeviceTokenResponse
+		status, err := c.postDeviceForm(ctx, DeviceTokenURL, form, &response)
+		if err != nil {
+			return "", "", err
+		}
+		if status == http.StatusOK {
+			return response.AccessToken, response.RefreshToken, nil
+		}
+		switch response.Error {
+		case "authorization_pending":
+		case "slow_down":
+			interval += 5 * deviceIntervalUnit
+		case "expired_token":
+			return "", "", ErrDeviceCodeExpired
+		case "access_denied":
+			return "", "", ErrDeviceAccessDenied
+		default:
+			return "", "", fmt.Errorf("device login failed: %s", response.errorDescription())
+		}
+	}
+}
+
+// RefreshDeviceToken exchanges the refresh token of a device login for a new
+// token, without the user having to approve a new login. The returned
+// refresh token replaces the previous one when the authorization server
+// rotates it.
+func (c *Client) RefreshDeviceToken(ctx context.Context, refreshToken string) (string, string, error) {
+	if refreshToken == "" {
+		return "", "", errors.New("no refresh token to renew the device login")
+	}
+	form := url.Values{}
+	form.Set("client_id", deviceClientID)
+	form.Set("grant_type", refreshGrantType)
+	form.Set("refresh_token", refreshToken)
+	var response hubDeviceTokenResponse
+	status, err := c.postDeviceForm(ctx, DeviceTokenURL, form, &response)
+	if err != nil {
+		return "", "", err
+	}
+	if status != http.StatusOK {
+		return "", "", fmt.Errorf("failed to refresh the device login: %s", response.errorDescription())
+	}
+	if response.RefreshToken == "" {
+		response.RefreshToken = refreshToken
+	}
+	return response.AccessToken, response.RefreshToken, nil
+}
+
+func (c *Client) postDeviceForm(ctx context.Context, path string, form url.Values, v interface{}) (int, error) {
+	domain := c.deviceAuthDomain
+	if domain == "" {
+		domain = DeviceAuthDomain
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", domain+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return 0, err
+	}
+	resp, err := c.doRawRequest(req, withFormContent)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+	buf, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return 0, err
+	}
+	if err := json.Unmarshal(buf, v); err != nil {
+		return 0, fmt.Errorf("unexpected response from the authorization server, status %q: %s", resp.Status, buf)
+	}
+	return resp.StatusCode, nil
+}
+
+// withFormContent overrides the JSON content type set by doRawRequest, the
+// authorization server only accepts form encoded requests
+func withFormContent(req *http.Request) error {
+	req.Header["Content-Type"] = []string{"application/x-www-form-urlencoded"}
+	return nil
+}
+
+type hubDeviceError struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+func (e hubDeviceError) errorDescription() string {
+	if e.ErrorDescription != "" {
+		return e.ErrorDescription
+	}
+	return e.Error
+}
+
+type hubDeviceCodeResponse struct {
+	hubDeviceError
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval"`
+}
+
+type hubDeviceTokenResponse struct {
+	hubDeviceError
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+}
@@ -0,0 +1,152 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func newTestDeviceLogin(t *testing.T, tokenResponses ...string) (*Client, *int) {
+	t.Helper()
+	previous := deviceIntervalUnit
+	deviceIntervalUnit = time.Millisecond
+	t.Cleanup(func() { deviceIntervalUnit = previous })
+
+	polls := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc(DeviceCodeURL, func(w http.ResponseWriter, r *http.Request) {
+		assert.NilError(t, r.ParseForm())
+		assert.Equal(t, r.PostForm.Get("client_id"), deviceClientID)
+		assert.Equal(t, r.PostForm.Get("audience"), "https://hub.docker.com")
+		fmt.Fprint(w, `{"device_code": "device", "user_code": "ABCD-EFGH", "verification_uri": "https://login.docker.com/activate",
+			"verification_uri_complete": "https://login.docker.com/activate?user_code=ABCD-EFGH", "expires_in": 60, "interval": 1}`)
+	})
+	mux.HandleFunc(DeviceTokenURL, func(w http.ResponseWriter, r *http.Request) {
+		assert.NilError(t, r.ParseForm())
+		assert.Equal(t, r.PostForm.Get("grant_type"), "urn:ietf:params:oauth:grant-type:device_code")
+		assert.Equal(t, r.PostForm.Get("device_code"), "device")
+		response := tokenResponses[polls]
+		polls++
+		if response[0] == '{' && response[2] == 'e' {
+			w.WriteHeader(http.StatusForbidden)
+		}
+		fmt.Fprint(w, response)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	client, err := NewClient()
+	assert.NilError(t, err)
+	client.deviceAuthDomain = server.URL
+	return client, &polls
+}
+
+func TestLoginWithDeviceCode(t *testing.T) {
+	client, polls := newTestDeviceLogin(t,
+		`{"error": "authorization_pending"}`,
+		`{"error": "slow_down"}`,
+		`{"error": "authorization_pending"}`,
+		`{"access_token": "access", "refresh_token": "refresh"}`,
+	)
+
+	var prompted *DeviceCode
+	token, refreshToken, err := client.LoginWithDeviceCode(context.Background(), func(code *DeviceCode) {
+		prompted = code
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, token, "access")
+	assert.Equal(t, refreshToken, "refresh")
+	assert.Equal(t, *polls, 4)
+	assert.Equal(t, prompted.UserCode, "ABCD-EFGH")
+	assert.Equal(t, prompted.VerificationURI, "https://login.docker.com/activate")
+}
+
+func TestLoginWithDeviceCodeErrors(t *testing.T) {
+	testCases := []struct {
+		name     string
+		response string
+		err      error
+	}{
+		{name: "expired", response: `{"error": "expired_token"}`, err: ErrDeviceCodeExpired},
+		{name: "denied", response: `{"error": "access_denied"}`, err: ErrDeviceAccessDenied},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			client, _ := newTestDeviceLogin(t, testCase.response)
+			_, _, err := client.LoginWithDeviceCode(context.Background(), func(*DeviceCode) {})
+			assert.Assert(t, errors.Is(err, testCase.err))
+		})
+	}
+}
+
+func TestPollDeviceTokenStopsWhenExpired(t *testing.T) {
+	client, polls := newTestDeviceLogin(t)
+
+	code := &DeviceCode{deviceCode: "device", interval: time.Millisecond, ExpiresAt: time.Now()}
+	_, _, err := client.PollDeviceToken(context.Background(), code)
+	assert.Assert(t, errors.Is(err, ErrDeviceCodeExpired))
+	assert.Equal(t, *polls, 0)
+}
+
+func TestRefreshDeviceToken(t *testing.T) {
+	testCases := []struct {
+		name         string
+		response     string
+		token        string
+		refreshToken string
+		err          string
+	}{
+		{name: "rotated", response: `{"access_token": "access", "refresh_token": "rotated"}`, token: "access", refreshToken: "rotated"},
+		{name: "kept", response: `{"access_token": "access"}`, token: "access", refreshToken: "refresh"},
+		{name: "revoked", response: `{"error": "invalid_grant", "error_description": "Unknown or invalid refresh token."}`, err: "failed to refresh the device login: Unknown or invalid refresh token."},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, r.URL.Path, DeviceTokenURL)
+				assert.NilError(t, r.ParseForm())
+				assert.Equal(t, r.PostForm.Get("client_id"), deviceClientID)
+				assert.Equal(t, r.PostForm.Get("grant_type"), "refresh_token")
+				assert.Equal(t, r.PostForm.Get("refresh_token"), "refresh")
+				if testCase.err != "" {
+					w.WriteHeader(http.StatusForbidden)
+				}
+				fmt.Fprint(w, testCase.response)
+			}))
+			defer server.Close()
+			client, err := NewClient()
+			assert.NilError(t, err)
+			client.deviceAuthDomain = server.URL
+
+			token, refreshToken, err := client.RefreshDeviceToken(context.Background(), "refresh")
+			if testCase.err != "" {
+				assert.Error(t, err, testCase.err)
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, token, testCase.token)
+			assert.Equal(t, refreshToken, testCase.refreshToken)
+		})
+	}
+}
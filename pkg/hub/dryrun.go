@@ -0,0 +1,79 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// WithDryRun makes the client only log the requests changing or deleting
+// resources instead of sending them. The mutating methods return a synthetic
+// result built from the request, read-only methods are unaffected.
+func WithDryRun() ClientOp {
+	return func(c *Client) error {
+		c.dryRun = true
+		return nil
+	}
+}
+
+// skipInDryRun reports if the request must not be sent because it would
+// change a resource in dry run mode, and logs it with its credentials
+// redacted. The request body is returned to build the synthetic response.
+func (c *Client) skipInDryRun(req *http.Request) (bool, []byte, error) {
+	if !c.dryRun {
+		return false, nil, nil
+	}
+	switch req.Method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+	default:
+		return false, nil, nil
+	}
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = ioutil.ReadAll(req.Body); err != nil {
+			return true, nil, err
+		}
+		req.Body.Close() //nolint:errcheck
+	}
+	if len(body) > 0 {
+		log.Infof("Dry run, not sending %s %s: %s", req.Method, req.URL, sensitiveFields.ReplaceAll(body, []byte(`$1"`+redacted+`"`)))
+	} else {
+		log.Infof("Dry run, not sending %s %s", req.Method, req.URL)
+	}
+	return true, body, nil
+}
+
+// dryRunResponse is the synthetic response of a registry request skipped in
+// dry run mode, with the status code the registry answers on success
+func dryRunResponse(req *http.Request) *http.Response {
+	status := http.StatusCreated
+	if req.Method == http.MethodDelete {
+		status = http.StatusAccepted
+	}
+	return &http.Response{
+		Status:     http.StatusText(status),
+		StatusCode: status,
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(bytes.NewReader(nil)),
+		Request:    req,
+	}
+}
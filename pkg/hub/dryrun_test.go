@@ -0,0 +1,133 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"gotest.tools/v3/assert"
+)
+
+const dryRunTokenUUID = "6b2e8c4a-1f0e-4c8e-9a4f-000000000001"
+
+func serveDryRunTokens(t *testing.T, requests *[]string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		*requests = append(*requests, r.Method+" "+r.URL.Path)
+		if r.Method != http.MethodGet {
+			t.Errorf("unexpected %s %s in dry run mode", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		result := hubTokenResult{UUID: dryRunTokenUUID, TokenLabel: "ci", IsActive: true}
+		if r.URL.Path == TokensURL {
+			assert.NilError(t, json.NewEncoder(w).Encode(hubTokenResponse{Count: 1, Results: []hubTokenResult{result}}))
+			return
+		}
+		assert.NilError(t, json.NewEncoder(w).Encode(result))
+	})
+	return mux
+}
+
+func newDryRunClient(t *testing.T, requests *[]string) *Client {
+	client := newTestClient(t, serveDryRunTokens(t, requests))
+	assert.NilError(t, client.Update(WithDryRun()))
+	return client
+}
+
+func TestDryRunCreateToken(t *testing.T) {
+	var requests []string
+	client := newDryRunClient(t, &requests)
+
+	token, err := client.CreateToken("preview", WithScopes(ScopeRepoRead))
+	assert.NilError(t, err)
+	assert.Equal(t, token.UUID, uuid.Nil)
+	assert.Equal(t, token.Description, "preview")
+	assert.Equal(t, token.IsActive, true)
+	assert.DeepEqual(t, token.Scopes, []string{ScopeRepoRead})
+	assert.Equal(t, token.Token, "")
+	assert.Equal(t, len(requests), 0)
+}
+
+func TestDryRunUpdateToken(t *testing.T) {
+	var requests []string
+	client := newDryRunClient(t, &requests)
+
+	token, err := client.UpdateToken(dryRunTokenUUID, "renamed", false)
+	assert.NilError(t, err)
+	assert.Equal(t, token.UUID.String(), dryRunTokenUUID)
+	assert.Equal(t, token.Description, "renamed")
+	assert.Equal(t, token.IsActive, false)
+
+	token, err = client.SetTokenActive(dryRunTokenUUID, false)
+	assert.NilError(t, err)
+	assert.Equal(t, token.Description, "ci", "the description must be left untouched")
+	assert.Equal(t, token.IsActive, false)
+	assert.DeepEqual(t, requests, []string{
+		"GET " + fmt.Sprintf(TokenURL, dryRunTokenUUID),
+		"GET " + fmt.Sprintf(TokenURL, dryRunTokenUUID),
+	})
+}
+
+func TestDryRunRevokeToken(t *testing.T) {
+	var requests []string
+	client := newDryRunClient(t, &requests)
+
+	assert.NilError(t, client.RemoveToken(dryRunTokenUUID))
+	assert.Equal(t, len(requests), 0)
+
+	assert.NilError(t, client.RevokeTokenByDescription("ci"))
+	assert.DeepEqual(t, requests, []string{"GET " + TokensURL})
+}
+
+func TestDryRunLeavesReadsUntouched(t *testing.T) {
+	var requests []string
+	client := newDryRunClient(t, &requests)
+
+	tokens, total, err := client.GetTokens()
+	assert.NilError(t, err)
+	assert.Equal(t, total, 1)
+	assert.Equal(t, tokens[0].Description, "ci")
+	assert.DeepEqual(t, requests, []string{"GET " + TokensURL})
+}
+
+func TestDryRunRedactsLoggedBody(t *testing.T) {
+	out := bytes.NewBuffer(nil)
+	log.SetOutput(out)
+	defer log.SetOutput(os.Stderr)
+	client, err := NewClient(WithDryRun())
+	assert.NilError(t, err)
+
+	body := `{"username": "alice", "password": "s3cret", "token": "dckr_pat_123"}`
+	req, err := http.NewRequest(http.MethodPost, "https://hub.docker.com/v2/users/login", strings.NewReader(body))
+	assert.NilError(t, err)
+	skip, sent, err := client.skipInDryRun(req)
+	assert.NilError(t, err)
+	assert.Assert(t, skip)
+	assert.Equal(t, string(sent), body, "the synthetic response still echoes the body")
+	assert.Assert(t, strings.Contains(out.String(), "alice"))
+	assert.Assert(t, !strings.Contains(out.String(), "s3cret"))
+	assert.Assert(t, !strings.Contains(out.String(), "dckr_pat_123"))
+}
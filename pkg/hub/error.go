@@ -16,7 +16,98 @@
 
 package hub
 
-import "fmt"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ErrIncompleteResults is returned along with the fetched elements when the
+// Hub API returned fewer elements than the total count it announced
+var ErrIncompleteResults = errors.New("incomplete results: the Hub API returned fewer elements than announced")
+
+// ErrScopesImmutable is returned when trying to change the scopes of an
+// existing token, which the Hub API doesn't allow
+var ErrScopesImmutable = errors.New("token scopes can't be changed, the token must be rotated instead")
+
+// ErrRepositoryNotFound is returned when the repository an operation targets
+// doesn't exist
+var ErrRepositoryNotFound = errors.New("repository not found")
+
+// ErrRepositoryAlreadyExists is returned when creating a repository which
+// already exists
+var ErrRepositoryAlreadyExists = errors.New("repository already exists")
+
+// ErrTagNotFound is returned when the tag an operation targets doesn't exist
+var ErrTagNotFound = errors.New("tag not found")
+
+// ErrPrivateRepositoryLimit is returned when the plan of the account doesn't
+// allow more private repositories
+var ErrPrivateRepositoryLimit = errors.New("the plan doesn't allow more private repositories")
+
+// ErrWebhookNotFound is returned when the webhook an operation targets
+// doesn't exist
+var ErrWebhookNotFound = errors.New("webhook not found")
+
+// ErrTwoFactorRequired is returned by Login when the account has 2FA
+// activated and no code provider was given
+var ErrTwoFactorRequired = errors.New("2FA is activated on the account, a 6 digit code is required")
+
+// ErrTeamAlreadyExists is returned when creating a team with the name of an
+// existing one
+var ErrTeamAlreadyExists = errors.New("team already exists")
+
+// ErrOrganizationAdminRequired is returned when an operation on an
+// organization requires the token of one of its owners
+var ErrOrganizationAdminRequired = errors.New("organization owner permissions are required, the token can't read the organization members")
+
+// ErrUnsupportedByServer is returned when an operation relies on a feature
+// the connected Hub API doesn't support, as reported by Client.Capabilities,
+// or doesn't expose through a documented endpoint
+var ErrUnsupportedByServer = errors.New("operation not supported by the Hub API server")
+
+// ErrInsufficientScopes is returned when the scopes of a token don't cover
+// the actions it was used for
+var ErrInsufficientScopes = errors.New("the token scopes don't cover all the observed actions")
+
+// APIError is returned when the Hub API answers with an error status, other
+// than the ones reported by the IsNotFoundError, IsForbiddenError and
+// IsTooManyRequestsError errors. Use errors.As to inspect it.
+type APIError struct {
+	// StatusCode is the HTTP status code of the response, e.g. 400
+	StatusCode int
+	// Status is the HTTP status of the response, e.g. "400 Bad Request"
+	Status string
+	// Message is the "message" field of the JSON error body, if any
+	Message string
+	// Detail is the "detail" field of the JSON error body, if any
+	Detail string
+	// Body is the raw response body
+	Body string
+}
+
+func (e *APIError) Error() string {
+	switch {
+	case e.Message != "":
+		return fmt.Sprintf("bad status code %q: %s", e.Status, e.Message)
+	case e.Detail != "":
+		return fmt.Sprintf("bad status code %q: %s", e.Status, e.Detail)
+	}
+	return fmt.Sprintf("bad status code %q: %s", e.Status, e.Body)
+}
+
+func newAPIError(resp *http.Response, body []byte) *APIError {
+	apiErr := &APIError{StatusCode: resp.StatusCode, Status: resp.Status, Body: string(body)}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err == nil {
+		// Non string fields are ignored
+		_ = json.Unmarshal(fields["message"], &apiErr.Message)
+		_ = json.Unmarshal(fields["detail"], &apiErr.Detail)
+	}
+	return apiErr
+}
 
 type authenticationError struct {
 }
@@ -51,10 +142,27 @@ func (f forbiddenError) Error() string {
 	return "operation not permitted"
 }
 
-// IsForbiddenError check if the error type is a forbidden error
+// IsForbiddenError check if the error, or an error it wraps, is a forbidden
+// error
 func IsForbiddenError(err error) bool {
-	_, ok := err.(*forbiddenError)
-	return ok
+	var target *forbiddenError
+	return errors.As(err, &target)
+}
+
+type tooManyRequestsError struct {
+	// retryAfter is the delay the Hub API asked to wait before sending new
+	// requests, zero if it didn't tell
+	retryAfter time.Duration
+}
+
+func (t tooManyRequestsError) Error() string {
+	return "too many requests, the Hub API rate limit was reached"
+}
+
+// IsTooManyRequestsError check if the error type is a too many requests error
+func IsTooManyRequestsError(err error) bool {
+	var target *tooManyRequestsError
+	return errors.As(err, &target)
 }
 
 type notFoundError struct{}
@@ -63,8 +171,9 @@ func (n notFoundError) Error() string {
 	return "resource not found"
 }
 
-// IsNotFoundError check if the error type is a not found error
+// IsNotFoundError check if the error, or an error it wraps, is a not found
+// error
 func IsNotFoundError(err error) bool {
-	_, ok := err.(*notFoundError)
-	return ok
+	var target *notFoundError
+	return errors.As(err, &target)
 }
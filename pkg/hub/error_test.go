@@ -18,6 +18,8 @@ package hub
 
 import (
 	"errors"
+	"fmt"
+	"net/http"
 	"testing"
 
 	"gotest.tools/v3/assert"
@@ -42,3 +44,35 @@ func TestIsNotFoundError(t *testing.T) {
 	assert.Assert(t, IsNotFoundError(&notFoundError{}))
 	assert.Assert(t, !IsNotFoundError(errors.New("")))
 }
+
+func TestIsTooManyRequestsError(t *testing.T) {
+	assert.Assert(t, IsTooManyRequestsError(&tooManyRequestsError{}))
+	assert.Assert(t, IsTooManyRequestsError(fmt.Errorf("page 2: %w", &tooManyRequestsError{})))
+	assert.Assert(t, !IsTooManyRequestsError(errors.New("")))
+}
+
+func TestAPIError(t *testing.T) {
+	testCases := []struct {
+		status   int
+		body     string
+		detail   string
+		expected string
+	}{
+		{http.StatusBadRequest, `{"detail": "token label already exists"}`, "token label already exists", `bad status code "400 Bad Request": token label already exists`},
+		{http.StatusConflict, `{"message": "conflict", "errinfo": {}}`, "", `bad status code "409 Conflict": conflict`},
+		{http.StatusInternalServerError, `oops`, "", `bad status code "500 Internal Server Error": oops`},
+	}
+	for _, tc := range testCases {
+		client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tc.status)
+			fmt.Fprint(w, tc.body)
+		}))
+		_, err := client.CreateToken("ci")
+		var apiErr *APIError
+		assert.Assert(t, errors.As(err, &apiErr))
+		assert.Equal(t, apiErr.StatusCode, tc.status)
+		assert.Equal(t, apiErr.Detail, tc.detail)
+		assert.Equal(t, apiErr.Body, tc.body)
+		assert.Error(t, err, tc.expected)
+	}
+}
@@ -0,0 +1,110 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"net/http"
+	"time"
+)
+
+// ClientEvent describes a single API call made by the client. Events are
+// emitted on Client.Events once the call completed, retries included.
+type ClientEvent struct {
+	// Operation is the HTTP method of the call
+	Operation string
+	// Target is the URL path of the call
+	Target string
+	// Status is the HTTP status code of the last response, 0 if none was
+	// received
+	Status int
+	// Duration is the time spent on the call, retries included
+	Duration time.Duration
+	// Err is the transport error of the call, if any. HTTP error statuses are
+	// only reported through Status.
+	Err error
+}
+
+// emitEvent sends the event to the client events channel without ever
+// blocking: if the channel is full the event is dropped.
+func (c *Client) emitEvent(req *http.Request, resp *http.Response, err error, start time.Time) {
+	if c.Events == nil {
+		return
+	}
+	event := ClientEvent{
+		Operation: req.Method,
+		Target:    req.URL.Path,
+		Duration:  time.Since(start),
+		Err:       err,
+	}
+	if resp != nil {
+		event.Status = resp.StatusCode
+	}
+	select {
+	case c.Events <- event:
+	default:
+	}
+}
+
+// RequestInfo describes a single HTTP request sent by the client. Unlike
+// ClientEvent, every attempt of a retried call is reported.
+type RequestInfo struct {
+	// Method is the HTTP method of the request
+	Method string
+	// URL is the full URL of the request
+	URL string
+	// Header holds the request headers, the credentials redacted
+	Header http.Header
+	// StatusCode is the HTTP status code of the response, 0 if none was
+	// received
+	StatusCode int
+	// Duration is the time spent on this attempt
+	Duration time.Duration
+	// Attempt is the number of the attempt, starting at 1
+	Attempt int
+	// Err is the transport error of the attempt, if any
+	Err error
+}
+
+// WithRequestLogger calls logger after every HTTP request sent by the client,
+// each retry included, so integrators can log them with the library of
+// their choice. The logger is called synchronously and must be safe for
+// concurrent use.
+func WithRequestLogger(logger func(RequestInfo)) ClientOp {
+	return func(c *Client) error {
+		c.requestLogger = logger
+		return nil
+	}
+}
+
+func (c *Client) logRequest(req *http.Request, resp *http.Response, err error, attempt int, duration time.Duration) {
+	if c.requestLogger == nil {
+		return
+	}
+	info := RequestInfo{
+		Method:   req.Method,
+		URL:      req.URL.String(),
+		Header:   req.Header.Clone(),
+		Duration: duration,
+		Attempt:  attempt,
+		Err:      err,
+	}
+	redactHeaders(info.Header)
+	if resp != nil {
+		info.StatusCode = resp.StatusCode
+	}
+	c.requestLogger(info)
+}
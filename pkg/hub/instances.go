@@ -17,7 +17,10 @@
 package hub
 
 import (
+	"fmt"
+	"net/url"
 	"os"
+	"strings"
 
 	"github.com/docker/docker/api/types/registry"
 )
@@ -40,6 +43,54 @@ var (
 	}
 )
 
+// WithDomain overrides the Hub API base URL. The domain may be given as a bare
+// host ("hub.docker.com"), with a trailing slash or with the "/v2" API prefix,
+// they all resolve to the same base URL. Only http and https schemes are
+// accepted.
+//
+// Docker Hub doesn't expose regional API endpoints, every region is served by
+// the global https://hub.docker.com endpoint used by default, so there is no
+// region option. WithDomain is meant for mirrors and test servers.
+func WithDomain(domain string) ClientOp {
+	return func(c *Client) error {
+		normalized, err := normalizeDomain(domain)
+		if err != nil {
+			return err
+		}
+		c.domain = normalized
+		return nil
+	}
+}
+
+// normalizeDomain turns the user given Hub API domain into a base URL the
+// endpoint paths (which all start with "/v2") can be appended to.
+func normalizeDomain(domain string) (string, error) {
+	domain = strings.TrimSpace(domain)
+	if domain == "" {
+		return "", fmt.Errorf("invalid Hub API domain: domain is empty")
+	}
+	if !strings.Contains(domain, "://") {
+		domain = "https://" + domain
+	}
+	u, err := url.Parse(domain)
+	if err != nil {
+		return "", fmt.Errorf("invalid Hub API domain %q: %w", domain, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("invalid Hub API domain %q: unsupported scheme %q, only http and https are supported", domain, u.Scheme)
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("invalid Hub API domain %q: missing host", domain)
+	}
+	path := strings.TrimRight(u.Path, "/")
+	path = strings.TrimSuffix(path, "/v2")
+	u.Path = strings.TrimRight(path, "/")
+	u.RawPath = ""
+	u.RawQuery = ""
+	u.Fragment = ""
+	return u.String(), nil
+}
+
 // getInstance returns the current hub instance, which can be overridden by
 // DOCKER_REGISTRY_URL and DOCKER_REGISTRY_URL env var
 func getInstance() *Instance {
@@ -47,6 +98,9 @@ func getInstance() *Instance {
 	reg := os.Getenv("DOCKER_REGISTRY_URL")
 
 	if apiBaseURL != "" && reg != "" {
+		if normalized, err := normalizeDomain(apiBaseURL); err == nil {
+			apiBaseURL = normalized
+		}
 		return &Instance{
 			APIHubBaseURL: apiBaseURL,
 			RegistryInfo: &registry.IndexInfo{
@@ -0,0 +1,57 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestWithDomain(t *testing.T) {
+	testCases := []struct {
+		domain   string
+		expected string
+	}{
+		{"hub.docker.com", "https://hub.docker.com"},
+		{"https://hub.docker.com", "https://hub.docker.com"},
+		{"https://hub.docker.com/", "https://hub.docker.com"},
+		{"https://hub.docker.com/v2", "https://hub.docker.com"},
+		{"https://hub.docker.com/v2/", "https://hub.docker.com"},
+		{"http://localhost:8080/v2", "http://localhost:8080"},
+		{"https://example.com/hub/v2", "https://example.com/hub"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.domain, func(t *testing.T) {
+			client, err := NewClient(WithDomain(tc.domain))
+			assert.NilError(t, err)
+			assert.Equal(t, client.domain, tc.expected)
+			assert.Equal(t, client.domain+TokensURL, tc.expected+"/v2/api_tokens")
+		})
+	}
+}
+
+func TestWithDomainInvalid(t *testing.T) {
+	_, err := NewClient(WithDomain("ftp://hub.docker.com"))
+	assert.Error(t, err, `invalid Hub API domain "ftp://hub.docker.com": unsupported scheme "ftp", only http and https are supported`)
+
+	_, err = NewClient(WithDomain(""))
+	assert.Error(t, err, "invalid Hub API domain: domain is empty")
+
+	_, err = NewClient(WithDomain("https://"))
+	assert.Error(t, err, `invalid Hub API domain "https://": missing host`)
+}
@@ -0,0 +1,148 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxLatencySamples is the number of most recent samples kept per operation
+// to compute the percentiles
+const maxLatencySamples = 1000
+
+// LatencyStat summarizes the latency of an operation
+type LatencyStat struct {
+	// Count is the number of calls recorded since the recording started
+	Count int
+	P50   time.Duration
+	P95   time.Duration
+	P99   time.Duration
+}
+
+type latencyRecorder struct {
+	lock    sync.Mutex
+	samples map[string]*latencySamples
+}
+
+type latencySamples struct {
+	count     int
+	durations []time.Duration
+	// next is the index of the oldest sample, overwritten once the buffer is
+	// full
+	next int
+}
+
+// WithLatencyRecording makes the client record the latency of its API calls,
+// see Client.LatencyStats.
+func WithLatencyRecording() ClientOp {
+	return func(c *Client) error {
+		c.latencies = &latencyRecorder{samples: map[string]*latencySamples{}}
+		return nil
+	}
+}
+
+// LatencyStats returns the latency percentiles of the token API calls per
+// operation: "create", "list", "get", "update" and "revoke". Percentiles are
+// computed over the last 1000 calls of each operation. It returns nil unless
+// the client was created with WithLatencyRecording.
+func (c *Client) LatencyStats() map[string]LatencyStat {
+	if c.latencies == nil {
+		return nil
+	}
+	c.latencies.lock.Lock()
+	defer c.latencies.lock.Unlock()
+	stats := make(map[string]LatencyStat, len(c.latencies.samples))
+	for operation, samples := range c.latencies.samples {
+		sorted := make([]time.Duration, len(samples.durations))
+		copy(sorted, samples.durations)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		stats[operation] = LatencyStat{
+			Count: samples.count,
+			P50:   percentile(sorted, 50),
+			P95:   percentile(sorted, 95),
+			P99:   percentile(sorted, 99),
+		}
+	}
+	return stats
+}
+
+func (c *Client) recordLatency(req *http.Request, duration time.Duration) {
+	if c.latencies == nil {
+		return
+	}
+	operation := c.latencyOperation(req)
+	if operation == "" {
+		return
+	}
+	c.latencies.lock.Lock()
+	defer c.latencies.lock.Unlock()
+	samples, ok := c.latencies.samples[operation]
+	if !ok {
+		samples = &latencySamples{}
+		c.latencies.samples[operation] = samples
+	}
+	samples.count++
+	if len(samples.durations) < maxLatencySamples {
+		samples.durations = append(samples.durations, duration)
+		return
+	}
+	samples.durations[samples.next] = duration
+	samples.next = (samples.next + 1) % maxLatencySamples
+}
+
+// latencyOperation names the token operation of a request, or returns an
+// empty string if the request isn't a call to the token API
+func (c *Client) latencyOperation(req *http.Request) string {
+	u := req.URL.String()
+	if !strings.HasPrefix(u, c.domain+TokensURL) {
+		return ""
+	}
+	rest := strings.TrimPrefix(u, c.domain+TokensURL)
+	collection := rest == "" || strings.HasPrefix(rest, "?")
+	if !collection && !strings.HasPrefix(rest, "/") {
+		return ""
+	}
+	switch {
+	case req.Method == http.MethodPost && collection:
+		return "create"
+	case req.Method == http.MethodGet && collection:
+		return "list"
+	case req.Method == http.MethodGet:
+		return "get"
+	case req.Method == http.MethodPatch:
+		return "update"
+	case req.Method == http.MethodDelete:
+		return "revoke"
+	}
+	return ""
+}
+
+// percentile returns the nearest-rank percentile of sorted durations
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
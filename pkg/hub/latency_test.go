@@ -0,0 +1,82 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestPercentile(t *testing.T) {
+	var sorted []time.Duration
+	for i := 1; i <= 100; i++ {
+		sorted = append(sorted, time.Duration(i)*time.Millisecond)
+	}
+	assert.Equal(t, percentile(sorted, 50), 50*time.Millisecond)
+	assert.Equal(t, percentile(sorted, 95), 95*time.Millisecond)
+	assert.Equal(t, percentile(sorted, 99), 99*time.Millisecond)
+	assert.Equal(t, percentile(sorted[:1], 99), time.Millisecond)
+	assert.Equal(t, percentile(nil, 50), time.Duration(0))
+}
+
+func TestLatencyStats(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		assert.NilError(t, json.NewEncoder(w).Encode(hubTokenResult{UUID: "6b2e8c4a-1f0e-4c8e-9a4f-000000000001"}))
+	}))
+	assert.Assert(t, client.LatencyStats() == nil, "recording should be opt-in")
+	assert.NilError(t, client.Update(WithLatencyRecording()))
+
+	id := "6b2e8c4a-1f0e-4c8e-9a4f-000000000001"
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := client.GetToken(id)
+			assert.NilError(t, err)
+		}()
+	}
+	wg.Wait()
+	_, err := client.CreateToken("ci")
+	assert.NilError(t, err)
+	assert.NilError(t, client.RemoveToken(id))
+	_, _, err = client.GetTokens()
+	assert.NilError(t, err)
+	_, err = client.UpdateToken(id, "ci", false)
+	assert.NilError(t, err)
+	_, err = client.GetRepository("org/img")
+	assert.NilError(t, err)
+
+	stats := client.LatencyStats()
+	assert.Equal(t, len(stats), 5, "only the token operations are recorded")
+	assert.Equal(t, stats["get"].Count, 10)
+	assert.Equal(t, stats["create"].Count, 1)
+	assert.Equal(t, stats["revoke"].Count, 1)
+	assert.Equal(t, stats["list"].Count, 1)
+	assert.Equal(t, stats["update"].Count, 1)
+	get := stats["get"]
+	assert.Assert(t, get.P50 > 0 && get.P50 <= get.P95 && get.P95 <= get.P99)
+}
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 )
 
@@ -29,12 +30,26 @@ const (
 	MembersURL = "/v2/orgs/%s/members/"
 	//MembersPerTeamURL path to the Hub API listing the members in a team
 	MembersPerTeamURL = "/v2/orgs/%s/groups/%s/members/"
+	//MemberURL path to the Hub API describing a member of an organization
+	MemberURL = "/v2/orgs/%s/members/%s/"
+
+	//MemberRoleOwner is the role of the organization owners
+	MemberRoleOwner = "owner"
+	//MemberRoleEditor is the role of the members who can manage repositories
+	MemberRoleEditor = "editor"
+	//MemberRoleMember is the role of the other members
+	MemberRoleMember = "member"
 )
 
 //Member is a user part of an organization
 type Member struct {
 	Username string `json:"username"`
 	FullName string `json:"full_name"`
+	//Role is one of MemberRoleOwner, MemberRoleEditor or MemberRoleMember,
+	//empty when the Hub API doesn't report it
+	Role       string    `json:"role,omitempty"`
+	DateJoined time.Time `json:"date_joined"`
+	IsGuest    bool      `json:"is_guest"`
 }
 
 //GetMembers lists all the members in an organization
@@ -44,27 +59,47 @@ func (c *Client) GetMembers(organization string) ([]Member, error) {
 		return nil, err
 	}
 	q := url.Values{}
-	q.Add("page_size", fmt.Sprintf("%v", itemsPerPage))
+	q.Add("page_size", fmt.Sprintf("%v", c.pageSizeOrDefault()))
 	q.Add("page", "1")
 	u.RawQuery = q.Encode()
 
-	members, next, err := c.getMembersPage(u.String())
+	members, total, next, err := c.getMembersPage(u.String())
 	if err != nil {
-		return nil, err
+		return nil, membersError(organization, err)
 	}
+	c.reportPage(len(members), total)
 
 	for next != "" {
-		pageMembers, n, err := c.getMembersPage(next)
+		pageMembers, _, n, err := c.getMembersPage(next)
 		if err != nil {
-			return nil, err
+			return nil, membersError(organization, err)
 		}
 		next = n
 		members = append(members, pageMembers...)
+		c.reportPage(len(members), total)
 	}
 
 	return members, nil
 }
 
+//GetMember returns a member of an organization
+func (c *Client) GetMember(organization, username string) (*Member, error) {
+	req, err := http.NewRequest("GET", c.domain+fmt.Sprintf(MemberURL, organization, username), nil)
+	if err != nil {
+		return nil, err
+	}
+	response, err := c.doRequest(req, withHubToken(c.token))
+	if err != nil {
+		return nil, membersError(organization, err)
+	}
+	var result hubMemberResult
+	if err := json.Unmarshal(response, &result); err != nil {
+		return nil, err
+	}
+	member := toMember(result)
+	return &member, nil
+}
+
 // GetMembersCount return the number of members in an organization
 func (c *Client) GetMembersCount(organization string) (int, error) {
 	u, err := url.Parse(c.domain + fmt.Sprintf(MembersURL, organization))
@@ -109,28 +144,57 @@ func (c *Client) GetMembersPerTeam(organization, team string) ([]Member, error)
 	return members, nil
 }
 
-func (c *Client) getMembersPage(url string) ([]Member, string, error) {
+func (c *Client) getMembersPage(url string) ([]Member, int, string, error) {
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
-		return nil, "", err
+		return nil, 0, "", err
 	}
 	response, err := c.doRequest(req, withHubToken(c.token))
 	if err != nil {
-		return nil, "", err
+		return nil, 0, "", err
 	}
 	var hubResponse hubMemberResponse
 	if err := json.Unmarshal(response, &hubResponse); err != nil {
-		return nil, "", err
+		return nil, 0, "", err
 	}
 	var members []Member
 	for _, result := range hubResponse.Results {
-		member := Member{
-			Username: result.UserName,
-			FullName: result.FullName,
-		}
-		members = append(members, member)
+		members = append(members, toMember(result))
+	}
+	return members, hubResponse.Count, hubResponse.Next, nil
+}
+
+func toMember(result hubMemberResult) Member {
+	return Member{
+		Username:   result.UserName,
+		FullName:   result.FullName,
+		Role:       memberRole(result.Role),
+		DateJoined: result.DateJoined,
+		IsGuest:    result.IsGuest,
+	}
+}
+
+// memberRole maps the role reported by the Hub API to one of the MemberRole
+// values, unknown roles are kept as is
+func memberRole(role string) string {
+	switch strings.ToLower(strings.TrimSpace(role)) {
+	case "owner", "owners":
+		return MemberRoleOwner
+	case "editor", "editors":
+		return MemberRoleEditor
+	case "member", "members":
+		return MemberRoleMember
+	}
+	return role
+}
+
+// membersError explains the forbidden errors, returned when listing the
+// members of an organization without being one of its owners
+func membersError(organization string, err error) error {
+	if IsForbiddenError(err) {
+		return fmt.Errorf("%w: %s", ErrOrganizationAdminRequired, organization)
 	}
-	return members, hubResponse.Next, nil
+	return err
 }
 
 type hubMemberResponse struct {
@@ -150,4 +214,6 @@ type hubMemberResult struct {
 	DateJoined  time.Time `json:"date_joined"`
 	ID          string    `json:"id"`
 	ProfileURL  string    `json:"profile_url"`
+	Role        string    `json:"role,omitempty"`
+	IsGuest     bool      `json:"is_guest"`
 }
@@ -0,0 +1,89 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestGetMembers(t *testing.T) {
+	var client *Client
+	client = newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, r.URL.Path, fmt.Sprintf(MembersURL, "org"))
+		page, err := strconv.Atoi(r.URL.Query().Get("page"))
+		assert.NilError(t, err)
+		switch page {
+		case 1:
+			fmt.Fprintf(w, `{"count": 3, "next": "%s%s?page=2", "results": [
+				{"username": "alice", "full_name": "Alice", "role": "Owner", "date_joined": "2020-01-02T03:04:05Z"},
+				{"username": "bob", "role": "editor"}
+			]}`, client.domain, fmt.Sprintf(MembersURL, "org"))
+		case 2:
+			fmt.Fprint(w, `{"count": 3, "results": [
+				{"username": "carol", "role": "member", "is_guest": true}
+			]}`)
+		default:
+			t.Fatalf("unexpected page %d", page)
+		}
+	}))
+
+	members, err := client.GetMembers("org")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, members, []Member{
+		{Username: "alice", FullName: "Alice", Role: MemberRoleOwner, DateJoined: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{Username: "bob", Role: MemberRoleEditor},
+		{Username: "carol", Role: MemberRoleMember, IsGuest: true},
+	})
+}
+
+func TestGetMember(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case fmt.Sprintf(MemberURL, "org", "alice"):
+			fmt.Fprint(w, `{"username": "alice", "role": "owner"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	member, err := client.GetMember("org", "alice")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, member, &Member{Username: "alice", Role: MemberRoleOwner})
+
+	_, err = client.GetMember("org", "mallory")
+	assert.Assert(t, IsNotFoundError(err))
+}
+
+func TestMembersRequireOrganizationAdmin(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+
+	_, err := client.GetMembers("org")
+	assert.Assert(t, errors.Is(err, ErrOrganizationAdminRequired))
+	assert.ErrorContains(t, err, ": org")
+
+	_, err = client.GetMember("org", "alice")
+	assert.Assert(t, errors.Is(err, ErrOrganizationAdminRequired))
+}
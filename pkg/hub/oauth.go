@@ -0,0 +1,48 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"fmt"
+	"time"
+)
+
+//OAuthApp is an OAuth application the user granted access to its account
+type OAuthApp struct {
+	ID         string
+	Name       string
+	ClientID   string
+	Scopes     []string
+	CreatedAt  time.Time
+	LastUsed   time.Time
+	WebsiteURL string
+}
+
+//GetOAuthAuthorizations would list the OAuth applications authorized by the
+//user. The Hub API has no documented endpoint for the OAuth grants of an
+//account, they can only be reviewed from the Hub website, so
+//ErrUnsupportedByServer is always returned.
+func (c *Client) GetOAuthAuthorizations() ([]OAuthApp, error) {
+	return nil, fmt.Errorf("%w: OAuth application authorizations", ErrUnsupportedByServer)
+}
+
+//RevokeOAuthAuthorization would revoke the access granted to an OAuth
+//application. Like GetOAuthAuthorizations, it always returns
+//ErrUnsupportedByServer.
+func (c *Client) RevokeOAuthAuthorization(id string) error {
+	return fmt.Errorf("%w: OAuth application authorizations", ErrUnsupportedByServer)
+}
@@ -0,0 +1,37 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestOAuthAuthorizations(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+	}))
+
+	apps, err := client.GetOAuthAuthorizations()
+	assert.Assert(t, errors.Is(err, ErrUnsupportedByServer))
+	assert.Equal(t, len(apps), 0)
+	err = client.RevokeOAuthAuthorization("1")
+	assert.Assert(t, errors.Is(err, ErrUnsupportedByServer))
+}
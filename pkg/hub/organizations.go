@@ -51,22 +51,24 @@ func (c *Client) GetOrganizations(ctx context.Context) ([]Organization, error) {
 		return nil, err
 	}
 	q := url.Values{}
-	q.Add("page_size", fmt.Sprintf("%v", itemsPerPage))
+	q.Add("page_size", fmt.Sprintf("%v", c.pageSizeOrDefault()))
 	q.Add("page", "1")
 	u.RawQuery = q.Encode()
 
-	organizations, next, err := c.getOrganizationsPage(ctx, u.String())
+	organizations, total, next, err := c.getOrganizationsPage(ctx, u.String())
 	if err != nil {
 		return nil, err
 	}
+	c.reportPage(len(organizations), total)
 
 	for next != "" {
-		pageOrganizations, n, err := c.getOrganizationsPage(ctx, next)
+		pageOrganizations, _, n, err := c.getOrganizationsPage(ctx, next)
 		if err != nil {
 			return nil, err
 		}
 		next = n
 		organizations = append(organizations, pageOrganizations...)
+		c.reportPage(len(organizations), total)
 	}
 
 	return organizations, nil
@@ -101,26 +103,26 @@ func (c *Client) GetOrganizationInfo(orgname string) (*Account, error) {
 	}, nil
 }
 
-func (c *Client) getOrganizationsPage(ctx context.Context, url string) ([]Organization, string, error) {
+func (c *Client) getOrganizationsPage(ctx context.Context, url string) ([]Organization, int, string, error) {
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
-		return nil, "", err
+		return nil, 0, "", err
 	}
 	req = req.WithContext(ctx)
 	response, err := c.doRequest(req, withHubToken(c.token))
 	if err != nil {
-		return nil, "", err
+		return nil, 0, "", err
 	}
 	var hubResponse hubOrganizationResponse
 	if err := json.Unmarshal(response, &hubResponse); err != nil {
-		return nil, "", err
+		return nil, 0, "", err
 	}
 
-	var organizations []Organization
+	organizations := make([]Organization, len(hubResponse.Results))
 	eg, _ := errgroup.WithContext(ctx)
 
-	for _, result := range hubResponse.Results {
-		result := result
+	for i, result := range hubResponse.Results {
+		i, result := i, result
 		eg.Go(func() error {
 			var (
 				teams   []Team
@@ -129,10 +131,12 @@ func (c *Client) getOrganizationsPage(ctx context.Context, url string) ([]Organi
 			subeg, _ := errgroup.WithContext(ctx)
 
 			subeg.Go(func() error {
+				var err error
 				teams, err = c.GetTeams(result.OrgName)
 				return err
 			})
 			subeg.Go(func() error {
+				var err error
 				members, err = c.GetMembers(result.OrgName)
 				return err
 			})
@@ -140,27 +144,25 @@ func (c *Client) getOrganizationsPage(ctx context.Context, url string) ([]Organi
 			if err := subeg.Wait(); err != nil {
 				return err
 			}
-			organization := Organization{
+			organizations[i] = Organization{
 				Namespace: result.OrgName,
 				FullName:  result.FullName,
 				Role:      getRole(teams),
 				Teams:     teams,
 				Members:   members,
 			}
-			organizations = append(organizations, organization)
-
 			return nil
 		})
 	}
 
 	if err := eg.Wait(); err != nil {
-		return []Organization{}, "", err
+		return []Organization{}, 0, "", err
 	}
 
 	sort.Slice(organizations, func(i, j int) bool {
 		return organizations[i].Namespace < organizations[j].Namespace
 	})
-	return organizations, hubResponse.Next, nil
+	return organizations, hubResponse.Count, hubResponse.Next, nil
 }
 
 func getRole(teams []Team) string {
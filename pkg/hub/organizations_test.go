@@ -0,0 +1,74 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestGetOrganizations(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == OrganizationsURL:
+			fmt.Fprint(w, `{"count": 3, "results": [{"orgname": "org-c"}, {"orgname": "org-a"}, {"orgname": "org-b"}]}`)
+		case strings.HasSuffix(r.URL.Path, "/groups/"):
+			if r.URL.Path == fmt.Sprintf(GroupsURL, "org-b") {
+				fmt.Fprint(w, `{"count": 1, "results": [{"id": 1, "name": "owners"}]}`)
+				return
+			}
+			fmt.Fprint(w, `{"count": 0, "results": []}`)
+		case strings.HasSuffix(r.URL.Path, "/groups/owners/members/"):
+			fmt.Fprint(w, `[{"username": "alice"}]`)
+		case strings.HasSuffix(r.URL.Path, "/members/"):
+			fmt.Fprint(w, `{"count": 1, "results": [{"username": "alice"}]}`)
+		default:
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+
+	organizations, err := client.GetOrganizations(context.Background())
+	assert.NilError(t, err)
+	assert.Equal(t, len(organizations), 3)
+	for i, namespace := range []string{"org-a", "org-b", "org-c"} {
+		assert.Equal(t, organizations[i].Namespace, namespace)
+		assert.Equal(t, len(organizations[i].Members), 1)
+	}
+	assert.Equal(t, organizations[0].Role, "Member")
+	assert.Equal(t, organizations[1].Role, "Owner")
+}
+
+func TestGetOrganizationsFailure(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == OrganizationsURL:
+			fmt.Fprint(w, `{"count": 2, "results": [{"orgname": "org-a"}, {"orgname": "org-b"}]}`)
+		case r.URL.Path == fmt.Sprintf(MembersURL, "org-b"):
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			fmt.Fprint(w, `{"count": 0, "results": []}`)
+		}
+	}))
+
+	_, err := client.GetOrganizations(context.Background())
+	assert.ErrorContains(t, err, "org-b")
+}
@@ -32,6 +32,8 @@ const (
 	ProPlan = "pro"
 	//FreePlan refers to a hub non-paid account
 	FreePlan = "free"
+	//UnlimitedLimit is the value of the plan limits which are not enforced
+	UnlimitedLimit = 9999
 )
 
 //Plan represents the current account Hub plan
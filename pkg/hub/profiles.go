@@ -0,0 +1,66 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// InteractiveProfile suits commands run by a user waiting for the result:
+	// requests time out after 10 seconds, without any retry nor rate limit.
+	InteractiveProfile = "interactive"
+	// BatchProfile suits unattended jobs sending many requests: requests time
+	// out after 60 seconds, transient failures are retried 5 times with a
+	// backoff starting at 2 seconds and no more than 5 requests are sent per
+	// second.
+	BatchProfile = "batch"
+)
+
+var profiles = map[string][]ClientOp{
+	InteractiveProfile: {
+		WithTimeout(10 * time.Second),
+		WithRetries(0, 0),
+		WithRateLimit(0),
+	},
+	BatchProfile: {
+		WithTimeout(60 * time.Second),
+		WithRetries(5, 2*time.Second),
+		WithRateLimit(5),
+	},
+}
+
+// WithProfile applies a preset of timeout, retries and rate limit settings,
+// see InteractiveProfile and BatchProfile. Each setting can be overridden by
+// passing its own option after this one.
+func WithProfile(name string) ClientOp {
+	return func(c *Client) error {
+		ops, ok := profiles[name]
+		if !ok {
+			var names []string
+			for name := range profiles {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return fmt.Errorf("unknown client profile %q, known profiles are: %s", name, strings.Join(names, ", "))
+		}
+		return c.Update(ops...)
+	}
+}
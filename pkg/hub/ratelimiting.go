@@ -34,6 +34,9 @@ type RateLimits struct {
 	Remaining       *int    `json:",omitempty"`
 	RemainingWindow *int    `json:",omitempty"`
 	Source          *string `json:",omitempty"`
+	// Unlimited is set when the registry doesn't enforce any pull rate limit
+	// for the account. The other values are then -1.
+	Unlimited bool `json:",omitempty"`
 }
 
 var (
@@ -64,6 +67,7 @@ func (c *Client) GetRateLimits() (*RateLimits, error) {
 	if err != nil {
 		return nil, err
 	}
+	defer resp.Body.Close() //nolint:errcheck
 
 	limitHeader := resp.Header.Get("Ratelimit-Limit")
 	remainingHeader := resp.Header.Get("Ratelimit-Remaining")
@@ -76,6 +80,7 @@ func (c *Client) GetRateLimits() (*RateLimits, error) {
 			Remaining:       &defaultValue,
 			RemainingWindow: &defaultValue,
 			Source:          &source,
+			Unlimited:       true,
 		}, nil
 	}
 
@@ -116,36 +121,42 @@ func tryGetToken(c *Client) (string, error) {
 }
 
 func (c *Client) getToken(password string, anonymous bool) (string, error) {
-	req, err := http.NewRequest("GET", first, nil)
-	if err != nil {
+	var t tokenResponse
+	if err := c.requestToken(first, password, anonymous, &t); err != nil {
 		return "", err
 	}
+	return t.Token, nil
+}
+
+// requestToken asks an authorization server for a token, authenticated with
+// the account and the given secret unless anonymous is set, and decodes the
+// response into v
+func (c *Client) requestToken(tokenURL, password string, anonymous bool, v interface{}) error {
+	req, err := http.NewRequest("GET", tokenURL, nil)
+	if err != nil {
+		return err
+	}
 
 	if !anonymous {
 		req.Header.Add("Authorization", "Basic "+basicAuth(c.account, password))
 	}
 	resp, err := c.doRawRequest(req)
 	if err != nil {
-		return "", err
+		return err
 	}
 	if resp.Body != nil {
 		defer resp.Body.Close() //nolint:errcheck
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return "", errors.New("unable to get authorization token")
+		return errors.New("unable to get authorization token")
 	}
 
 	buf, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return "", err
+		return err
 	}
-	var t tokenResponse
-	if err := json.Unmarshal(buf, &t); err != nil {
-		return "", err
-	}
-
-	return t.Token, nil
+	return json.Unmarshal(buf, v)
 }
 
 func parseLimitHeader(value string) (int, int, error) {
@@ -0,0 +1,79 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func serveRateLimits(t *testing.T, headers map[string]string) {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			w.Write([]byte(`{"token": "registry-token"}`)) //nolint:errcheck
+		case "/v2/ratelimitpreview/test/manifests/latest":
+			assert.Equal(t, r.Method, http.MethodHead)
+			assert.Equal(t, r.Header.Get("Authorization"), "Bearer registry-token")
+			for name, value := range headers {
+				w.Header().Set(name, value)
+			}
+		default:
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	previousFirst, previousSecond := first, second
+	SetURLs(server.URL+"/token", server.URL+"/v2/ratelimitpreview/test/manifests/latest")
+	t.Cleanup(func() { SetURLs(previousFirst, previousSecond) })
+}
+
+func TestGetRateLimits(t *testing.T) {
+	serveRateLimits(t, map[string]string{
+		"Ratelimit-Limit":         "200;w=21600",
+		"Ratelimit-Remaining":     "187;w=21600",
+		"Docker-Ratelimit-Source": "192.0.2.1",
+	})
+	client, err := NewClient()
+	assert.NilError(t, err)
+
+	limits, err := client.GetRateLimits()
+	assert.NilError(t, err)
+	assert.Assert(t, !limits.Unlimited)
+	assert.Equal(t, *limits.Limit, 200)
+	assert.Equal(t, *limits.LimitWindow, 21600)
+	assert.Equal(t, *limits.Remaining, 187)
+	assert.Equal(t, *limits.RemainingWindow, 21600)
+	assert.Equal(t, *limits.Source, "192.0.2.1")
+}
+
+func TestGetRateLimitsUnlimited(t *testing.T) {
+	serveRateLimits(t, nil)
+	client, err := NewClient()
+	assert.NilError(t, err)
+
+	limits, err := client.GetRateLimits()
+	assert.NilError(t, err)
+	assert.Assert(t, limits.Unlimited)
+	assert.Equal(t, *limits.Limit, -1)
+	assert.Equal(t, *limits.Remaining, -1)
+}
@@ -0,0 +1,466 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/containerd/containerd/images"
+	"github.com/containerd/containerd/platforms"
+	"github.com/docker/distribution/registry/client/auth/challenge"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const (
+	// RegistryBaseURL path to the registry API base, used to discover the
+	// authentication endpoint
+	RegistryBaseURL = "/v2/"
+	// RegistryManifestURL path to the registry API manifest of a repository
+	RegistryManifestURL = "/v2/%s/manifests/%s"
+	// DefaultPlatform is the platform selected in multi-architecture images
+	// when none is specified
+	DefaultPlatform = "linux/amd64"
+
+	// registryTokenExpiryMargin is removed from a registry token lifetime so
+	// a cached token is never used right before it expires
+	registryTokenExpiryMargin = 10 * time.Second
+	// defaultRegistryTokenLifetime is the lifetime of a registry token when
+	// the authorization server does not return one, as per the token spec
+	defaultRegistryTokenLifetime = 60 * time.Second
+	// maxConcurrentDigestResolutions bounds the number of manifest requests
+	// sent in parallel when resolving the digests of many tags
+	maxConcurrentDigestResolutions = 8
+	// MediaTypeOCIArtifactManifest is the media type of the OCI artifact
+	// manifests, used for non-image content like SBOMs
+	MediaTypeOCIArtifactManifest = "application/vnd.oci.artifact.manifest.v1+json"
+)
+
+var (
+	manifestMediaTypes = []string{
+		images.MediaTypeDockerSchema2ManifestList,
+		ocispec.MediaTypeImageIndex,
+		images.MediaTypeDockerSchema2Manifest,
+		ocispec.MediaTypeImageManifest,
+	}
+)
+
+//Layer is a layer of an image, as described by its manifest
+type Layer struct {
+	Digest    string
+	Size      int64
+	MediaType string
+}
+
+type registryToken struct {
+	token     string
+	expiresAt time.Time
+}
+
+// GetRegistryToken exchanges the client credentials for a short-lived registry
+// bearer token restricted to the given scope (e.g. "repository:org/img:pull").
+// The token can be used directly against the registry API and is cached until
+// it expires.
+func (c *Client) GetRegistryToken(scope string) (string, error) {
+	if token, ok := c.cachedRegistryToken(scope); ok {
+		return token, nil
+	}
+
+	realm, service, err := c.getRegistryAuthChallenge()
+	if err != nil {
+		return "", err
+	}
+	u, err := url.Parse(realm)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	if service != "" {
+		q.Set("service", service)
+	}
+	// Several space separated scopes can be requested at once
+	for _, s := range strings.Fields(scope) {
+		q.Add("scope", s)
+	}
+	u.RawQuery = q.Encode()
+
+	secret := c.registrySecret()
+	var response hubRegistryTokenResponse
+	if err := c.requestToken(u.String(), secret, c.account == "" || secret == "", &response); err != nil {
+		return "", err
+	}
+	token := response.Token
+	if token == "" {
+		token = response.AccessToken
+	}
+	if token == "" {
+		return "", errors.New("no registry token returned by the authorization server")
+	}
+
+	lifetime := defaultRegistryTokenLifetime
+	if response.ExpiresIn > 0 {
+		lifetime = time.Duration(response.ExpiresIn) * time.Second
+	}
+	issuedAt := response.IssuedAt
+	if issuedAt.IsZero() {
+		issuedAt = time.Now()
+	}
+
+	c.registryTokensLock.Lock()
+	defer c.registryTokensLock.Unlock()
+	// Another call may have fetched a token for the same scope meanwhile,
+	// keep the one already shared
+	if cached, ok := c.registryTokens[scope]; ok && time.Now().Before(cached.expiresAt) {
+		return cached.token, nil
+	}
+	if c.registryTokens == nil {
+		c.registryTokens = map[string]registryToken{}
+	}
+	c.registryTokens[scope] = registryToken{
+		token:     token,
+		expiresAt: issuedAt.Add(lifetime - registryTokenExpiryMargin),
+	}
+	return token, nil
+}
+
+func (c *Client) cachedRegistryToken(scope string) (string, bool) {
+	c.registryTokensLock.Lock()
+	defer c.registryTokensLock.Unlock()
+	cached, ok := c.registryTokens[scope]
+	if !ok || !time.Now().Before(cached.expiresAt) {
+		return "", false
+	}
+	return cached.token, true
+}
+
+//GetImageLayers returns the layers of a tagged image. If the tag is a
+//multi-architecture image, the layers of the image matching the given
+//platform (e.g. "linux/arm64") are returned, DefaultPlatform by default.
+func (c *Client) GetImageLayers(namespace, name, tag string, platform ...string) ([]Layer, error) {
+	repository := namespace + "/" + name
+	manifest, err := c.getImageManifest(repository, tag, platform...)
+	if err != nil {
+		return nil, err
+	}
+	layers := make([]Layer, len(manifest.Layers))
+	for i, layer := range manifest.Layers {
+		layers[i] = Layer{
+			Digest:    layer.Digest.String(),
+			Size:      layer.Size,
+			MediaType: layer.MediaType,
+		}
+	}
+	return layers, nil
+}
+
+//DigestError is returned by ResolveDigests for each tag whose digest could
+//not be resolved
+type DigestError struct {
+	Tag string
+	Err error
+}
+
+func (e *DigestError) Error() string {
+	return fmt.Sprintf("failed to resolve digest of tag %q: %s", e.Tag, e.Err)
+}
+
+//Unwrap returns the underlying error, e.g. a not found error for a missing tag
+func (e *DigestError) Unwrap() error {
+	return e.Err
+}
+
+//ResolveDigests returns the content digests of the given tags, indexed by
+//tag. Tags are resolved concurrently; a DigestError is returned for each tag
+//which could not be resolved, in the order of the tags.
+func (c *Client) ResolveDigests(namespace, name string, tags []string) (map[string]string, []error) {
+	repository := namespace + "/" + name
+	digests := make([]string, len(tags))
+	errs := make([]error, len(tags))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxConcurrentDigestResolutions)
+	for i, tag := range tags {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, tag string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			digest, err := c.headManifest(repository, tag)
+			if err != nil {
+				errs[i] = &DigestError{Tag: tag, Err: err}
+				return
+			}
+			digests[i] = digest
+		}(i, tag)
+	}
+	wg.Wait()
+
+	resolved := make(map[string]string, len(tags))
+	var failures []error
+	for i, tag := range tags {
+		if errs[i] != nil {
+			failures = append(failures, errs[i])
+			continue
+		}
+		resolved[tag] = digests[i]
+	}
+	return resolved, failures
+}
+
+//CopyTag makes destTag point to the same manifest as srcTag, without pulling
+//nor pushing any layer
+func (c *Client) CopyTag(namespace, name, srcTag, destTag string) error {
+	repository := namespace + "/" + name
+	content, mediaType, _, err := c.getManifest(repository, srcTag)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("PUT", c.registry+fmt.Sprintf(RegistryManifestURL, repository, destTag), bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+	resp, err := c.doRegistryRequest(req, pushScope(repository), withContentType(mediaType))
+	if err != nil {
+		return fmt.Errorf("failed to copy tag %q to %q in %s: %w", srcTag, destTag, repository, err)
+	}
+	return resp.Body.Close()
+}
+
+// getImageManifest returns the image manifest of a reference, resolving
+// the manifest matching the platform when the reference points to an index
+func (c *Client) getImageManifest(repository, reference string, platform ...string) (*ocispec.Manifest, error) {
+	content, mediaType, _, err := c.getManifest(repository, reference)
+	if err != nil {
+		return nil, err
+	}
+	switch mediaType {
+	case images.MediaTypeDockerSchema2ManifestList, ocispec.MediaTypeImageIndex:
+		descriptor, err := selectPlatform(content, platform...)
+		if err != nil {
+			return nil, err
+		}
+		content, mediaType, _, err = c.getManifest(repository, descriptor.Digest.String())
+		if err != nil {
+			return nil, err
+		}
+	}
+	switch mediaType {
+	case images.MediaTypeDockerSchema2Manifest, ocispec.MediaTypeImageManifest:
+	default:
+		return nil, fmt.Errorf("unsupported manifest media type %q", mediaType)
+	}
+	var manifest ocispec.Manifest
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return nil, err
+	}
+	return &manifest, nil
+}
+
+func selectPlatform(content []byte, platform ...string) (*ocispec.Descriptor, error) {
+	wanted := DefaultPlatform
+	if len(platform) > 0 && platform[0] != "" {
+		wanted = platform[0]
+	}
+	p, err := platforms.Parse(wanted)
+	if err != nil {
+		return nil, fmt.Errorf("invalid platform %q: %s", wanted, err)
+	}
+	var index ocispec.Index
+	if err := json.Unmarshal(content, &index); err != nil {
+		return nil, err
+	}
+	matcher := platforms.Only(p)
+	for _, descriptor := range index.Manifests {
+		descriptor := descriptor
+		if descriptor.Platform != nil && matcher.Match(*descriptor.Platform) {
+			return &descriptor, nil
+		}
+	}
+	return nil, fmt.Errorf("no image found for platform %q", wanted)
+}
+
+// getManifest fetches a manifest from the registry and returns its content,
+// media type and digest
+func (c *Client) getManifest(repository, reference string) ([]byte, string, string, error) {
+	req, err := http.NewRequest("GET", c.registry+fmt.Sprintf(RegistryManifestURL, repository, reference), nil)
+	if err != nil {
+		return nil, "", "", err
+	}
+	resp, err := c.doRegistryRequest(req, pullScope(repository), withAccept(manifestMediaTypes...))
+	if err != nil {
+		return nil, "", "", err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+	content, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", "", err
+	}
+	return content, resp.Header.Get("Content-Type"), resp.Header.Get("Docker-Content-Digest"), nil
+}
+
+//GetManifestMediaType returns the media type of the manifest of a tag, e.g.
+//an image index for a multi-arch image, an image manifest for a single-arch
+//image or an OCI artifact manifest, without fetching the manifest.
+func (c *Client) GetManifestMediaType(namespace, name, tag string) (string, error) {
+	header, err := c.headManifestHeader(namespace+"/"+name, tag, append(manifestMediaTypes, MediaTypeOCIArtifactManifest)...)
+	if err != nil {
+		return "", err
+	}
+	mediaType := strings.TrimSpace(strings.SplitN(header.Get("Content-Type"), ";", 2)[0])
+	if mediaType == "" {
+		return "", errors.New("registry did not return a manifest media type")
+	}
+	return mediaType, nil
+}
+
+// headManifest returns the content digest of a manifest without fetching it
+func (c *Client) headManifest(repository, reference string) (string, error) {
+	header, err := c.headManifestHeader(repository, reference, manifestMediaTypes...)
+	if err != nil {
+		return "", err
+	}
+	digest := header.Get("Docker-Content-Digest")
+	if digest == "" {
+		return "", errors.New("registry did not return a content digest")
+	}
+	return digest, nil
+}
+
+func (c *Client) headManifestHeader(repository, reference string, mediaTypes ...string) (http.Header, error) {
+	req, err := http.NewRequest("HEAD", c.registry+fmt.Sprintf(RegistryManifestURL, repository, reference), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.doRegistryRequest(req, pullScope(repository), withAccept(mediaTypes...))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+	return resp.Header, nil
+}
+
+// doRegistryRequest sends a request to the registry, authenticated with a
+// registry token for the given scope. The caller must close the body of the
+// returned response.
+func (c *Client) doRegistryRequest(req *http.Request, scope string, reqOps ...RequestOp) (*http.Response, error) {
+	if skip, _, err := c.skipInDryRun(req); skip {
+		if err != nil {
+			return nil, err
+		}
+		return dryRunResponse(req), nil
+	}
+	token, err := c.GetRegistryToken(scope)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.doRawRequest(req, append(reqOps, withHubToken(token))...)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close() //nolint:errcheck
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		return nil, &notFoundError{}
+	case http.StatusForbidden:
+		return nil, &forbiddenError{}
+	}
+	buf, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 4096))
+	return nil, fmt.Errorf("bad status code %q: %s", resp.Status, buf)
+}
+
+func withAccept(mediaTypes ...string) RequestOp {
+	return func(req *http.Request) error {
+		req.Header["Accept"] = mediaTypes
+		return nil
+	}
+}
+
+func withContentType(mediaType string) RequestOp {
+	return func(req *http.Request) error {
+		req.Header["Content-Type"] = []string{mediaType}
+		return nil
+	}
+}
+
+func pushScope(repository string) string {
+	return fmt.Sprintf("repository:%s:pull,push", repository)
+}
+
+func pullScope(repository string) string {
+	return fmt.Sprintf("repository:%s:pull", repository)
+}
+
+// getRegistryAuthChallenge pings the registry and returns the realm and the
+// service of the bearer challenge it answers with
+func (c *Client) getRegistryAuthChallenge() (string, string, error) {
+	req, err := http.NewRequest("GET", c.registry+RegistryBaseURL, nil)
+	if err != nil {
+		return "", "", err
+	}
+	resp, err := c.doRawRequest(req)
+	if err != nil {
+		return "", "", err
+	}
+	if resp.Body != nil {
+		defer resp.Body.Close() //nolint:errcheck
+		_, _ = ioutil.ReadAll(resp.Body)
+	}
+	if resp.StatusCode != http.StatusUnauthorized {
+		return "", "", fmt.Errorf("unexpected status code %q while looking up registry authentication", resp.Status)
+	}
+	for _, ch := range challenge.ResponseChallenges(resp) {
+		if !strings.EqualFold(ch.Scheme, "bearer") {
+			continue
+		}
+		realm := ch.Parameters["realm"]
+		if realm == "" {
+			return "", "", errors.New("registry bearer challenge without realm")
+		}
+		return realm, ch.Parameters["service"], nil
+	}
+	return "", "", errors.New("registry did not answer with a bearer challenge")
+}
+
+// registrySecret returns the best secret available to authenticate against
+// the registry authorization server
+func (c *Client) registrySecret() string {
+	for _, secret := range []string{c.password, c.refreshToken, c.token} {
+		if secret != "" {
+			return secret
+		}
+	}
+	return ""
+}
+
+type hubRegistryTokenResponse struct {
+	Token       string    `json:"token"`
+	AccessToken string    `json:"access_token"`
+	ExpiresIn   int       `json:"expires_in"`
+	IssuedAt    time.Time `json:"issued_at"`
+}
@@ -0,0 +1,178 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/containerd/containerd/images"
+	"github.com/docker/distribution/reference"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const (
+	// RegistryBlobURL path to the registry API blob of a repository
+	RegistryBlobURL = "/v2/%s/blobs/%s"
+)
+
+//ImageInspect is the description of an image, read from its manifest and
+//configuration
+type ImageInspect struct {
+	// Reference is the normalized reference of the inspected image
+	Reference    string
+	Architecture string
+	OS           string
+	Variant      string
+	Created      time.Time
+	Config       ImageConfig
+	Layers       []Layer
+	History      []History
+}
+
+//ImageConfig is the execution configuration of an image
+type ImageConfig struct {
+	User         string
+	ExposedPorts []string
+	Env          []string
+	Entrypoint   []string
+	Cmd          []string
+	WorkingDir   string
+	Labels       map[string]string
+	StopSignal   string
+}
+
+//History describes how a layer of an image was built
+type History struct {
+	Created    time.Time
+	CreatedBy  string
+	Author     string
+	Comment    string
+	EmptyLayer bool
+}
+
+//InspectManifest describes an image from its manifest and configuration,
+//without pulling its layers. If the reference points to a multi-architecture
+//image, the image matching the given platform (e.g. "linux/arm64") is
+//inspected, DefaultPlatform by default.
+func (c *Client) InspectManifest(ref string, platform ...string) (*ImageInspect, error) {
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return nil, err
+	}
+	named = reference.TagNameOnly(named)
+	repository := reference.Path(named)
+	var ociRef string
+	switch r := named.(type) {
+	case reference.Canonical:
+		ociRef = r.Digest().String()
+	case reference.Tagged:
+		ociRef = r.Tag()
+	}
+
+	manifest, err := c.getImageManifest(repository, ociRef, platform...)
+	if err != nil {
+		return nil, err
+	}
+	switch manifest.Config.MediaType {
+	case "", images.MediaTypeDockerSchema2Config, ocispec.MediaTypeImageConfig:
+	default:
+		return nil, fmt.Errorf("%s is not an image: unsupported config media type %q", ref, manifest.Config.MediaType)
+	}
+	var config hubImageConfig
+	if err := c.getBlobJSON(repository, manifest.Config.Digest, &config); err != nil {
+		return nil, err
+	}
+
+	inspect := &ImageInspect{
+		Reference:    named.String(),
+		Architecture: config.Architecture,
+		OS:           config.OS,
+		Variant:      config.Variant,
+		Config: ImageConfig{
+			User:       config.Config.User,
+			Env:        config.Config.Env,
+			Entrypoint: config.Config.Entrypoint,
+			Cmd:        config.Config.Cmd,
+			WorkingDir: config.Config.WorkingDir,
+			Labels:     config.Config.Labels,
+			StopSignal: config.Config.StopSignal,
+		},
+		Layers:  make([]Layer, len(manifest.Layers)),
+		History: make([]History, len(config.History)),
+	}
+	if config.Created != nil {
+		inspect.Created = *config.Created
+	}
+	for port := range config.Config.ExposedPorts {
+		inspect.Config.ExposedPorts = append(inspect.Config.ExposedPorts, port)
+	}
+	sort.Strings(inspect.Config.ExposedPorts)
+	for i, layer := range manifest.Layers {
+		inspect.Layers[i] = Layer{
+			Digest:    layer.Digest.String(),
+			Size:      layer.Size,
+			MediaType: layer.MediaType,
+		}
+	}
+	for i, history := range config.History {
+		inspect.History[i] = History{
+			CreatedBy:  history.CreatedBy,
+			Author:     history.Author,
+			Comment:    history.Comment,
+			EmptyLayer: history.EmptyLayer,
+		}
+		if history.Created != nil {
+			inspect.History[i].Created = *history.Created
+		}
+	}
+	return inspect, nil
+}
+
+// getBlobJSON fetches a blob from the registry, checks its content matches
+// its digest and decodes it
+func (c *Client) getBlobJSON(repository string, dgst digest.Digest, v interface{}) error {
+	req, err := http.NewRequest("GET", c.registry+fmt.Sprintf(RegistryBlobURL, repository, dgst), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.doRegistryRequest(req, pullScope(repository))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+	content, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if actual := digest.FromBytes(content); actual != dgst {
+		return fmt.Errorf("blob %s of %s does not match its digest, got %s", dgst, repository, actual)
+	}
+	return json.Unmarshal(content, v)
+}
+
+// hubImageConfig is an image configuration with the CPU variant, which the
+// image spec version in use doesn't describe yet
+type hubImageConfig struct {
+	ocispec.Image
+	Variant string `json:"variant,omitempty"`
+}
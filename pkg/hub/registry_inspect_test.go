@@ -0,0 +1,98 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"testing"
+	"time"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"gotest.tools/v3/assert"
+)
+
+const testImageConfig = `{
+	"architecture": "arm64",
+	"variant": "v8",
+	"os": "linux",
+	"created": "2020-10-02T09:00:00Z",
+	"config": {
+		"Env": ["PATH=/usr/local/bin:/usr/bin"],
+		"Entrypoint": ["/docker-entrypoint.sh"],
+		"Cmd": ["nginx", "-g", "daemon off;"],
+		"ExposedPorts": {"80/tcp": {}, "443/tcp": {}, "8080/tcp": {}, "53/udp": {}}
+	},
+	"rootfs": {"type": "layers", "diff_ids": []},
+	"history": [
+		{"created": "2020-10-01T09:00:00Z", "created_by": "/bin/sh -c #(nop) ADD file:123 in / "},
+		{"created": "2020-10-02T09:00:00Z", "created_by": "/bin/sh -c #(nop)  CMD [\"nginx\"]", "empty_layer": true}
+	]
+}`
+
+func inspectManifests(config string, configDigest digest.Digest) map[string]testManifest {
+	return map[string]testManifest{
+		"/v2/org/img/manifests/latest": {
+			mediaType: ocispec.MediaTypeImageIndex,
+			content: `{"schemaVersion": 2, "manifests": [
+				{"mediaType": "application/vnd.oci.image.manifest.v1+json", "digest": "` + arm64Digest + `", "size": 100, "platform": {"os": "linux", "architecture": "arm64"}}
+			]}`,
+		},
+		"/v2/org/img/manifests/" + arm64Digest: {
+			mediaType: ocispec.MediaTypeImageManifest,
+			content: `{"schemaVersion": 2, "config": {"mediaType": "application/vnd.oci.image.config.v1+json", "digest": "` + configDigest.String() + `", "size": 10}, "layers": [
+				{"mediaType": "application/vnd.oci.image.layer.v1.tar+gzip", "digest": "` + layerDigest + `", "size": 2048}
+			]}`,
+		},
+		"/v2/org/img/blobs/" + configDigest.String(): {
+			mediaType: ocispec.MediaTypeImageConfig,
+			content:   config,
+		},
+	}
+}
+
+func TestInspectManifest(t *testing.T) {
+	client, err := NewClient()
+	assert.NilError(t, err)
+	newTestRegistry(t, client, serveManifests(t, inspectManifests(testImageConfig, digest.FromString(testImageConfig))))
+
+	inspect, err := client.InspectManifest("org/img", "linux/arm64")
+	assert.NilError(t, err)
+	assert.Equal(t, inspect.Reference, "docker.io/org/img:latest")
+	assert.Equal(t, inspect.Architecture, "arm64")
+	assert.Equal(t, inspect.OS, "linux")
+	assert.Equal(t, inspect.Variant, "v8")
+	assert.Equal(t, inspect.Created, time.Date(2020, 10, 2, 9, 0, 0, 0, time.UTC))
+	assert.DeepEqual(t, inspect.Config.Env, []string{"PATH=/usr/local/bin:/usr/bin"})
+	assert.DeepEqual(t, inspect.Config.Entrypoint, []string{"/docker-entrypoint.sh"})
+	assert.DeepEqual(t, inspect.Config.Cmd, []string{"nginx", "-g", "daemon off;"})
+	assert.DeepEqual(t, inspect.Config.ExposedPorts, []string{"443/tcp", "53/udp", "80/tcp", "8080/tcp"})
+	assert.DeepEqual(t, inspect.Layers, []Layer{{Digest: layerDigest, Size: 2048, MediaType: "application/vnd.oci.image.layer.v1.tar+gzip"}})
+	assert.DeepEqual(t, inspect.History, []History{
+		{Created: time.Date(2020, 10, 1, 9, 0, 0, 0, time.UTC), CreatedBy: "/bin/sh -c #(nop) ADD file:123 in / "},
+		{Created: time.Date(2020, 10, 2, 9, 0, 0, 0, time.UTC), CreatedBy: `/bin/sh -c #(nop)  CMD ["nginx"]`, EmptyLayer: true},
+	})
+}
+
+func TestInspectManifestChecksConfigDigest(t *testing.T) {
+	client, err := NewClient()
+	assert.NilError(t, err)
+	configDigest := digest.FromString("another config")
+	newTestRegistry(t, client, serveManifests(t, inspectManifests(testImageConfig, configDigest)))
+
+	_, err = client.InspectManifest("org/img:latest", "linux/arm64")
+	assert.ErrorContains(t, err, "blob "+configDigest.String()+" of org/img does not match its digest")
+}
@@ -17,16 +17,29 @@
 package hub
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
+	"strings"
+	"sync"
 	"time"
 )
 
+const (
+	// maxConcurrentRepositoryLookups bounds the number of repositories fetched
+	// in parallel by PrivacyStatus
+	maxConcurrentRepositoryLookups = 8
+)
+
 const (
 	// RepositoriesURL is the Hub API base URL
 	RepositoriesURL = "/v2/repositories/"
+	// RepositoryURL path to the Hub API returning a repository
+	RepositoryURL = "/v2/repositories/%s/"
 )
 
 //Repository represents a Docker Hub repository
@@ -37,43 +50,281 @@ type Repository struct {
 	PullCount   int
 	StarCount   int
 	IsPrivate   bool
+	Categories  []string
 }
 
-//GetRepositories lists all the repositories a user can access
-func (c *Client) GetRepositories(account string) ([]Repository, int, error) {
-	if account == "" {
-		account = c.account
+//Affiliation describes how the authenticated user is related to a repository
+type Affiliation string
+
+const (
+	//OwnedAffiliation matches the repositories of the user's own namespace
+	OwnedAffiliation = Affiliation("owner")
+	//OrganizationAffiliation matches the repositories of an organization
+	OrganizationAffiliation = Affiliation("organization")
+	//CollaboratorAffiliation matches the repositories of another user the
+	//authenticated user collaborates on
+	CollaboratorAffiliation = Affiliation("collaborator")
+)
+
+//GetRepositories lists all the repositories a user can access. When
+//affiliations are given, only the repositories matching one of them are
+//returned, the filtering is done by the client as the Hub API has no such
+//parameter. An empty account lists the repositories of the authenticated user.
+func (c *Client) GetRepositories(account string, affiliations ...Affiliation) ([]Repository, int, error) {
+	account, err := c.resolveNamespace(account)
+	if err != nil {
+		return nil, 0, err
 	}
-	repositoriesURL := fmt.Sprintf("%s%s%s", c.domain, RepositoriesURL, account)
-	u, err := url.Parse(repositoriesURL)
+	u, err := c.repositoriesURL(account)
 	if err != nil {
 		return nil, 0, err
 	}
-	q := url.Values{}
-	q.Add("page_size", fmt.Sprintf("%v", itemsPerPage))
-	q.Add("page", "1")
-	q.Add("ordering", "last_updated")
-	u.RawQuery = q.Encode()
+	if len(affiliations) > 0 {
+		// The Hub lists a single namespace at a time, so the namespace
+		// either matches the affiliations as a whole or not at all
+		matches, err := c.namespaceMatches(account, affiliations)
+		if err != nil {
+			return nil, 0, err
+		}
+		if !matches {
+			return nil, 0, nil
+		}
+	}
 
-	repos, total, next, err := c.getRepositoriesPage(u.String(), account)
+	repos, total, next, err := c.getRepositoriesPage(context.Background(), u, account)
 	if err != nil {
 		return nil, 0, err
 	}
+	c.reportPage(len(repos), total)
 
 	if c.fetchAllElements {
 		for next != "" {
-			pageRepos, _, n, err := c.getRepositoriesPage(next, account)
+			pageRepos, _, n, err := c.getRepositoriesPage(context.Background(), next, account)
 			if err != nil {
 				return nil, 0, err
 			}
 			next = n
 			repos = append(repos, pageRepos...)
+			c.reportPage(len(repos), total)
 		}
 	}
 
 	return repos, total, nil
 }
 
+//StreamRepositories pages through all the repositories of a namespace and
+//sends them on the returned channel as soon as they are fetched. The channel
+//is closed once all the repositories are sent, on failure or when the context
+//is canceled. Errors are reported on the error channel.
+func (c *Client) StreamRepositories(ctx context.Context, namespace string) (<-chan Repository, <-chan error) {
+	repositories := make(chan Repository)
+	errs := make(chan error, 1)
+	go func() {
+		defer close(errs)
+		defer close(repositories)
+
+		namespace, err := c.resolveNamespace(namespace)
+		if err != nil {
+			errs <- err
+			return
+		}
+		next, err := c.repositoriesURL(namespace)
+		if err != nil {
+			errs <- err
+			return
+		}
+		fetched := 0
+		for next != "" {
+			var (
+				page  []Repository
+				total int
+			)
+			page, total, next, err = c.getRepositoriesPage(ctx, next, namespace)
+			if err != nil {
+				errs <- err
+				return
+			}
+			fetched += len(page)
+			c.reportPage(fetched, total)
+			for _, repository := range page {
+				select {
+				case repositories <- repository:
+				case <-ctx.Done():
+					errs <- ctx.Err()
+					return
+				}
+			}
+		}
+	}()
+	return repositories, errs
+}
+
+//GetRepository returns a repository, given as "namespace/name"
+func (c *Client) GetRepository(repository string) (*Repository, error) {
+	req, err := http.NewRequest("GET", c.domain+fmt.Sprintf(RepositoryURL, repository), nil)
+	if err != nil {
+		return nil, err
+	}
+	response, err := c.doRequest(req, withHubToken(c.token))
+	if err != nil {
+		return nil, err
+	}
+	var result hubRepositoryResult
+	if err := json.Unmarshal(response, &result); err != nil {
+		return nil, err
+	}
+	repo := toRepository(repository, result)
+	return &repo, nil
+}
+
+//PrivacyStatus tells, for each of the given image references, whether its
+//repository is private. The repositories are fetched concurrently; an error is
+//returned for each reference which couldn't be checked, without aborting the
+//others.
+func (c *Client) PrivacyStatus(refs []string) (map[string]bool, []error) {
+	private := make([]bool, len(refs))
+	errs := make([]error, len(refs))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxConcurrentRepositoryLookups)
+	for i, ref := range refs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, ref string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			repoPath, err := getRepoPath(ref)
+			if err != nil {
+				errs[i] = fmt.Errorf("invalid reference %q: %w", ref, err)
+				return
+			}
+			repository, err := c.GetRepository(repoPath)
+			if err != nil {
+				errs[i] = fmt.Errorf("failed to check privacy of %q: %w", ref, err)
+				return
+			}
+			private[i] = repository.IsPrivate
+		}(i, ref)
+	}
+	wg.Wait()
+
+	status := make(map[string]bool, len(refs))
+	var failures []error
+	for i, ref := range refs {
+		if errs[i] != nil {
+			failures = append(failures, errs[i])
+			continue
+		}
+		status[ref] = private[i]
+	}
+	return status, failures
+}
+
+//GetDefaultTag would return the tag featured on the repository page. The Hub
+//API doesn't document such a setting, the repository endpoint has no default
+//tag field, so ErrUnsupportedByServer is always returned.
+func (c *Client) GetDefaultTag(namespace, name string) (string, error) {
+	return "", fmt.Errorf("%w: repository default tag", ErrUnsupportedByServer)
+}
+
+//SetDefaultTag would set the tag featured on the repository page. Like
+//GetDefaultTag, it always returns ErrUnsupportedByServer and changes nothing.
+func (c *Client) SetDefaultTag(namespace, name, tag string) error {
+	return fmt.Errorf("%w: repository default tag", ErrUnsupportedByServer)
+}
+
+//CreateRepository creates a repository in a namespace. An error wrapping
+//ErrRepositoryAlreadyExists is returned if the repository already exists.
+func (c *Client) CreateRepository(namespace, name, description string, private bool) (*Repository, error) {
+	namespace, err := c.resolveNamespace(namespace)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateRepositoryName(name); err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(hubCreateRepositoryRequest{
+		Namespace:   namespace,
+		Name:        name,
+		Description: description,
+		IsPrivate:   private,
+		Registry:    "docker",
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("POST", c.domain+RepositoriesURL, bytes.NewBuffer(data))
+	if err != nil {
+		return nil, err
+	}
+	repository := namespace + "/" + name
+	response, err := c.doRequest(req, withHubToken(c.token))
+	if err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && isAlreadyExistsError(apiErr) {
+			return nil, fmt.Errorf("%w: %s", ErrRepositoryAlreadyExists, repository)
+		}
+		return nil, err
+	}
+	var result hubRepositoryResult
+	if err := json.Unmarshal(response, &result); err != nil {
+		return nil, err
+	}
+	repo := toRepository(repository, result)
+	return &repo, nil
+}
+
+// validateRepositoryName checks the name against the Hub naming rules:
+// lowercase alphanumeric components separated by '.', '_' or '-'
+func validateRepositoryName(name string) error {
+	if len(name) < 2 || len(name) > 255 {
+		return fmt.Errorf("invalid repository name %q: must be between 2 and 255 characters long", name)
+	}
+	if !scopeRepositoryPattern.MatchString(name) {
+		return fmt.Errorf("invalid repository name %q: only lowercase letters, digits and separators ('.', '_' or '-') are allowed", name)
+	}
+	return nil
+}
+
+// isAlreadyExistsError tells whether the Hub API refused to create a
+// repository because it exists, reported either as a conflict or as a bad
+// request depending on the Hub API version
+func isAlreadyExistsError(apiErr *APIError) bool {
+	switch apiErr.StatusCode {
+	case http.StatusConflict:
+		return true
+	case http.StatusBadRequest:
+		return strings.Contains(strings.ToLower(apiErr.Body), "already exists")
+	}
+	return false
+}
+
+//SetRepositoryVisibility makes a repository private or public. An error
+//wrapping ErrPrivateRepositoryLimit is returned when the plan of the account
+//doesn't allow more private repositories.
+func (c *Client) SetRepositoryVisibility(repository string, private bool) error {
+	repository, err := getRepoPath(repository)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(hubVisibilityRequest{IsPrivate: private})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("PATCH", c.domain+fmt.Sprintf(RepositoryURL, repository), bytes.NewBuffer(data))
+	if err != nil {
+		return err
+	}
+	if _, err := c.doRequest(req, withHubToken(c.token)); err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusPaymentRequired {
+			return fmt.Errorf("%w: can't make %s private", ErrPrivateRepositoryLimit, repository)
+		}
+		return err
+	}
+	return nil
+}
+
 //RemoveRepository removes a repository on Hub
 func (c *Client) RemoveRepository(repository string) error {
 	repositoryURL := fmt.Sprintf("%s%s%s/", c.domain, RepositoriesURL, repository)
@@ -89,11 +340,113 @@ func (c *Client) RemoveRepository(repository string) error {
 	return nil
 }
 
-func (c *Client) getRepositoriesPage(url, account string) ([]Repository, int, string, error) {
+// getAllRepositories lists all the repositories of a namespace, whether the
+// client fetches all the elements or not
+func (c *Client) getAllRepositories(namespace string) ([]Repository, error) {
+	namespace, err := c.resolveNamespace(namespace)
+	if err != nil {
+		return nil, err
+	}
+	next, err := c.repositoriesURL(namespace)
+	if err != nil {
+		return nil, err
+	}
+	var repositories []Repository
+	for next != "" {
+		var (
+			page  []Repository
+			total int
+		)
+		page, total, next, err = c.getRepositoriesPage(context.Background(), next, namespace)
+		if err != nil {
+			return nil, err
+		}
+		repositories = append(repositories, page...)
+		c.reportPage(len(repositories), total)
+	}
+	return repositories, nil
+}
+
+// resolveNamespace defaults an empty namespace to the account the client
+// logged in with, or to the namespace of the token owner
+func (c *Client) resolveNamespace(namespace string) (string, error) {
+	if namespace != "" {
+		return namespace, nil
+	}
+	if c.account != "" {
+		return c.account, nil
+	}
+	account, err := c.WhoAmI()
+	if err != nil {
+		return "", fmt.Errorf("can't resolve the namespace of the authenticated user: %w", err)
+	}
+	return account.Namespace, nil
+}
+
+func (c *Client) repositoriesURL(account string) (string, error) {
+	account, err := c.resolveNamespace(account)
+	if err != nil {
+		return "", err
+	}
+	u, err := url.Parse(fmt.Sprintf("%s%s%s", c.domain, RepositoriesURL, account))
+	if err != nil {
+		return "", err
+	}
+	q := url.Values{}
+	q.Add("page_size", fmt.Sprintf("%v", c.pageSizeOrDefault()))
+	q.Add("page", "1")
+	q.Add("ordering", "last_updated")
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+func (c *Client) namespaceMatches(namespace string, affiliations []Affiliation) (bool, error) {
+	wanted := map[Affiliation]bool{}
+	for _, affiliation := range affiliations {
+		switch affiliation {
+		case OwnedAffiliation, OrganizationAffiliation, CollaboratorAffiliation:
+			wanted[affiliation] = true
+		default:
+			return false, fmt.Errorf("unknown affiliation %q", affiliation)
+		}
+	}
+	affiliation, err := c.namespaceAffiliation(namespace, wanted[OrganizationAffiliation] || wanted[CollaboratorAffiliation])
+	if err != nil {
+		return false, fmt.Errorf("can't resolve the affiliation of namespace %q: %w", namespace, err)
+	}
+	return wanted[affiliation], nil
+}
+
+// namespaceAffiliation tells how the authenticated user is related to a
+// namespace. Unless others is set, only the user's own namespace is told
+// apart and any other namespace is reported as a collaboration, without
+// looking it up.
+func (c *Client) namespaceAffiliation(namespace string, others bool) (Affiliation, error) {
+	user, err := c.WhoAmI()
+	if err != nil {
+		return "", err
+	}
+	if strings.EqualFold(user.Namespace, namespace) {
+		return OwnedAffiliation, nil
+	}
+	if !others {
+		return CollaboratorAffiliation, nil
+	}
+	if _, err := c.GetOrganizationInfo(namespace); err != nil {
+		if IsNotFoundError(err) {
+			return CollaboratorAffiliation, nil
+		}
+		return "", err
+	}
+	return OrganizationAffiliation, nil
+}
+
+func (c *Client) getRepositoriesPage(ctx context.Context, url, account string) ([]Repository, int, string, error) {
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
 		return nil, 0, "", err
 	}
+	req = req.WithContext(ctx)
 	response, err := c.doRequest(req, withHubToken(c.token))
 	if err != nil {
 		return nil, 0, "", err
@@ -104,19 +457,27 @@ func (c *Client) getRepositoriesPage(url, account string) ([]Repository, int, st
 	}
 	var repos []Repository
 	for _, result := range hubResponse.Results {
-		repo := Repository{
-			Name:        fmt.Sprintf("%s/%s", account, result.Name),
-			Description: result.Description,
-			LastUpdated: result.LastUpdated,
-			PullCount:   result.PullCount,
-			StarCount:   result.StarCount,
-			IsPrivate:   result.IsPrivate,
-		}
-		repos = append(repos, repo)
+		repos = append(repos, toRepository(fmt.Sprintf("%s/%s", account, result.Name), result))
 	}
 	return repos, hubResponse.Count, hubResponse.Next, nil
 }
 
+func toRepository(name string, result hubRepositoryResult) Repository {
+	var categories []string
+	for _, category := range result.Categories {
+		categories = append(categories, category.Slug)
+	}
+	return Repository{
+		Name:        name,
+		Description: result.Description,
+		LastUpdated: result.LastUpdated,
+		PullCount:   result.PullCount,
+		StarCount:   result.StarCount,
+		IsPrivate:   result.IsPrivate,
+		Categories:  categories,
+	}
+}
+
 type hubRepositoryResponse struct {
 	Count    int                   `json:"count"`
 	Next     string                `json:"next,omitempty"`
@@ -138,6 +499,24 @@ type hubRepositoryResult struct {
 	LastUpdated    time.Time      `json:"last_updated"`
 	Status         int            `json:"status"`
 	User           string         `json:"user"`
+	Categories     []hubCategory  `json:"categories,omitempty"`
+}
+
+type hubCreateRepositoryRequest struct {
+	Namespace   string `json:"namespace"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	IsPrivate   bool   `json:"is_private"`
+	Registry    string `json:"registry"`
+}
+
+type hubVisibilityRequest struct {
+	IsPrivate bool `json:"is_private"`
+}
+
+type hubCategory struct {
+	Name string `json:"name"`
+	Slug string `json:"slug"`
 }
 
 //RepositoryType lists all the different repository types handled by the Docker Hub
@@ -0,0 +1,103 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// defaultDeleteConcurrency is the number of repositories deleted in parallel
+// when DeleteOpts doesn't set it
+const defaultDeleteConcurrency = 4
+
+//DeleteOpts are the guardrails of DeleteRepositoriesWhere
+type DeleteOpts struct {
+	// Confirm must be set for anything to be deleted
+	Confirm bool
+	// MaxDeletes is the maximum number of repositories which can be deleted.
+	// It is mandatory: nothing is deleted if more repositories match.
+	MaxDeletes int
+	// Concurrency is the number of repositories deleted in parallel, 4 by
+	// default
+	Concurrency int
+}
+
+//DeleteRepositoriesWhere deletes the repositories of a namespace matching the
+//predicate, concurrently. The names of the deleted repositories are returned
+//with an error for each repository which couldn't be deleted. An error
+//preventing the whole operation, like a missing confirmation, is returned
+//under the namespace key.
+func (c *Client) DeleteRepositoriesWhere(namespace string, pred func(Repository) bool, opts DeleteOpts) ([]string, map[string]error) {
+	if namespace == "" {
+		namespace = c.account
+	}
+	fail := func(err error) ([]string, map[string]error) {
+		return nil, map[string]error{namespace: err}
+	}
+	if !opts.Confirm {
+		return fail(errors.New("refusing to delete repositories without confirmation"))
+	}
+	if opts.MaxDeletes <= 0 {
+		return fail(errors.New("refusing to delete repositories without a maximum number of deletions"))
+	}
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultDeleteConcurrency
+	}
+
+	repositories, err := c.getAllRepositories(namespace)
+	if err != nil {
+		return fail(err)
+	}
+	var matching []string
+	for _, repository := range repositories {
+		if pred(repository) {
+			matching = append(matching, repository.Name)
+		}
+	}
+	if len(matching) > opts.MaxDeletes {
+		return fail(fmt.Errorf("refusing to delete %d repositories, more than the maximum of %d", len(matching), opts.MaxDeletes))
+	}
+
+	var (
+		deleted []string
+		errs    = map[string]error{}
+		lock    sync.Mutex
+		wg      sync.WaitGroup
+	)
+	sem := make(chan struct{}, concurrency)
+	for _, repository := range matching {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(repository string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			err := c.RemoveRepository(repository)
+			lock.Lock()
+			defer lock.Unlock()
+			if err != nil {
+				errs[repository] = err
+				return
+			}
+			deleted = append(deleted, repository)
+		}(repository)
+	}
+	wg.Wait()
+	return deleted, errs
+}
@@ -0,0 +1,86 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DescriptionField is the short description of a repository
+	DescriptionField = "description"
+	// PrivacyField is the visibility of a repository, "true" if private
+	PrivacyField = "private"
+	// CategoriesField is the comma separated list of categories of a repository
+	CategoriesField = "categories"
+)
+
+//RepositorySpec is the desired state of a repository
+type RepositorySpec struct {
+	Description string
+	IsPrivate   bool
+	Categories  []string
+}
+
+//RepositoryChange is a field of a repository whose current value differs
+//from the desired one
+type RepositoryChange struct {
+	Field   string
+	Current string
+	Desired string
+}
+
+//RepoDiff lists the changes needed for a repository to match its spec
+type RepoDiff struct {
+	Repository string
+	Changes    []RepositoryChange
+}
+
+//Empty returns true if the repository already matches its spec
+func (d RepoDiff) Empty() bool {
+	return len(d.Changes) == 0
+}
+
+//DiffRepository compares the current state of a repository with the desired
+//one, without changing anything. The order of the categories doesn't matter.
+func (c *Client) DiffRepository(namespace, name string, desired RepositorySpec) (RepoDiff, error) {
+	repository := namespace + "/" + name
+	current, err := c.GetRepository(repository)
+	if err != nil {
+		return RepoDiff{}, err
+	}
+
+	diff := RepoDiff{Repository: repository}
+	add := func(field, current, desired string) {
+		if current != desired {
+			diff.Changes = append(diff.Changes, RepositoryChange{Field: field, Current: current, Desired: desired})
+		}
+	}
+	add(DescriptionField, current.Description, desired.Description)
+	add(PrivacyField, strconv.FormatBool(current.IsPrivate), strconv.FormatBool(desired.IsPrivate))
+	add(CategoriesField, joinSorted(current.Categories), joinSorted(desired.Categories))
+	return diff, nil
+}
+
+func joinSorted(values []string) string {
+	sorted := make([]string, len(values))
+	copy(sorted, values)
+	sort.Strings(sorted)
+	return strings.Join(sorted, ",")
+}
@@ -0,0 +1,82 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestDiffRepository(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, r.URL.Path, fmt.Sprintf(RepositoryURL, "org/img"))
+		fmt.Fprint(w, `{"name": "img", "namespace": "org", "description": "An image", "is_private": false,
+			"categories": [{"name": "Databases", "slug": "databases"}, {"name": "Monitoring", "slug": "monitoring"}]}`)
+	}))
+	current := RepositorySpec{
+		Description: "An image",
+		IsPrivate:   false,
+		Categories:  []string{"monitoring", "databases"},
+	}
+
+	testCases := []struct {
+		name     string
+		desired  func(RepositorySpec) RepositorySpec
+		expected []RepositoryChange
+	}{
+		{
+			name:    "no change",
+			desired: func(spec RepositorySpec) RepositorySpec { return spec },
+		},
+		{
+			name: "description",
+			desired: func(spec RepositorySpec) RepositorySpec {
+				spec.Description = "A better image"
+				return spec
+			},
+			expected: []RepositoryChange{{Field: DescriptionField, Current: "An image", Desired: "A better image"}},
+		},
+		{
+			name: "privacy",
+			desired: func(spec RepositorySpec) RepositorySpec {
+				spec.IsPrivate = true
+				return spec
+			},
+			expected: []RepositoryChange{{Field: PrivacyField, Current: "false", Desired: "true"}},
+		},
+		{
+			name: "categories",
+			desired: func(spec RepositorySpec) RepositorySpec {
+				spec.Categories = []string{"databases"}
+				return spec
+			},
+			expected: []RepositoryChange{{Field: CategoriesField, Current: "databases,monitoring", Desired: "databases"}},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			diff, err := client.DiffRepository("org", "img", testCase.desired(current))
+			assert.NilError(t, err)
+			assert.Equal(t, diff.Repository, "org/img")
+			assert.DeepEqual(t, diff.Changes, testCase.expected)
+			assert.Equal(t, diff.Empty(), len(testCase.expected) == 0)
+		})
+	}
+}
@@ -0,0 +1,180 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/containerd/containerd/images"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const (
+	// RegistryBlobMountURL path to the registry API mounting a blob of another
+	// repository
+	RegistryBlobMountURL = "/v2/%s/blobs/uploads/?mount=%s&from=%s"
+)
+
+//MoveRepositoryByCopy moves a repository, given as "namespace/name", to
+//another namespace and returns its new path. The Hub API can't transfer a
+//repository, so the destination repository is created with the same
+//description and visibility, every tag is copied through the registry, by
+//mounting the blobs without pulling them, and the source repository is then
+//deleted. The source is left untouched if anything fails before the
+//deletion, and the destination repository is removed again if a tag can't
+//be copied so a failed move can simply be retried.
+//
+//Stars, pull counts, webhooks, collaborators and build settings are not
+//moved. As the source repository is deleted, confirm must be set.
+func (c *Client) MoveRepositoryByCopy(repository, toNamespace string, confirm bool) (string, error) {
+	if !confirm {
+		return "", errors.New("refusing to move a repository without confirmation, the source repository is deleted")
+	}
+	parts := strings.Split(repository, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", fmt.Errorf("invalid repository %q, expected namespace/name", repository)
+	}
+	if strings.EqualFold(parts[0], toNamespace) {
+		return "", fmt.Errorf("repository %q is already in namespace %q", repository, toNamespace)
+	}
+	if err := c.checkNamespaceExists(toNamespace); err != nil {
+		return "", err
+	}
+
+	source, err := c.GetRepository(repository)
+	if err != nil {
+		return "", err
+	}
+	tags, err := c.getAllTags(repository)
+	if err != nil {
+		return "", err
+	}
+	destination, err := c.CreateRepository(toNamespace, parts[1], source.Description, source.IsPrivate)
+	if err != nil {
+		return "", err
+	}
+	copied := map[string]bool{}
+	for _, tag := range tags {
+		name := strings.TrimPrefix(tag.Name, repository+":")
+		if err := c.copyManifest(repository, destination.Name, name, copied); err != nil {
+			if rmErr := c.RemoveRepository(destination.Name); rmErr != nil {
+				return "", fmt.Errorf("failed to copy tag %q to %s, %s was kept and %s could not be removed (%v): %w", name, destination.Name, repository, destination.Name, rmErr, err)
+			}
+			return "", fmt.Errorf("failed to copy tag %q to %s, %s was kept: %w", name, destination.Name, repository, err)
+		}
+	}
+	if err := c.RemoveRepository(repository); err != nil {
+		return "", fmt.Errorf("%s was copied to %s but could not be deleted: %w", repository, destination.Name, err)
+	}
+	return destination.Name, nil
+}
+
+// checkNamespaceExists checks the namespace is either the one of the
+// authenticated user or an existing organization
+func (c *Client) checkNamespaceExists(namespace string) error {
+	account, err := c.WhoAmI()
+	if err != nil {
+		return err
+	}
+	if strings.EqualFold(account.Namespace, namespace) {
+		return nil
+	}
+	if _, err := c.GetOrganizationInfo(namespace); err != nil {
+		if IsNotFoundError(err) {
+			return fmt.Errorf("namespace %q not found", namespace)
+		}
+		return err
+	}
+	return nil
+}
+
+// copyManifest copies the manifest of a reference, and everything it refers
+// to, from a repository to another. The digests already copied are skipped.
+func (c *Client) copyManifest(from, to, reference string, copied map[string]bool) error {
+	content, mediaType, _, err := c.getManifest(from, reference)
+	if err != nil {
+		return err
+	}
+	switch mediaType {
+	case images.MediaTypeDockerSchema2ManifestList, ocispec.MediaTypeImageIndex:
+		var index ocispec.Index
+		if err := json.Unmarshal(content, &index); err != nil {
+			return err
+		}
+		for _, descriptor := range index.Manifests {
+			if copied[descriptor.Digest.String()] {
+				continue
+			}
+			if err := c.copyManifest(from, to, descriptor.Digest.String(), copied); err != nil {
+				return err
+			}
+		}
+	case images.MediaTypeDockerSchema2Manifest, ocispec.MediaTypeImageManifest:
+		var manifest ocispec.Manifest
+		if err := json.Unmarshal(content, &manifest); err != nil {
+			return err
+		}
+		for _, blob := range append([]ocispec.Descriptor{manifest.Config}, manifest.Layers...) {
+			// Foreign layers are not stored by the registry
+			if len(blob.URLs) > 0 || copied[blob.Digest.String()] {
+				continue
+			}
+			if err := c.mountBlob(from, to, blob.Digest.String()); err != nil {
+				return err
+			}
+			copied[blob.Digest.String()] = true
+		}
+	default:
+		return fmt.Errorf("unsupported manifest media type %q", mediaType)
+	}
+
+	req, err := http.NewRequest("PUT", c.registry+fmt.Sprintf(RegistryManifestURL, to, reference), bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+	resp, err := c.doRegistryRequest(req, pushScope(to), withContentType(mediaType))
+	if err != nil {
+		return err
+	}
+	copied[reference] = true
+	return resp.Body.Close()
+}
+
+// mountBlob makes a blob of a repository available in another one, without
+// uploading it
+func (c *Client) mountBlob(from, to, digest string) error {
+	req, err := http.NewRequest("POST", c.registry+fmt.Sprintf(RegistryBlobMountURL, to, digest, from), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.doRegistryRequest(req, pushScope(to)+" "+pullScope(from))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+	// The registry starts a regular upload, answering 202, when it can't
+	// mount the blob
+	if resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("registry could not mount blob %s from %s", digest, from)
+	}
+	return nil
+}
@@ -0,0 +1,322 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func serveRepositoryPages(t *testing.T, client **Client, pages int) http.Handler {
+	t.Helper()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, r.URL.Path, RepositoriesURL+"org")
+		page, err := strconv.Atoi(r.URL.Query().Get("page"))
+		assert.NilError(t, err)
+		response := hubRepositoryResponse{
+			Count:   pages,
+			Results: []hubRepositoryResult{{Name: fmt.Sprintf("repo-%d", page)}},
+		}
+		if page < pages {
+			response.Next = fmt.Sprintf("%s%sorg?page=%d", (*client).domain, RepositoriesURL, page+1)
+		}
+		assert.NilError(t, json.NewEncoder(w).Encode(response))
+	})
+}
+
+func TestStreamRepositories(t *testing.T) {
+	var client *Client
+	client = newTestClient(t, serveRepositoryPages(t, &client, 3))
+
+	repositories, errs := client.StreamRepositories(context.Background(), "org")
+	var names []string
+	for repository := range repositories {
+		names = append(names, repository.Name)
+	}
+	assert.NilError(t, <-errs)
+	assert.DeepEqual(t, names, []string{"org/repo-1", "org/repo-2", "org/repo-3"})
+}
+
+func TestGetRepositoriesDefaultsToAuthenticatedUser(t *testing.T) {
+	var client *Client
+	userCalls := 0
+	mux := http.NewServeMux()
+	mux.Handle(UserURL, serveUser(t, "org", &userCalls))
+	mux.Handle(RepositoriesURL, serveRepositoryPages(t, &client, 2))
+	client = newTestClient(t, mux)
+	assert.NilError(t, client.Update(WithAllElements()))
+
+	repositories, total, err := client.GetRepositories("")
+	assert.NilError(t, err)
+	assert.Equal(t, total, 2)
+	assert.Equal(t, len(repositories), 2)
+	assert.Equal(t, repositories[0].Name, "org/repo-1")
+	assert.Equal(t, userCalls, 1)
+}
+
+func TestStreamRepositoriesCanceled(t *testing.T) {
+	var client *Client
+	client = newTestClient(t, serveRepositoryPages(t, &client, 3))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	repositories, errs := client.StreamRepositories(ctx, "org")
+	first := <-repositories
+	assert.Equal(t, first.Name, "org/repo-1")
+	cancel()
+	for range repositories {
+	}
+	assert.Assert(t, errors.Is(<-errs, context.Canceled))
+}
+
+func TestGetRepositoriesByAffiliation(t *testing.T) {
+	calls, orgCalls := 0, 0
+	mux := http.NewServeMux()
+	mux.Handle(UserURL, serveUser(t, "alice", &calls))
+	mux.HandleFunc(fmt.Sprintf(OrganizationInfoURL, "org"), func(w http.ResponseWriter, r *http.Request) {
+		orgCalls++
+		fmt.Fprint(w, `{"orgname": "org"}`)
+	})
+	mux.HandleFunc(fmt.Sprintf(OrganizationInfoURL, "bob"), func(w http.ResponseWriter, r *http.Request) {
+		orgCalls++
+		http.NotFound(w, r)
+	})
+	mux.HandleFunc(fmt.Sprintf(OrganizationInfoURL, "broken"), func(w http.ResponseWriter, r *http.Request) {
+		orgCalls++
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	mux.HandleFunc(RepositoriesURL, func(w http.ResponseWriter, r *http.Request) {
+		_, ok := r.URL.Query()["affiliation"]
+		assert.Assert(t, !ok, "the affiliations are filtered by the client")
+		response := hubRepositoryResponse{Count: 1, Results: []hubRepositoryResult{{Name: "repo"}}}
+		assert.NilError(t, json.NewEncoder(w).Encode(response))
+	})
+	client := newTestClient(t, mux)
+
+	testCases := []struct {
+		namespace    string
+		affiliations []Affiliation
+		expected     int
+		orgCalls     int
+		err          string
+	}{
+		{namespace: "alice", affiliations: []Affiliation{OwnedAffiliation}, expected: 1},
+		{namespace: "org", affiliations: []Affiliation{OwnedAffiliation}},
+		{namespace: "broken", affiliations: []Affiliation{OwnedAffiliation}},
+		{namespace: "org", affiliations: []Affiliation{OrganizationAffiliation}, expected: 1, orgCalls: 1},
+		{namespace: "bob", affiliations: []Affiliation{OrganizationAffiliation}, orgCalls: 1},
+		{namespace: "bob", affiliations: []Affiliation{OwnedAffiliation, CollaboratorAffiliation}, expected: 1, orgCalls: 1},
+		{namespace: "broken", affiliations: []Affiliation{CollaboratorAffiliation}, orgCalls: 1, err: `can't resolve the affiliation of namespace "broken"`},
+		{namespace: "alice", affiliations: []Affiliation{"unknown"}, err: `unknown affiliation "unknown"`},
+	}
+	for _, testCase := range testCases {
+		t.Run(fmt.Sprintf("%s %v", testCase.namespace, testCase.affiliations), func(t *testing.T) {
+			orgCalls = 0
+			repositories, total, err := client.GetRepositories(testCase.namespace, testCase.affiliations...)
+			assert.Equal(t, orgCalls, testCase.orgCalls)
+			if testCase.err != "" {
+				assert.ErrorContains(t, err, testCase.err)
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, total, testCase.expected)
+			assert.Equal(t, len(repositories), testCase.expected)
+		})
+	}
+}
+
+func TestDefaultTag(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+	}))
+
+	_, err := client.GetDefaultTag("org", "img")
+	assert.Assert(t, errors.Is(err, ErrUnsupportedByServer))
+	err = client.SetDefaultTag("org", "img", "stable")
+	assert.Assert(t, errors.Is(err, ErrUnsupportedByServer))
+}
+
+func TestPrivacyStatus(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case fmt.Sprintf(RepositoryURL, "org/public"), fmt.Sprintf(RepositoryURL, "library/alpine"):
+			fmt.Fprint(w, `{"is_private": false}`)
+		case fmt.Sprintf(RepositoryURL, "org/private"):
+			fmt.Fprint(w, `{"is_private": true}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	status, errs := client.PrivacyStatus([]string{"org/public", "org/private:1.0", "alpine", "org/missing", "Invalid"})
+	assert.DeepEqual(t, status, map[string]bool{"org/public": false, "org/private:1.0": true, "alpine": false})
+	assert.Equal(t, len(errs), 2)
+	assert.ErrorContains(t, errs[0], `failed to check privacy of "org/missing"`)
+	assert.ErrorContains(t, errs[1], `invalid reference "Invalid"`)
+}
+
+func TestPageCallback(t *testing.T) {
+	var client *Client
+	client = newTestClient(t, serveRepositoryPages(t, &client, 3))
+	var calls [][2]int
+	assert.NilError(t, client.Update(WithAllElements(), WithPageCallback(func(fetched, total int) {
+		calls = append(calls, [2]int{fetched, total})
+	})))
+
+	_, _, err := client.GetRepositories("org")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, calls, [][2]int{{1, 3}, {2, 3}, {3, 3}})
+
+	calls = nil
+	assert.NilError(t, client.Update(WithPageCallback(nil)))
+	_, _, err = client.GetRepositories("org")
+	assert.NilError(t, err)
+	assert.Equal(t, len(calls), 0)
+}
+
+func TestDeleteRepositoriesWhere(t *testing.T) {
+	var (
+		lock    sync.Mutex
+		removed []string
+	)
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			response := hubRepositoryResponse{Count: 4}
+			for _, name := range []string{"ci-1", "ci-2", "ci-locked", "app"} {
+				response.Results = append(response.Results, hubRepositoryResult{Name: name})
+			}
+			assert.NilError(t, json.NewEncoder(w).Encode(response))
+			return
+		}
+		assert.Equal(t, r.Method, http.MethodDelete)
+		if r.URL.Path == RepositoriesURL+"org/ci-locked/" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		lock.Lock()
+		removed = append(removed, r.URL.Path)
+		lock.Unlock()
+	}))
+	isCI := func(repository Repository) bool { return strings.HasPrefix(repository.Name, "org/ci-") }
+
+	_, errs := client.DeleteRepositoriesWhere("org", isCI, DeleteOpts{MaxDeletes: 10})
+	assert.ErrorContains(t, errs["org"], "without confirmation")
+	_, errs = client.DeleteRepositoriesWhere("org", isCI, DeleteOpts{Confirm: true})
+	assert.ErrorContains(t, errs["org"], "without a maximum number of deletions")
+	_, errs = client.DeleteRepositoriesWhere("org", isCI, DeleteOpts{Confirm: true, MaxDeletes: 2})
+	assert.ErrorContains(t, errs["org"], "refusing to delete 3 repositories, more than the maximum of 2")
+	assert.Equal(t, len(removed), 0)
+
+	deleted, errs := client.DeleteRepositoriesWhere("org", isCI, DeleteOpts{Confirm: true, MaxDeletes: 3})
+	sort.Strings(deleted)
+	assert.DeepEqual(t, deleted, []string{"org/ci-1", "org/ci-2"})
+	assert.Equal(t, len(errs), 1)
+	assert.Assert(t, IsForbiddenError(errs["org/ci-locked"]))
+	assert.Equal(t, len(removed), 2)
+}
+
+func TestSetRepositoryVisibility(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, r.Method, http.MethodPatch)
+		var body map[string]interface{}
+		assert.NilError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, len(body), 1, "only is_private should be sent")
+		if r.URL.Path == fmt.Sprintf(RepositoryURL, "org/full") {
+			w.WriteHeader(http.StatusPaymentRequired)
+			fmt.Fprint(w, `{"detail": "Upgrade your plan to add more private repositories"}`)
+			return
+		}
+		assert.Assert(t, r.URL.Path == fmt.Sprintf(RepositoryURL, "org/img") || r.URL.Path == fmt.Sprintf(RepositoryURL, "library/nginx"), r.URL.Path)
+		assert.Equal(t, body["is_private"], false)
+		fmt.Fprint(w, `{"name": "img", "is_private": false}`)
+	}))
+
+	assert.NilError(t, client.SetRepositoryVisibility("org/img", false))
+	assert.NilError(t, client.SetRepositoryVisibility("docker.io/org/img", false))
+	assert.NilError(t, client.SetRepositoryVisibility("nginx", false))
+	assert.ErrorContains(t, client.SetRepositoryVisibility("Org/Img", false), "must be lowercase")
+
+	err := client.SetRepositoryVisibility("org/full", true)
+	assert.Assert(t, errors.Is(err, ErrPrivateRepositoryLimit))
+	assert.ErrorContains(t, err, "can't make org/full private")
+}
+
+func TestCreateRepository(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, r.Method, http.MethodPost)
+		assert.Equal(t, r.URL.Path, RepositoriesURL)
+		var request hubCreateRepositoryRequest
+		assert.NilError(t, json.NewDecoder(r.Body).Decode(&request))
+		switch request.Name {
+		case "exists":
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"message": "Repository with this Name and Namespace already exists."}`)
+		case "conflict":
+			w.WriteHeader(http.StatusConflict)
+		default:
+			assert.Equal(t, request.Namespace, "org")
+			assert.Equal(t, request.IsPrivate, true)
+			assert.NilError(t, json.NewEncoder(w).Encode(hubRepositoryResult{
+				Name:        request.Name,
+				Namespace:   request.Namespace,
+				Description: request.Description,
+				IsPrivate:   request.IsPrivate,
+			}))
+		}
+	}))
+
+	repository, err := client.CreateRepository("org", "my-app.v2", "My app", true)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, repository, &Repository{Name: "org/my-app.v2", Description: "My app", IsPrivate: true})
+
+	for _, name := range []string{"exists", "conflict"} {
+		_, err = client.CreateRepository("org", name, "", true)
+		assert.Assert(t, errors.Is(err, ErrRepositoryAlreadyExists), name)
+		assert.Error(t, err, "repository already exists: org/"+name)
+	}
+}
+
+func TestCreateRepositoryValidatesName(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+	}))
+
+	testCases := []struct {
+		name          string
+		expectedError string
+	}{
+		{name: "a", expectedError: `invalid repository name "a": must be between 2 and 255 characters long`},
+		{name: strings.Repeat("a", 256), expectedError: "must be between 2 and 255 characters long"},
+		{name: "MyApp", expectedError: `invalid repository name "MyApp": only lowercase letters`},
+		{name: "-app", expectedError: "only lowercase letters"},
+		{name: "my--app", expectedError: "only lowercase letters"},
+		{name: "my/app", expectedError: "only lowercase letters"},
+	}
+	for _, testCase := range testCases {
+		_, err := client.CreateRepository("org", testCase.name, "", false)
+		assert.ErrorContains(t, err, testCase.expectedError)
+	}
+}
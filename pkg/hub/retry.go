@@ -0,0 +1,319 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// WithTimeout bounds the duration of each request sent to the Hub, including
+// the reading of the response body. A zero timeout disables it.
+func WithTimeout(timeout time.Duration) ClientOp {
+	return func(c *Client) error {
+		if timeout < 0 {
+			return fmt.Errorf("invalid timeout %s: must be positive", timeout)
+		}
+		c.timeout = timeout
+		return nil
+	}
+}
+
+// maxRetryDelay caps the delay between two retries, whether it comes from
+// the exponential backoff or from a Retry-After header
+const maxRetryDelay = time.Minute
+
+// maxRateLimitWaits is the number of times a paginated listing waits for the
+// Hub API rate limit to be lifted before giving up, when no retry policy is
+// configured
+const maxRateLimitWaits = 5
+
+// rateLimitDelay is the delay to wait once the Hub API rate limit is reached
+// during a paginated listing, when it doesn't send a Retry-After header
+var rateLimitDelay = 5 * time.Second
+
+// defaultRetryableStatuses are the HTTP statuses retried unless
+// WithRetryableStatuses is used
+var defaultRetryableStatuses = []int{
+	http.StatusTooManyRequests,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// WithRetries makes the client retry up to maxRetries times the idempotent
+// requests which failed because of a network error or a transient server
+// error (429, 502, 503 and 504 unless changed with WithRetryableStatuses).
+// The client waits backoff before the first retry, doubling the delay for
+// each following one up to maxRetryDelay, with up to half of it randomly
+// added to spread the retries, unless the server tells how long to wait with
+// a Retry-After header, capped to maxRetryDelay as well. Zero retries disables
+// them, the paginated token listings still wait for the Hub API rate limit to
+// be lifted then.
+func WithRetries(maxRetries int, backoff time.Duration) ClientOp {
+	return func(c *Client) error {
+		if maxRetries < 0 || backoff < 0 {
+			return fmt.Errorf("invalid retries settings: retries and backoff must be positive")
+		}
+		c.maxRetries = maxRetries
+		c.retryBackoff = backoff
+		return nil
+	}
+}
+
+// WithRetryableStatuses replaces the HTTP statuses triggering a retry, 429,
+// 502, 503 and 504 by default. Without any status, only the network errors
+// are retried. Retries must be enabled with WithRetries.
+func WithRetryableStatuses(codes ...int) ClientOp {
+	return func(c *Client) error {
+		statuses := make(map[int]bool, len(codes))
+		for _, code := range codes {
+			if code < 100 || code > 599 {
+				return fmt.Errorf("invalid retryable status %d", code)
+			}
+			statuses[code] = true
+		}
+		c.retryableStatuses = statuses
+		return nil
+	}
+}
+
+// WithRateLimit spaces the requests sent by the client so no more than
+// requestsPerSecond are sent. A zero rate disables the limit.
+func WithRateLimit(requestsPerSecond float64) ClientOp {
+	return func(c *Client) error {
+		if requestsPerSecond < 0 {
+			return fmt.Errorf("invalid rate limit %v: must be positive", requestsPerSecond)
+		}
+		c.minRequestInterval = 0
+		if requestsPerSecond > 0 {
+			c.minRequestInterval = time.Duration(float64(time.Second) / requestsPerSecond)
+		}
+		return nil
+	}
+}
+
+// send sends a single attempt of a request, once the rate limit allows it
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	if err := c.waitRateLimit(req.Context()); err != nil {
+		return nil, err
+	}
+	if c.timeout == 0 {
+		return c.httpClient().Do(req)
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), c.timeout)
+	resp, err := c.httpClient().Do(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, c.timeoutError(req.Context(), ctx, err)
+	}
+	// The timeout covers the reading of the body, it is released once the
+	// body is read to the end or closed
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel, client: c, parent: req.Context(), ctx: ctx}
+	return resp, nil
+}
+
+// timeoutError replaces err by an error wrapping context.DeadlineExceeded if
+// the request failed because the client timeout expired, rather than because
+// of the caller context
+func (c *Client) timeoutError(parent, ctx context.Context, err error) error {
+	if ctx.Err() == context.DeadlineExceeded && parent.Err() == nil {
+		return fmt.Errorf("request to the Hub API timed out after %s: %w", c.timeout, context.DeadlineExceeded)
+	}
+	return err
+}
+
+func (c *Client) waitRateLimit(ctx context.Context) error {
+	if c.minRequestInterval == 0 {
+		return nil
+	}
+	c.rateLimitLock.Lock()
+	now := time.Now()
+	next := c.nextRequest
+	if next.Before(now) {
+		next = now
+	}
+	c.nextRequest = next.Add(c.minRequestInterval)
+	c.rateLimitLock.Unlock()
+	return sleep(ctx, next.Sub(now))
+}
+
+func (c *Client) shouldRetry(req *http.Request, resp *http.Response, err error) bool {
+	if !isIdempotent(req) {
+		return false
+	}
+	if err != nil {
+		// Errors caused by the caller canceling the request are final
+		return req.Context().Err() == nil
+	}
+	if c.retryableStatuses != nil {
+		return c.retryableStatuses[resp.StatusCode]
+	}
+	for _, status := range defaultRetryableStatuses {
+		if resp.StatusCode == status {
+			return true
+		}
+	}
+	return false
+}
+
+// retryDelay returns how long to wait before retrying the failed attempt
+func (c *Client) retryDelay(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if delay, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			if delay > maxRetryDelay {
+				delay = maxRetryDelay
+			}
+			return delay
+		}
+	}
+	delay := c.retryBackoff
+	for i := 0; i < attempt && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	delay = withJitter(delay)
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay
+}
+
+// withJitter randomly adds up to half of the delay, so the retries of
+// concurrent clients are spread
+func withJitter(delay time.Duration) time.Duration {
+	if delay <= 0 {
+		return delay
+	}
+	return delay + time.Duration(rand.Int63n(int64(delay)/2+1))
+}
+
+// waitRateLimited waits before fetching again a page rejected because the
+// Hub API rate limit was reached. It only applies when no retry policy is
+// configured, the retries of doRawRequest handle the rate limit otherwise. It
+// returns false if err is not a rate limit error or if the page was already
+// fetched too many times.
+func (c *Client) waitRateLimited(ctx context.Context, err error, attempt int) (bool, error) {
+	if c.maxRetries > 0 || c.retryableStatuses != nil || attempt >= maxRateLimitWaits {
+		return false, nil
+	}
+	var rateLimitErr *tooManyRequestsError
+	if !errors.As(err, &rateLimitErr) {
+		return false, nil
+	}
+	delay := rateLimitErr.retryAfter
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	if delay == 0 {
+		delay = withJitter(rateLimitDelay)
+	}
+	log.Debugf("Hub API rate limit reached, retrying in %s", delay)
+	return true, sleep(ctx, delay)
+}
+
+// parseRetryAfter parses a Retry-After header, given either in seconds or as
+// an HTTP date
+func parseRetryAfter(value string) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	delay := time.Until(date)
+	if delay < 0 {
+		delay = 0
+	}
+	return delay, true
+}
+
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+	default:
+		return false
+	}
+	// A body which can't be replayed can't be sent twice
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+func rewindBody(req *http.Request) error {
+	if req.GetBody == nil {
+		return nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return err
+	}
+	req.Body = body
+	return nil
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+	client *Client
+	parent context.Context
+	ctx    context.Context
+}
+
+func (c *cancelOnClose) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	switch {
+	case err == io.EOF:
+		c.cancel()
+	case err != nil:
+		err = c.client.timeoutError(c.parent, c.ctx, err)
+	}
+	return n, err
+}
+
+func (c *cancelOnClose) Close() error {
+	defer c.cancel()
+	return c.ReadCloser.Close()
+}
@@ -0,0 +1,339 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func serveFailures(failures int, calls *int) http.Handler {
+	return serveStatusFailures(http.StatusServiceUnavailable, failures, calls)
+}
+
+func serveStatusFailures(status, failures int, calls *int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*calls++
+		if *calls <= failures {
+			w.WriteHeader(status)
+			return
+		}
+		w.Write([]byte(`{}`)) //nolint:errcheck
+	})
+}
+
+func TestRetries(t *testing.T) {
+	calls := 0
+	client := newTestClient(t, serveFailures(2, &calls))
+	assert.NilError(t, client.Update(WithRetries(2, time.Millisecond)))
+
+	req, err := http.NewRequest("GET", client.domain, nil)
+	assert.NilError(t, err)
+	_, err = client.doRequest(req)
+	assert.NilError(t, err)
+	assert.Equal(t, calls, 3)
+}
+
+func TestRetriesExhausted(t *testing.T) {
+	calls := 0
+	client := newTestClient(t, serveFailures(5, &calls))
+	assert.NilError(t, client.Update(WithRetries(1, time.Millisecond)))
+
+	req, err := http.NewRequest("PUT", client.domain, strings.NewReader("body"))
+	assert.NilError(t, err)
+	_, err = client.doRequest(req)
+	assert.ErrorContains(t, err, "503")
+	assert.Equal(t, calls, 2)
+}
+
+func TestNoRetryOnPost(t *testing.T) {
+	calls := 0
+	client := newTestClient(t, serveFailures(1, &calls))
+	assert.NilError(t, client.Update(WithRetries(3, time.Millisecond)))
+
+	req, err := http.NewRequest("POST", client.domain, strings.NewReader("body"))
+	assert.NilError(t, err)
+	_, err = client.doRequest(req)
+	assert.ErrorContains(t, err, "503")
+	assert.Equal(t, calls, 1)
+}
+
+func TestRetryableStatuses(t *testing.T) {
+	testCases := []struct {
+		status   int
+		expected int
+	}{
+		{http.StatusRequestTimeout, 2},
+		{http.StatusTooManyRequests, 1},
+		{http.StatusServiceUnavailable, 1},
+	}
+	for _, tc := range testCases {
+		calls := 0
+		client := newTestClient(t, serveStatusFailures(tc.status, 1, &calls))
+		assert.NilError(t, client.Update(
+			WithRetries(1, time.Millisecond),
+			WithRetryableStatuses(http.StatusRequestTimeout),
+		))
+
+		req, err := http.NewRequest("GET", client.domain, nil)
+		assert.NilError(t, err)
+		_, _ = client.doRequest(req)
+		assert.Equal(t, calls, tc.expected, "status %d", tc.status)
+	}
+
+	_, err := NewClient(WithRetryableStatuses(42))
+	assert.Error(t, err, "invalid retryable status 42")
+}
+
+func TestRetryDelay(t *testing.T) {
+	client, err := NewClient(WithRetries(10, time.Second))
+	assert.NilError(t, err)
+
+	assertDelay := func(delay, min time.Duration) {
+		t.Helper()
+		assert.Assert(t, delay >= min && delay <= min+min/2, "%s not in [%s, %s]", delay, min, min+min/2)
+	}
+	assertDelay(client.retryDelay(0, nil), time.Second)
+	assertDelay(client.retryDelay(1, nil), 2*time.Second)
+	assertDelay(client.retryDelay(3, nil), 8*time.Second)
+	assert.Equal(t, client.retryDelay(9, nil), maxRetryDelay)
+
+	resp := &http.Response{Header: http.Header{"Retry-After": []string{"5"}}}
+	assert.Equal(t, client.retryDelay(0, resp), 5*time.Second)
+	resp.Header.Set("Retry-After", "86400")
+	assert.Equal(t, client.retryDelay(0, resp), maxRetryDelay)
+	resp.Header.Set("Retry-After", "soon")
+	assertDelay(client.retryDelay(0, resp), time.Second)
+}
+
+func TestRetryHonorsRetryAfter(t *testing.T) {
+	calls := 0
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{}`)) //nolint:errcheck
+	}))
+	assert.NilError(t, client.Update(WithRetries(1, time.Millisecond)))
+
+	start := time.Now()
+	req, err := http.NewRequest("GET", client.domain, nil)
+	assert.NilError(t, err)
+	_, err = client.doRequest(req)
+	assert.NilError(t, err)
+	assert.Equal(t, calls, 2)
+	assert.Assert(t, time.Since(start) >= time.Second, "the retry should wait for the Retry-After delay")
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	delay, ok := parseRetryAfter("120")
+	assert.Assert(t, ok)
+	assert.Equal(t, delay, 2*time.Minute)
+
+	delay, ok = parseRetryAfter(time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+	assert.Assert(t, ok)
+	assert.Assert(t, delay > 59*time.Minute && delay <= time.Hour, delay)
+
+	delay, ok = parseRetryAfter("Wed, 21 Oct 2015 07:28:00 GMT")
+	assert.Assert(t, ok)
+	assert.Equal(t, delay, time.Duration(0), "a past date means no wait")
+
+	for _, invalid := range []string{"", "-1", "tomorrow"} {
+		_, ok := parseRetryAfter(invalid)
+		assert.Assert(t, !ok, invalid)
+	}
+}
+
+func TestGetTokensRetriesRateLimitedPages(t *testing.T) {
+	rateLimitDelay = time.Millisecond
+	defer func() { rateLimitDelay = 5 * time.Second }()
+
+	for _, ops := range [][]ClientOp{nil, {WithRetries(1, time.Millisecond)}} {
+		testGetTokensRetriesRateLimitedPages(t, ops...)
+	}
+}
+
+func testGetTokensRetriesRateLimitedPages(t *testing.T, ops ...ClientOp) {
+	var server *httptest.Server
+	calls := map[string]int{}
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page")
+		calls[page]++
+		switch {
+		case page == "2" && calls[page] == 1:
+			w.Header().Set("Retry-After", time.Now().UTC().Format(http.TimeFormat))
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		case page == "3" && calls[page] == 1:
+			// No Retry-After, the default delay or the backoff is used
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		response := hubTokenResponse{
+			Count:   3,
+			Results: []hubTokenResult{{UUID: "6b2e8c4a-1f0e-4c8e-9a4f-00000000000" + page}},
+		}
+		if page != "3" {
+			n, err := strconv.Atoi(page)
+			assert.NilError(t, err)
+			response.Next = fmt.Sprintf("%s%s?page=%d", server.URL, TokensURL, n+1)
+		}
+		assert.NilError(t, json.NewEncoder(w).Encode(response))
+	}))
+	t.Cleanup(server.Close)
+	client, err := NewClient(append([]ClientOp{WithHubToken("token"), WithAllElements()}, ops...)...)
+	assert.NilError(t, err)
+	client.domain = server.URL
+
+	tokens, total, err := client.GetTokens()
+	assert.NilError(t, err)
+	assert.Equal(t, total, 3)
+	assert.Equal(t, len(tokens), 3)
+	assert.DeepEqual(t, calls, map[string]int{"1": 1, "2": 2, "3": 2})
+}
+
+func TestGetTokensRateLimitFollowsRetryPolicy(t *testing.T) {
+	rateLimitDelay = time.Millisecond
+	defer func() { rateLimitDelay = 5 * time.Second }()
+
+	testCases := []struct {
+		name     string
+		ops      []ClientOp
+		expected int
+	}{
+		{name: "no retry policy", expected: maxRateLimitWaits + 1},
+		{name: "retries", ops: []ClientOp{WithRetries(2, time.Millisecond)}, expected: 3},
+		{
+			name:     "429 not retryable",
+			ops:      []ClientOp{WithRetries(2, time.Millisecond), WithRetryableStatuses(http.StatusServiceUnavailable)},
+			expected: 1,
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			calls := 0
+			client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				w.WriteHeader(http.StatusTooManyRequests)
+			}))
+			assert.NilError(t, client.Update(testCase.ops...))
+
+			_, _, err := client.GetTokens()
+			assert.Assert(t, IsTooManyRequestsError(err))
+			assert.Equal(t, calls, testCase.expected)
+		})
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	assert.NilError(t, client.Update(WithTimeout(10*time.Millisecond)))
+
+	req, err := http.NewRequest("GET", client.domain, nil)
+	assert.NilError(t, err)
+	_, err = client.doRequest(req)
+	assert.ErrorContains(t, err, "deadline exceeded")
+	assert.Assert(t, errors.Is(err, context.DeadlineExceeded))
+	assert.ErrorContains(t, err, "timed out after 10ms")
+}
+
+func TestTimeoutWhileReadingBody(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"count": 1, `)) //nolint:errcheck
+		w.(http.Flusher).Flush()
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	assert.NilError(t, client.Update(WithTimeout(50*time.Millisecond)))
+
+	_, _, err := client.GetTokens()
+	assert.Assert(t, errors.Is(err, context.DeadlineExceeded), err)
+}
+
+func TestTimeoutReleasedOnceBodyIsRead(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	body := &cancelOnClose{ReadCloser: ioutil.NopCloser(strings.NewReader("body")), cancel: cancel, client: &Client{}, parent: context.Background(), ctx: ctx}
+
+	_, err := ioutil.ReadAll(body)
+	assert.NilError(t, err)
+	assert.Equal(t, ctx.Err(), context.Canceled, "the timeout is released without closing the body")
+}
+
+func TestTimeoutNotConfusedWithCancellation(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	assert.NilError(t, client.Update(WithTimeout(time.Minute)))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := client.GetTokenWithContext(ctx, "6b2e8c4a-1f0e-4c8e-9a4f-000000000001")
+	assert.Assert(t, err != nil)
+	assert.Assert(t, !strings.Contains(err.Error(), "timed out after"), "the caller deadline is not the client timeout")
+}
+
+func TestRateLimit(t *testing.T) {
+	calls := 0
+	client := newTestClient(t, serveFailures(0, &calls))
+	assert.NilError(t, client.Update(WithRateLimit(20)))
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		req, err := http.NewRequest("GET", client.domain, nil)
+		assert.NilError(t, err)
+		_, err = client.doRequest(req)
+		assert.NilError(t, err)
+	}
+	assert.Assert(t, time.Since(start) >= 100*time.Millisecond, "3 requests at 20 per second should take at least 100ms")
+}
+
+func TestWithProfile(t *testing.T) {
+	client, err := NewClient(WithProfile(BatchProfile), WithRetries(1, time.Second))
+	assert.NilError(t, err)
+	assert.Equal(t, client.timeout, 60*time.Second)
+	assert.Equal(t, client.maxRetries, 1, "options given after the profile should override it")
+	assert.Equal(t, client.minRequestInterval, 200*time.Millisecond)
+
+	assert.NilError(t, client.Update(WithProfile(InteractiveProfile)))
+	assert.Equal(t, client.timeout, 10*time.Second)
+	assert.Equal(t, client.maxRetries, 0)
+	assert.Equal(t, client.minRequestInterval, time.Duration(0))
+
+	_, err = NewClient(WithProfile("fast"))
+	assert.ErrorContains(t, err, `unknown client profile "fast", known profiles are: batch, interactive`)
+}
@@ -0,0 +1,29 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"fmt"
+)
+
+//ReposWithScanDisabled would return the repositories of a namespace where the
+//vulnerability scanning is turned off. The Hub API doesn't document any
+//endpoint exposing the scanning setting of a repository, so it always returns
+//ErrUnsupportedByServer rather than guessing one.
+func (c *Client) ReposWithScanDisabled(namespace string) ([]Repository, error) {
+	return nil, fmt.Errorf("%w: repository vulnerability scanning settings", ErrUnsupportedByServer)
+}
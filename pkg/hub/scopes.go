@@ -0,0 +1,366 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	// ScopeRepoAdmin allows to read, write and delete repositories
+	ScopeRepoAdmin = "repo:admin"
+	// ScopeRepoWrite allows to read and write repositories
+	ScopeRepoWrite = "repo:write"
+	// ScopeRepoRead allows to read public and private repositories
+	ScopeRepoRead = "repo:read"
+	// ScopeRepoPublicRead allows to read public repositories only
+	ScopeRepoPublicRead = "repo:public_read"
+)
+
+// scopeRanks orders the scopes from the least to the most privileged
+var scopeRanks = map[string]int{
+	ScopeRepoPublicRead: 1,
+	ScopeRepoRead:       2,
+	ScopeRepoWrite:      3,
+	ScopeRepoAdmin:      4,
+}
+
+// CanonicalScopes returns the sorted set of scopes granting the same
+// capability as the given ones: duplicates are removed and the repository
+// scopes implied by a more privileged one, like repo:read by repo:write, are
+// dropped. Unknown scopes are kept as is.
+func CanonicalScopes(scopes []string) []string {
+	maxRank := maxScopeRank(scopes)
+	set := map[string]struct{}{}
+	for _, scope := range scopes {
+		if rank, ok := scopeRanks[scope]; ok && rank < maxRank {
+			continue
+		}
+		set[scope] = struct{}{}
+	}
+	canonical := make([]string, 0, len(set))
+	for scope := range set {
+		canonical = append(canonical, scope)
+	}
+	sort.Strings(canonical)
+	return canonical
+}
+
+// TokensEquivalent checks whether two tokens grant the same capability,
+// whatever their description or UUID. Hub tokens are not restricted to some
+// repositories, so their capability is fully described by their scopes.
+func TokensEquivalent(a, b Token) bool {
+	scopesA, scopesB := CanonicalScopes(a.Scopes), CanonicalScopes(b.Scopes)
+	if len(scopesA) != len(scopesB) {
+		return false
+	}
+	for i := range scopesA {
+		if scopesA[i] != scopesB[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// validateScopes checks the scopes of a token to create. The targeted scopes
+// are parsed but rejected, the Hub only creates tokens granting an action on
+// all the repositories.
+func validateScopes(scopes []string) error {
+	if len(scopes) == 0 {
+		return errors.New("invalid token scopes: at least one scope is required")
+	}
+	allowed := strings.Join(allowedScopes(), ", ")
+	for _, scope := range scopes {
+		if _, err := ParseScope(scope); err != nil {
+			return fmt.Errorf("%w, allowed token scopes are: %s", err, allowed)
+		}
+		if _, ok := scopeRanks[scope]; !ok {
+			return fmt.Errorf("invalid token scope %q: the Hub doesn't accept targeted scopes, allowed token scopes are: %s", scope, allowed)
+		}
+	}
+	return nil
+}
+
+// ScopeSpec is the structured form of a token scope. Repository scopes are
+// either "repo:<action>", granting the action on all the repositories, or
+// "repo:<target>:<action>" restricting it to the target, which is a
+// repository ("myorg/myrepo"), all the repositories of a namespace
+// ("myorg/*") or all the repositories ("*").
+type ScopeSpec struct {
+	Resource string
+	Target   string
+	Action   string
+}
+
+// AllTargets is the ScopeSpec target matching all the repositories
+const AllTargets = "*"
+
+var (
+	scopeNamespacePattern  = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+	scopeRepositoryPattern = regexp.MustCompile(`^[a-z0-9]+(?:[._-][a-z0-9]+)*$`)
+)
+
+// ParseScope parses a scope, e.g. "repo:write" or "repo:myorg/*:pull".
+func ParseScope(scope string) (ScopeSpec, error) {
+	parts := strings.Split(scope, ":")
+	if parts[0] != "repo" {
+		return ScopeSpec{}, fmt.Errorf("invalid scope %q: unknown resource %q", scope, parts[0])
+	}
+	switch len(parts) {
+	case 2:
+		if _, ok := scopeRanks[scope]; !ok {
+			return ScopeSpec{}, fmt.Errorf("invalid scope %q: unknown action %q", scope, parts[1])
+		}
+		return ScopeSpec{Resource: parts[0], Target: AllTargets, Action: parts[1]}, nil
+	case 3:
+		if err := validateScopeTarget(parts[1]); err != nil {
+			return ScopeSpec{}, fmt.Errorf("invalid scope %q: %s", scope, err)
+		}
+		switch ActionKind(parts[2]) {
+		case PullAction, PushAction, DeleteAction:
+		default:
+			return ScopeSpec{}, fmt.Errorf("invalid scope %q: unknown action %q", scope, parts[2])
+		}
+		return ScopeSpec{Resource: parts[0], Target: parts[1], Action: parts[2]}, nil
+	default:
+		return ScopeSpec{}, fmt.Errorf("invalid scope %q: expected resource:action or resource:target:action", scope)
+	}
+}
+
+func validateScopeTarget(target string) error {
+	if target == AllTargets {
+		return nil
+	}
+	parts := strings.Split(target, "/")
+	if len(parts) != 2 || !scopeNamespacePattern.MatchString(parts[0]) {
+		return fmt.Errorf("invalid target %q: expected namespace/repository, namespace/* or *", target)
+	}
+	if parts[1] != "*" && !scopeRepositoryPattern.MatchString(parts[1]) {
+		return fmt.Errorf("invalid target %q: invalid repository name %q", target, parts[1])
+	}
+	return nil
+}
+
+// String returns the scope in its textual form
+func (s ScopeSpec) String() string {
+	if _, ok := scopeRanks[s.Resource+":"+s.Action]; ok && s.Target == AllTargets {
+		return s.Resource + ":" + s.Action
+	}
+	return s.Resource + ":" + s.Target + ":" + s.Action
+}
+
+// Matches checks whether the scope target covers the given repository, in
+// the "namespace/name" form.
+func (s ScopeSpec) Matches(repository string) bool {
+	switch {
+	case s.Target == AllTargets:
+		return true
+	case strings.HasSuffix(s.Target, "/*"):
+		return strings.HasPrefix(repository, strings.TrimSuffix(s.Target, "*"))
+	default:
+		return s.Target == repository
+	}
+}
+
+// ScopeSpecs returns the structured form of the token scopes
+func (t Token) ScopeSpecs() ([]ScopeSpec, error) {
+	specs := make([]ScopeSpec, 0, len(t.Scopes))
+	for _, scope := range t.Scopes {
+		spec, err := ParseScope(scope)
+		if err != nil {
+			return nil, err
+		}
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
+
+// allowedScopes returns the known scopes from the least to the most privileged
+func allowedScopes() []string {
+	scopes := make([]string, 0, len(scopeRanks))
+	for scope := range scopeRanks {
+		scopes = append(scopes, scope)
+	}
+	sort.Slice(scopes, func(i, j int) bool { return scopeRanks[scopes[i]] < scopeRanks[scopes[j]] })
+	return scopes
+}
+
+// ActionKind is the kind of operation a token was used for
+type ActionKind string
+
+const (
+	// PullAction is an image pull
+	PullAction = ActionKind("pull")
+	// PushAction is an image push
+	PushAction = ActionKind("push")
+	// DeleteAction is a tag or repository deletion
+	DeleteAction = ActionKind("delete")
+)
+
+// Action is an operation observed for a token
+type Action struct {
+	Kind       ActionKind
+	Repository string
+	// Private is true if the repository the action targeted is private
+	Private bool
+}
+
+// SuggestScopeReduction returns the least privileged scopes sufficient to
+// perform all the observed actions. Each action is matched against the token
+// scope covering its repository, and the reduction keeps the target of that
+// scope: a token scoped to "repo:myorg/*:push" which only pulled is reduced
+// to "repo:myorg/*:pull", never widened to all the repositories. The targeted
+// actions are ranked like the repository scopes, pull, push then delete. The
+// token scopes are returned unchanged if they can't be reduced, along with an
+// error wrapping ErrInsufficientScopes if they don't cover some actions.
+func SuggestScopeReduction(token Token, observedActions []Action) ([]string, error) {
+	specs, err := token.ScopeSpecs()
+	if err != nil {
+		return nil, err
+	}
+	// needed is the rank required per target, every target of the token
+	// being kept with the least privileged action even if unused
+	needed := map[string]int{}
+	for _, spec := range specs {
+		needed[spec.Target] = scopeRanks[ScopeRepoPublicRead]
+	}
+	var uncovered []string
+	for _, action := range observedActions {
+		rank := scopeRanks[scopeFor(action)]
+		spec, ok := coveringScope(specs, action.Repository, rank)
+		if !ok {
+			uncovered = append(uncovered, fmt.Sprintf("%s on %s", action.Kind, action.Repository))
+			continue
+		}
+		if rank > needed[spec.Target] {
+			needed[spec.Target] = rank
+		}
+	}
+	if len(uncovered) > 0 {
+		return token.Scopes, fmt.Errorf("%w: %s", ErrInsufficientScopes, strings.Join(uncovered, ", "))
+	}
+
+	var suggested []string
+	for target, rank := range needed {
+		switch {
+		case target == AllTargets:
+			suggested = append(suggested, scopeRankNames[rank])
+		case rank > needed[AllTargets]:
+			suggested = append(suggested, ScopeSpec{Resource: "repo", Target: target, Action: targetedActions[rank]}.String())
+		}
+	}
+	// The suggested scopes are never wider than the token ones, they only
+	// differ when they can be reduced
+	suggested = CanonicalScopes(suggested)
+	current := CanonicalScopes(token.Scopes)
+	if strings.Join(suggested, " ") == strings.Join(current, " ") {
+		return token.Scopes, nil
+	}
+	return suggested, nil
+}
+
+var (
+	// scopeRankNames are the repository scopes by rank
+	scopeRankNames = map[int]string{
+		1: ScopeRepoPublicRead,
+		2: ScopeRepoRead,
+		3: ScopeRepoWrite,
+		4: ScopeRepoAdmin,
+	}
+	// targetedActions are the actions of the targeted scopes by rank, a pull
+	// covering both the public and private repositories
+	targetedActions = map[int]string{
+		1: string(PullAction),
+		2: string(PullAction),
+		3: string(PushAction),
+		4: string(DeleteAction),
+	}
+)
+
+// coveringScope returns the narrowest scope covering an action of the given
+// rank on a repository
+func coveringScope(specs []ScopeSpec, repository string, rank int) (ScopeSpec, bool) {
+	var covering *ScopeSpec
+	for i, spec := range specs {
+		if !spec.Matches(repository) || scopeSpecRank(spec) < rank {
+			continue
+		}
+		if covering == nil || targetWidth(spec.Target) < targetWidth(covering.Target) {
+			covering = &specs[i]
+		}
+	}
+	if covering == nil {
+		return ScopeSpec{}, false
+	}
+	return *covering, true
+}
+
+func scopeSpecRank(spec ScopeSpec) int {
+	if spec.Target == AllTargets {
+		if rank, ok := scopeRanks[spec.String()]; ok {
+			return rank
+		}
+	}
+	switch ActionKind(spec.Action) {
+	case PullAction:
+		return scopeRanks[ScopeRepoRead]
+	case PushAction:
+		return scopeRanks[ScopeRepoWrite]
+	case DeleteAction:
+		return scopeRanks[ScopeRepoAdmin]
+	}
+	return 0
+}
+
+// targetWidth orders the targets from a single repository to all of them
+func targetWidth(target string) int {
+	switch {
+	case target == AllTargets:
+		return 2
+	case strings.HasSuffix(target, "/*"):
+		return 1
+	}
+	return 0
+}
+
+func scopeFor(action Action) string {
+	switch action.Kind {
+	case DeleteAction:
+		return ScopeRepoAdmin
+	case PushAction:
+		return ScopeRepoWrite
+	default:
+		if action.Private {
+			return ScopeRepoRead
+		}
+		return ScopeRepoPublicRead
+	}
+}
+
+func maxScopeRank(scopes []string) int {
+	rank := 0
+	for _, scope := range scopes {
+		if scopeRanks[scope] > rank {
+			rank = scopeRanks[scope]
+		}
+	}
+	return rank
+}
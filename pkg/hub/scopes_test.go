@@ -0,0 +1,235 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"gotest.tools/v3/assert"
+)
+
+func TestSuggestScopeReduction(t *testing.T) {
+	admin := Token{Scopes: []string{ScopeRepoAdmin}}
+	testCases := []struct {
+		name     string
+		token    Token
+		actions  []Action
+		expected []string
+		err      string
+	}{
+		{
+			name:     "unused token",
+			token:    admin,
+			expected: []string{ScopeRepoPublicRead},
+		},
+		{
+			name:     "public pulls",
+			token:    admin,
+			actions:  []Action{{Kind: PullAction, Repository: "library/alpine"}},
+			expected: []string{ScopeRepoPublicRead},
+		},
+		{
+			name:     "private pulls",
+			token:    admin,
+			actions:  []Action{{Kind: PullAction, Repository: "library/alpine"}, {Kind: PullAction, Repository: "org/private", Private: true}},
+			expected: []string{ScopeRepoRead},
+		},
+		{
+			name:     "pushes",
+			token:    admin,
+			actions:  []Action{{Kind: PushAction, Repository: "org/img"}, {Kind: PullAction, Repository: "org/private", Private: true}},
+			expected: []string{ScopeRepoWrite},
+		},
+		{
+			name:     "deletions need admin",
+			token:    admin,
+			actions:  []Action{{Kind: DeleteAction, Repository: "org/img"}},
+			expected: []string{ScopeRepoAdmin},
+		},
+		{
+			name:     "already minimal",
+			token:    Token{Scopes: []string{ScopeRepoWrite}},
+			actions:  []Action{{Kind: PushAction, Repository: "org/img"}},
+			expected: []string{ScopeRepoWrite},
+		},
+		{
+			name:    "insufficient scopes",
+			token:   Token{Scopes: []string{ScopeRepoRead}},
+			actions: []Action{{Kind: PushAction, Repository: "org/img"}, {Kind: PullAction, Repository: "org/img"}},
+			err:     "the token scopes don't cover all the observed actions: push on org/img",
+		},
+		{
+			name:     "targeted scope keeps its target",
+			token:    Token{Scopes: []string{"repo:org/*:push"}},
+			actions:  []Action{{Kind: PullAction, Repository: "org/img", Private: true}},
+			expected: []string{"repo:org/*:pull"},
+		},
+		{
+			name:     "narrowest target is reduced",
+			token:    Token{Scopes: []string{ScopeRepoAdmin, "repo:org/img:push"}},
+			actions:  []Action{{Kind: PushAction, Repository: "org/img"}, {Kind: PullAction, Repository: "library/alpine"}},
+			expected: []string{"repo:org/img:push", ScopeRepoPublicRead},
+		},
+		{
+			name:    "target not covered",
+			token:   Token{Scopes: []string{"repo:org/*:push"}},
+			actions: []Action{{Kind: PullAction, Repository: "other/img"}},
+			err:     "the token scopes don't cover all the observed actions: pull on other/img",
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			scopes, err := SuggestScopeReduction(testCase.token, testCase.actions)
+			if testCase.err != "" {
+				assert.Assert(t, errors.Is(err, ErrInsufficientScopes))
+				assert.Error(t, err, testCase.err)
+				assert.DeepEqual(t, scopes, testCase.token.Scopes)
+				return
+			}
+			assert.NilError(t, err)
+			assert.DeepEqual(t, scopes, testCase.expected)
+		})
+	}
+}
+
+func TestUpdateTokenScopes(t *testing.T) {
+	client, err := NewClient()
+	assert.NilError(t, err)
+	id := "6b2e8c4a-1f0e-4c8e-9a4f-000000000001"
+
+	_, err = client.UpdateTokenScopes(id, nil)
+	assert.ErrorContains(t, err, "at least one scope is required")
+	_, err = client.UpdateTokenScopes(id, []string{ScopeRepoRead, "repo:everything"})
+	assert.Error(t, err, `invalid scope "repo:everything": unknown action "everything", allowed token scopes are: repo:public_read, repo:read, repo:write, repo:admin`)
+	_, err = client.UpdateTokenScopes(id, []string{"repo:myorg/*:pull"})
+	assert.ErrorContains(t, err, `invalid token scope "repo:myorg/*:pull": the Hub doesn't accept targeted scopes`)
+	_, err = client.UpdateTokenScopes(id, []string{ScopeRepoRead})
+	assert.Equal(t, err, ErrScopesImmutable)
+}
+
+func TestCanonicalScopes(t *testing.T) {
+	assert.DeepEqual(t, CanonicalScopes([]string{ScopeRepoRead, ScopeRepoAdmin, ScopeRepoRead}), []string{ScopeRepoAdmin})
+	assert.DeepEqual(t, CanonicalScopes([]string{"other", ScopeRepoPublicRead}), []string{"other", ScopeRepoPublicRead})
+	assert.DeepEqual(t, CanonicalScopes(nil), []string{})
+}
+
+func TestTokensEquivalent(t *testing.T) {
+	original := Token{
+		UUID:        uuid.MustParse("6b2e8c4a-1f0e-4c8e-9a4f-000000000001"),
+		Description: "ci",
+		Scopes:      []string{ScopeRepoWrite, "other"},
+	}
+	replacement := Token{
+		UUID:        uuid.MustParse("6b2e8c4a-1f0e-4c8e-9a4f-000000000002"),
+		Description: "ci (rotated)",
+		Scopes:      []string{"other", ScopeRepoRead, ScopeRepoWrite},
+	}
+	assert.Assert(t, TokensEquivalent(original, replacement))
+
+	replacement.Scopes = []string{"other", ScopeRepoRead}
+	assert.Assert(t, !TokensEquivalent(original, replacement))
+	replacement.Scopes = []string{ScopeRepoWrite}
+	assert.Assert(t, !TokensEquivalent(original, replacement))
+}
+
+func TestCreateTokenScopesRoundTrip(t *testing.T) {
+	testCases := [][]string{
+		{ScopeRepoPublicRead},
+		{ScopeRepoRead},
+		{ScopeRepoWrite},
+		{ScopeRepoAdmin},
+		{ScopeRepoRead, ScopeRepoWrite},
+		{ScopeRepoPublicRead, ScopeRepoRead, ScopeRepoWrite, ScopeRepoAdmin},
+	}
+	for _, scopes := range testCases {
+		client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var request hubTokenRequest
+			assert.NilError(t, json.NewDecoder(r.Body).Decode(&request))
+			assert.DeepEqual(t, request.Scopes, scopes)
+			assert.NilError(t, json.NewEncoder(w).Encode(hubTokenResult{
+				UUID:   "6b2e8c4a-1f0e-4c8e-9a4f-000000000001",
+				Scopes: request.Scopes,
+			}))
+		}))
+		token, err := client.CreateToken("ci", WithScopes(scopes...))
+		assert.NilError(t, err)
+		assert.DeepEqual(t, token.Scopes, scopes)
+	}
+}
+
+func TestParseScope(t *testing.T) {
+	valid := map[string]ScopeSpec{
+		"repo:read":              {Resource: "repo", Target: AllTargets, Action: "read"},
+		"repo:public_read":       {Resource: "repo", Target: AllTargets, Action: "public_read"},
+		"repo:*:pull":            {Resource: "repo", Target: "*", Action: "pull"},
+		"repo:myorg/*:pull":      {Resource: "repo", Target: "myorg/*", Action: "pull"},
+		"repo:myorg/my-app:push": {Resource: "repo", Target: "myorg/my-app", Action: "push"},
+		"repo:my_org/app:delete": {Resource: "repo", Target: "my_org/app", Action: "delete"},
+	}
+	for scope, expected := range valid {
+		spec, err := ParseScope(scope)
+		assert.NilError(t, err, scope)
+		assert.DeepEqual(t, spec, expected)
+		assert.Equal(t, spec.String(), scope)
+	}
+
+	invalid := map[string]string{
+		"":                     `invalid scope "": unknown resource ""`,
+		"org:read":             `invalid scope "org:read": unknown resource "org"`,
+		"repo:everything":      `invalid scope "repo:everything": unknown action "everything"`,
+		"repo:myorg/*:write":   `invalid scope "repo:myorg/*:write": unknown action "write"`,
+		"repo:myorg:pull":      `invalid scope "repo:myorg:pull": invalid target "myorg": expected namespace/repository, namespace/* or *`,
+		"repo:*/app:pull":      `invalid scope "repo:*/app:pull": invalid target "*/app": expected namespace/repository, namespace/* or *`,
+		"repo:myorg/a*:pull":   `invalid scope "repo:myorg/a*:pull": invalid target "myorg/a*": invalid repository name "a*"`,
+		"repo:myorg/app:pull:": `invalid scope "repo:myorg/app:pull:": expected resource:action or resource:target:action`,
+	}
+	for scope, expected := range invalid {
+		_, err := ParseScope(scope)
+		assert.Error(t, err, expected)
+	}
+}
+
+func TestScopeSpecMatches(t *testing.T) {
+	all, err := ParseScope("repo:write")
+	assert.NilError(t, err)
+	namespace, err := ParseScope("repo:myorg/*:pull")
+	assert.NilError(t, err)
+	repository, err := ParseScope("repo:myorg/app:pull")
+	assert.NilError(t, err)
+
+	assert.Assert(t, all.Matches("other/app"))
+	assert.Assert(t, namespace.Matches("myorg/app"))
+	assert.Assert(t, !namespace.Matches("myorganization/app"))
+	assert.Assert(t, repository.Matches("myorg/app"))
+	assert.Assert(t, !repository.Matches("myorg/app2"))
+}
+
+func TestTokenScopeSpecs(t *testing.T) {
+	specs, err := Token{Scopes: []string{ScopeRepoRead, "repo:myorg/*:push"}}.ScopeSpecs()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, specs, []ScopeSpec{
+		{Resource: "repo", Target: AllTargets, Action: "read"},
+		{Resource: "repo", Target: "myorg/*", Action: "push"},
+	})
+
+	_, err = Token{Scopes: []string{"repo:all"}}.ScopeSpecs()
+	assert.ErrorContains(t, err, `unknown action "all"`)
+}
@@ -0,0 +1,122 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// SecretSink receives the secret of a created token, which the Hub API only
+// returns once
+type SecretSink interface {
+	WriteSecret(tokenUUID string, secret string) error
+}
+
+// WithSecretSink writes the secret of the created token to the sink. The
+// secret is then scrubbed from the returned token and raw response. If the
+// sink fails, the token is returned with its secret along with the error.
+func WithSecretSink(sink SecretSink) CreateTokenOp {
+	return func(r *hubTokenRequest) error {
+		if sink == nil {
+			return fmt.Errorf("invalid secret sink: nil")
+		}
+		r.sink = sink
+		return nil
+	}
+}
+
+// WriterSecretSink writes the secrets to a writer, one per line
+type WriterSecretSink struct {
+	W io.Writer
+}
+
+// NewStdoutSecretSink returns a sink printing the secrets on the standard output
+func NewStdoutSecretSink() *WriterSecretSink {
+	return &WriterSecretSink{W: os.Stdout}
+}
+
+// WriteSecret implements SecretSink
+func (s *WriterSecretSink) WriteSecret(_ string, secret string) error {
+	_, err := fmt.Fprintln(s.W, secret)
+	return err
+}
+
+// FileSecretSink writes the secret to a file only readable by its owner,
+// replacing its content
+type FileSecretSink struct {
+	Path string
+}
+
+// WriteSecret implements SecretSink
+func (s *FileSecretSink) WriteSecret(_ string, secret string) error {
+	return writeSecretFile(s.Path, secret+"\n")
+}
+
+// EnvScriptSecretSink writes a shell script exporting the secret in the given
+// environment variable, to be sourced by a CI pipeline
+type EnvScriptSecretSink struct {
+	Path     string
+	Variable string
+}
+
+var envVariablePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// WriteSecret implements SecretSink
+func (s *EnvScriptSecretSink) WriteSecret(tokenUUID string, secret string) error {
+	if !envVariablePattern.MatchString(s.Variable) {
+		return fmt.Errorf("invalid environment variable name %q", s.Variable)
+	}
+	quoted := "'" + strings.Replace(secret, "'", `'\''`, -1) + "'"
+	script := fmt.Sprintf("# Docker Hub personal access token %s\nexport %s=%s\n", tokenUUID, s.Variable, quoted)
+	return writeSecretFile(s.Path, script)
+}
+
+func writeSecretFile(path, content string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	// The mode of an existing file is kept by OpenFile, restrict it before
+	// writing the secret
+	if err := f.Chmod(0600); err != nil {
+		f.Close() //nolint:errcheck
+		return err
+	}
+	if _, err := f.WriteString(content); err != nil {
+		f.Close() //nolint:errcheck
+		return err
+	}
+	return f.Close()
+}
+
+// scrubSecret empties the token secret of a raw token creation response
+func scrubSecret(response []byte) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(response, &fields); err != nil {
+		return nil, err
+	}
+	if _, ok := fields["token"]; !ok {
+		return response, nil
+	}
+	fields["token"] = json.RawMessage(`""`)
+	return json.Marshal(fields)
+}
@@ -0,0 +1,87 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+type failingSink struct{}
+
+func (failingSink) WriteSecret(string, string) error {
+	return errors.New("secret store unavailable")
+}
+
+func serveTokenCreation(t *testing.T) http.Handler {
+	t.Helper()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NilError(t, json.NewEncoder(w).Encode(hubTokenResult{
+			UUID:  "6b2e8c4a-1f0e-4c8e-9a4f-000000000001",
+			Token: "dckr_pat_it's-secret",
+		}))
+	})
+}
+
+func TestWithSecretSink(t *testing.T) {
+	client := newTestClient(t, serveTokenCreation(t))
+	var out bytes.Buffer
+
+	token, raw, err := client.CreateTokenRaw("ci", WithSecretSink(&WriterSecretSink{W: &out}))
+	assert.NilError(t, err)
+	assert.Equal(t, out.String(), "dckr_pat_it's-secret\n")
+	assert.Equal(t, token.Token, "", "the secret should be scrubbed from the token")
+	assert.Assert(t, !bytes.Contains(raw, []byte("secret")), "the secret should be scrubbed from the raw response")
+
+	token, err = client.CreateToken("ci", WithSecretSink(failingSink{}))
+	assert.ErrorContains(t, err, "secret store unavailable")
+	assert.Equal(t, token.Token, "dckr_pat_it's-secret", "the secret should be kept when the sink fails")
+}
+
+func TestFileSecretSinks(t *testing.T) {
+	dir := t.TempDir()
+	client := newTestClient(t, serveTokenCreation(t))
+
+	path := filepath.Join(dir, "secret")
+	assert.NilError(t, ioutil.WriteFile(path, []byte("old"), 0644))
+	_, err := client.CreateToken("ci", WithSecretSink(&FileSecretSink{Path: path}))
+	assert.NilError(t, err)
+	content, err := ioutil.ReadFile(path)
+	assert.NilError(t, err)
+	assert.Equal(t, string(content), "dckr_pat_it's-secret\n")
+	info, err := os.Stat(path)
+	assert.NilError(t, err)
+	assert.Equal(t, info.Mode().Perm(), os.FileMode(0600))
+
+	script := filepath.Join(dir, "env.sh")
+	_, err = client.CreateToken("ci", WithSecretSink(&EnvScriptSecretSink{Path: script, Variable: "DOCKER_TOKEN"}))
+	assert.NilError(t, err)
+	content, err = ioutil.ReadFile(script)
+	assert.NilError(t, err)
+	assert.Equal(t, string(content), "# Docker Hub personal access token 6b2e8c4a-1f0e-4c8e-9a4f-000000000001\nexport DOCKER_TOKEN='dckr_pat_it'\\''s-secret'\n")
+
+	_, err = client.CreateToken("ci", WithSecretSink(&EnvScriptSecretSink{Path: script, Variable: "1TOKEN"}))
+	assert.ErrorContains(t, err, `invalid environment variable name "1TOKEN"`)
+}
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"sort"
 	"time"
 
 	"github.com/docker/distribution/reference"
@@ -29,7 +30,7 @@ import (
 const (
 	// TagsURL path to the Hub API listing the tags
 	TagsURL = "/v2/repositories/%s/tags/"
-	// DeleteTagURL path to the Hub API to remove a tag
+	// DeleteTagURL path to the Hub API of a tag, to get or remove it
 	DeleteTagURL = "/v2/repositories/%s/tags/%s/"
 )
 
@@ -39,6 +40,7 @@ type Tag struct {
 	FullSize            int
 	LastUpdated         time.Time
 	LastUpdaterUserName string
+	Digest              string
 	Images              []Image
 	LastPulled          time.Time
 	LastPushed          time.Time
@@ -57,6 +59,42 @@ type Image struct {
 	Status       string
 }
 
+//Platform is a platform specific image a tag points to
+type Platform struct {
+	OS           string
+	Architecture string
+	Variant      string
+	Digest       string
+	Size         int
+}
+
+//Platforms returns the per-platform images of the tag, one for a single
+//architecture image and one per platform for a multi-architecture image
+func (t Tag) Platforms() []Platform {
+	platforms := make([]Platform, len(t.Images))
+	for i, image := range t.Images {
+		platforms[i] = Platform{
+			OS:           image.Os,
+			Architecture: image.Architecture,
+			Variant:      image.Variant,
+			Digest:       image.Digest,
+			Size:         image.Size,
+		}
+	}
+	return platforms
+}
+
+//HasPlatform checks whether the tag has an image for the given os and
+//architecture, whatever its variant
+func (t Tag) HasPlatform(os, arch string) bool {
+	for _, image := range t.Images {
+		if image.Os == os && image.Architecture == arch {
+			return true
+		}
+	}
+	return false
+}
+
 //GetTags calls the hub repo API and returns all the information on all tags
 func (c *Client) GetTags(repository string, reqOps ...RequestOp) ([]Tag, int, error) {
 	repoPath, err := getRepoPath(repository)
@@ -68,7 +106,7 @@ func (c *Client) GetTags(repository string, reqOps ...RequestOp) ([]Tag, int, er
 		return nil, 0, err
 	}
 	q := url.Values{}
-	q.Add("page_size", fmt.Sprintf("%v", itemsPerPage))
+	q.Add("page_size", fmt.Sprintf("%v", c.pageSizeOrDefault()))
 	q.Add("page", "1")
 	u.RawQuery = q.Encode()
 
@@ -76,6 +114,7 @@ func (c *Client) GetTags(repository string, reqOps ...RequestOp) ([]Tag, int, er
 	if err != nil {
 		return nil, 0, err
 	}
+	c.reportPage(len(tags), total)
 	if c.fetchAllElements {
 		for next != "" {
 			pageTags, _, n, err := c.getTagsPage(next, repository, reqOps...)
@@ -84,20 +123,108 @@ func (c *Client) GetTags(repository string, reqOps ...RequestOp) ([]Tag, int, er
 			}
 			next = n
 			tags = append(tags, pageTags...)
+			c.reportPage(len(tags), total)
 		}
 	}
 
 	return tags, total, nil
 }
 
-//RemoveTag removes a tag in a repository on Hub
+//GetTagsModifiedSince returns the tags of a repository updated after since,
+//the most recent first. The tags are requested by descending update date so
+//the paging stops at the first tag older than since. If the tags turn out not
+//to be sorted, all the pages are fetched.
+func (c *Client) GetTagsModifiedSince(namespace, name string, since time.Time) ([]Tag, error) {
+	repository := namespace + "/" + name
+	u, err := url.Parse(c.domain + fmt.Sprintf(TagsURL, repository))
+	if err != nil {
+		return nil, err
+	}
+	q := url.Values{}
+	q.Add("page_size", fmt.Sprintf("%v", c.pageSizeOrDefault()))
+	q.Add("page", "1")
+	q.Add("ordering", "last_updated")
+	u.RawQuery = q.Encode()
+
+	var (
+		modified []Tag
+		previous time.Time
+	)
+	sorted := true
+	next := u.String()
+	for next != "" {
+		var page []Tag
+		page, _, next, err = c.getTagsPage(next, repository)
+		if err != nil {
+			return nil, err
+		}
+		reachedCutoff := false
+		for _, tag := range page {
+			if !previous.IsZero() && tag.LastUpdated.After(previous) {
+				sorted = false
+			}
+			previous = tag.LastUpdated
+			if tag.LastUpdated.After(since) {
+				modified = append(modified, tag)
+			} else {
+				reachedCutoff = true
+			}
+		}
+		if reachedCutoff && sorted {
+			break
+		}
+	}
+	if !sorted {
+		sort.SliceStable(modified, func(i, j int) bool {
+			return modified[i].LastUpdated.After(modified[j].LastUpdated)
+		})
+	}
+	return modified, nil
+}
+
+//RemoveTag removes a tag in a repository on Hub. An error wrapping
+//ErrTagNotFound is returned if the tag doesn't exist.
 func (c *Client) RemoveTag(repository, tag string) error {
 	req, err := http.NewRequest("DELETE", c.domain+fmt.Sprintf(DeleteTagURL, repository, tag), nil)
 	if err != nil {
 		return err
 	}
+	if _, err := c.doRequest(req, withHubToken(c.token)); err != nil {
+		if IsNotFoundError(err) {
+			return fmt.Errorf("%w: %s:%s", ErrTagNotFound, repository, tag)
+		}
+		return err
+	}
+	return nil
+}
+
+//TagExists checks whether a tag exists in a repository. An error wrapping
+//ErrRepositoryNotFound is returned if the repository itself doesn't exist.
+func (c *Client) TagExists(namespace, name, tag string) (bool, error) {
+	repository := namespace + "/" + name
+	req, err := http.NewRequest("GET", c.domain+fmt.Sprintf(DeleteTagURL, repository, tag), nil)
+	if err != nil {
+		return false, err
+	}
 	_, err = c.doRequest(req, withHubToken(c.token))
-	return err
+	if err == nil {
+		return true, nil
+	}
+	if !IsNotFoundError(err) {
+		return false, err
+	}
+
+	req, err = http.NewRequest("GET", c.domain+fmt.Sprintf(RepositoryURL, repository), nil)
+	if err != nil {
+		return false, err
+	}
+	if _, err := c.doRequest(req, withHubToken(c.token)); err != nil {
+		if IsNotFoundError(err) {
+			return false, fmt.Errorf("%w: %s", ErrRepositoryNotFound, repository)
+		}
+		return false, err
+	}
+	return false, nil
 }
 
 func (c *Client) getTagsPage(url, repository string, reqOps ...RequestOp) ([]Tag, int, string, error) {
@@ -120,6 +247,7 @@ func (c *Client) getTagsPage(url, repository string, reqOps ...RequestOp) ([]Tag
 			FullSize:            result.FullSize,
 			LastUpdated:         result.LastUpdated,
 			LastUpdaterUserName: result.LastUpdaterUserName,
+			Digest:              result.Digest,
 			Images:              toImages(result.Images),
 			Status:              result.Status,
 			LastPulled:          result.LastPulled,
@@ -142,6 +270,7 @@ type hubTagResult struct {
 	ID                  int           `json:"id"`
 	Name                string        `json:"name"`
 	ImageID             string        `json:"image_id,omitempty"`
+	Digest              string        `json:"digest,omitempty"`
 	LastUpdated         time.Time     `json:"last_updated"`
 	LastUpdater         int           `json:"last_updater"`
 	LastUpdaterUserName string        `json:"last_updater_username"`
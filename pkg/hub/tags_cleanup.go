@@ -0,0 +1,111 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+//DeleteTagsOlderThan removes the tags of a repository last updated before the
+//cutoff, concurrently. The names of the removed tags are returned, sorted,
+//along with an error listing the tags which couldn't be removed. A zero
+//cutoff is refused so that a missing value can't delete every tag.
+func (c *Client) DeleteTagsOlderThan(repository string, before time.Time) ([]string, error) {
+	if before.IsZero() {
+		return nil, errors.New("refusing to delete tags without a cutoff time")
+	}
+	repoPath, err := getRepoPath(repository)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := c.getAllTags(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	var matching []string
+	for _, tag := range tags {
+		if tag.LastUpdated.Before(before) {
+			matching = append(matching, strings.TrimPrefix(tag.Name, repoPath+":"))
+		}
+	}
+
+	var (
+		deleted []string
+		failed  []string
+		lock    sync.Mutex
+		wg      sync.WaitGroup
+	)
+	sem := make(chan struct{}, defaultDeleteConcurrency)
+	for _, tag := range matching {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(tag string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			err := c.RemoveTag(repoPath, tag)
+			lock.Lock()
+			defer lock.Unlock()
+			if err != nil {
+				failed = append(failed, fmt.Sprintf("%s: %s", tag, err))
+				return
+			}
+			deleted = append(deleted, tag)
+		}(tag)
+	}
+	wg.Wait()
+	sort.Strings(deleted)
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		return deleted, fmt.Errorf("failed to delete %d of %d tags: %s", len(failed), len(matching), strings.Join(failed, ", "))
+	}
+	return deleted, nil
+}
+
+// getAllTags lists all the tags of a repository, whether the client fetches
+// all the elements or not
+func (c *Client) getAllTags(repoPath string) ([]Tag, error) {
+	u, err := url.Parse(c.domain + fmt.Sprintf(TagsURL, repoPath))
+	if err != nil {
+		return nil, err
+	}
+	q := url.Values{}
+	q.Add("page_size", fmt.Sprintf("%v", c.pageSizeOrDefault()))
+	q.Add("page", "1")
+	u.RawQuery = q.Encode()
+
+	var tags []Tag
+	next := u.String()
+	for next != "" {
+		var (
+			page  []Tag
+			total int
+		)
+		page, total, next, err = c.getTagsPage(next, repoPath)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, page...)
+		c.reportPage(len(tags), total)
+	}
+	return tags, nil
+}
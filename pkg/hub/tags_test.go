@@ -0,0 +1,245 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestTagPlatforms(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, r.URL.Path, fmt.Sprintf(TagsURL, "org/img"))
+		fmt.Fprint(w, `{"count": 2, "results": [
+			{"name": "single", "images": [
+				{"os": "linux", "architecture": "amd64", "digest": "sha256:aaa", "size": 10}
+			]},
+			{"name": "multi", "images": [
+				{"os": "linux", "architecture": "amd64", "digest": "sha256:bbb", "size": 20},
+				{"os": "linux", "architecture": "arm", "variant": "v7", "digest": "sha256:ccc", "size": 30}
+			]}
+		]}`)
+	}))
+
+	tags, _, err := client.GetTags("org/img")
+	assert.NilError(t, err)
+	assert.Equal(t, len(tags), 2)
+
+	single, multi := tags[0], tags[1]
+	assert.DeepEqual(t, single.Platforms(), []Platform{
+		{OS: "linux", Architecture: "amd64", Digest: "sha256:aaa", Size: 10},
+	})
+	assert.Assert(t, single.HasPlatform("linux", "amd64"))
+	assert.Assert(t, !single.HasPlatform("linux", "arm"))
+
+	assert.DeepEqual(t, multi.Platforms(), []Platform{
+		{OS: "linux", Architecture: "amd64", Digest: "sha256:bbb", Size: 20},
+		{OS: "linux", Architecture: "arm", Variant: "v7", Digest: "sha256:ccc", Size: 30},
+	})
+	assert.Assert(t, multi.HasPlatform("linux", "arm"))
+	assert.Assert(t, !multi.HasPlatform("windows", "amd64"))
+}
+
+func TestGetTagsMultiPlatformFixture(t *testing.T) {
+	fixture, err := ioutil.ReadFile("testdata/tags-multi-platform.json")
+	assert.NilError(t, err)
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, r.URL.Path, fmt.Sprintf(TagsURL, "org/img"))
+		w.Write(fixture) //nolint:errcheck
+	}))
+
+	tags, total, err := client.GetTags("org/img")
+	assert.NilError(t, err)
+	assert.Equal(t, total, 2)
+	assert.Equal(t, len(tags), 2)
+
+	tag := tags[0]
+	assert.Equal(t, tag.Name, "org/img:1.0")
+	assert.Equal(t, tag.FullSize, 2797612)
+	assert.Equal(t, tag.LastUpdated, time.Date(2020, 10, 2, 9, 0, 0, 0, time.UTC))
+	assert.Equal(t, tag.Digest, "sha256:4f1b6f5c4cb6a5a0c1ab6e1d3a4f8e3f7b27e9c0a47a5a7d0d3c8b1e2f3a4b5c")
+	assert.DeepEqual(t, tag.Platforms(), []Platform{
+		{OS: "linux", Architecture: "amd64", Digest: "sha256:1111111111111111111111111111111111111111111111111111111111111111", Size: 2797612},
+		{OS: "linux", Architecture: "arm64", Variant: "v8", Digest: "sha256:2222222222222222222222222222222222222222222222222222222222222222", Size: 2710003},
+		{OS: "linux", Architecture: "arm", Variant: "v7", Digest: "sha256:3333333333333333333333333333333333333333333333333333333333333333", Size: 2456001},
+	})
+
+	var arm64 []string
+	for _, tag := range tags {
+		if tag.HasPlatform("linux", "arm64") {
+			arm64 = append(arm64, tag.Name)
+		}
+	}
+	assert.DeepEqual(t, arm64, []string{"org/img:1.0"})
+}
+
+func TestTagExists(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc(fmt.Sprintf(DeleteTagURL, "org/img", "latest"), func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"name": "latest"}`)
+	})
+	mux.HandleFunc(fmt.Sprintf(RepositoryURL, "org/img"), func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != fmt.Sprintf(RepositoryURL, "org/img") {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `{"name": "img"}`)
+	})
+	mux.HandleFunc(fmt.Sprintf(DeleteTagURL, "org/broken", "latest"), func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	client := newTestClient(t, mux)
+
+	exists, err := client.TagExists("org", "img", "latest")
+	assert.NilError(t, err)
+	assert.Assert(t, exists)
+
+	exists, err = client.TagExists("org", "img", "missing")
+	assert.NilError(t, err)
+	assert.Assert(t, !exists)
+
+	_, err = client.TagExists("org", "missing", "latest")
+	assert.Assert(t, errors.Is(err, ErrRepositoryNotFound))
+	assert.ErrorContains(t, err, "org/missing")
+
+	_, err = client.TagExists("org", "broken", "latest")
+	assert.ErrorContains(t, err, "500")
+}
+
+func serveTagPages(t *testing.T, client **Client, pages [][]hubTagResult, requested *int) http.Handler {
+	t.Helper()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, r.URL.Path, fmt.Sprintf(TagsURL, "org/img"))
+		page := 1
+		if p := r.URL.Query().Get("page"); p != "" {
+			var err error
+			page, err = strconv.Atoi(p)
+			assert.NilError(t, err)
+		}
+		*requested = page
+		response := hubTagResponse{Results: pages[page-1]}
+		if page < len(pages) {
+			response.Next = fmt.Sprintf("%s%s?page=%d", (*client).domain, fmt.Sprintf(TagsURL, "org/img"), page+1)
+		}
+		assert.NilError(t, json.NewEncoder(w).Encode(response))
+	})
+}
+
+func TestGetTagsModifiedSince(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2020, 11, d, 0, 0, 0, 0, time.UTC) }
+	since := day(10)
+
+	t.Run("sorted", func(t *testing.T) {
+		requested := 0
+		var client *Client
+		client = newTestClient(t, serveTagPages(t, &client, [][]hubTagResult{
+			{{Name: "3", LastUpdated: day(20)}, {Name: "2", LastUpdated: day(15)}},
+			{{Name: "1", LastUpdated: day(12)}, {Name: "old", LastUpdated: day(5)}},
+			{{Name: "older", LastUpdated: day(1)}},
+		}, &requested))
+
+		tags, err := client.GetTagsModifiedSince("org", "img", since)
+		assert.NilError(t, err)
+		var names []string
+		for _, tag := range tags {
+			names = append(names, tag.Name)
+		}
+		assert.DeepEqual(t, names, []string{"org/img:3", "org/img:2", "org/img:1"})
+		assert.Equal(t, requested, 2, "paging should stop at the cutoff")
+	})
+
+	t.Run("unsorted", func(t *testing.T) {
+		requested := 0
+		var client *Client
+		client = newTestClient(t, serveTagPages(t, &client, [][]hubTagResult{
+			{{Name: "old", LastUpdated: day(5)}, {Name: "2", LastUpdated: day(15)}},
+			{{Name: "3", LastUpdated: day(20)}},
+		}, &requested))
+
+		tags, err := client.GetTagsModifiedSince("org", "img", since)
+		assert.NilError(t, err)
+		assert.Equal(t, len(tags), 2)
+		assert.Equal(t, tags[0].Name, "org/img:3")
+		assert.Equal(t, requested, 2, "all the pages should be fetched when the tags are not sorted")
+	})
+}
+
+func TestRemoveTagNotFound(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, r.Method, http.MethodDelete)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+
+	err := client.RemoveTag("org/img", "missing")
+	assert.Assert(t, errors.Is(err, ErrTagNotFound))
+	assert.Error(t, err, "tag not found: org/img:missing")
+}
+
+func TestDeleteTagsOlderThan(t *testing.T) {
+	cutoff := time.Date(2020, 10, 1, 0, 0, 0, 0, time.UTC)
+	var (
+		lock    sync.Mutex
+		removed []string
+	)
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			assert.Equal(t, r.URL.Path, fmt.Sprintf(TagsURL, "org/img"))
+			assert.NilError(t, json.NewEncoder(w).Encode(hubTagResponse{
+				Count: 4,
+				Results: []hubTagResult{
+					{Name: "new", LastUpdated: cutoff.Add(time.Hour)},
+					{Name: "old", LastUpdated: cutoff.Add(-time.Hour)},
+					{Name: "older", LastUpdated: cutoff.Add(-48 * time.Hour)},
+					{Name: "gone", LastUpdated: cutoff.Add(-72 * time.Hour)},
+				},
+			}))
+		case http.MethodDelete:
+			if r.URL.Path == fmt.Sprintf(DeleteTagURL, "org/img", "gone") {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			lock.Lock()
+			removed = append(removed, r.URL.Path)
+			lock.Unlock()
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+
+	deleted, err := client.DeleteTagsOlderThan("org/img", cutoff)
+	assert.Error(t, err, "failed to delete 1 of 3 tags: gone: tag not found: org/img:gone")
+	assert.DeepEqual(t, deleted, []string{"old", "older"})
+	assert.Equal(t, len(removed), 2)
+}
+
+func TestDeleteTagsOlderThanRequiresCutoff(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+	}))
+
+	_, err := client.DeleteTagsOlderThan("org/img", time.Time{})
+	assert.Error(t, err, "refusing to delete tags without a cutoff time")
+}
@@ -17,8 +17,10 @@
 package hub
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -30,12 +32,18 @@ import (
 const (
 	//GroupsURL path to the Hub API listing the groups in an organization
 	GroupsURL = "/v2/orgs/%s/groups/"
+	//GroupURL path to the Hub API managing a group of an organization
+	GroupURL = "/v2/orgs/%s/groups/%s/"
+	//GroupMemberURL path to the Hub API removing a member from a group
+	GroupMemberURL = "/v2/orgs/%s/groups/%s/members/%s/"
 )
 
 //Team represents a hub group in an organization
 type Team struct {
+	ID          int
 	Name        string
 	Description string
+	MemberCount int
 	Members     []Member
 }
 
@@ -46,22 +54,24 @@ func (c *Client) GetTeams(organization string) ([]Team, error) {
 		return nil, err
 	}
 	q := url.Values{}
-	q.Add("page_size", fmt.Sprintf("%v", itemsPerPage))
+	q.Add("page_size", fmt.Sprintf("%v", c.pageSizeOrDefault()))
 	q.Add("page", "1")
 	u.RawQuery = q.Encode()
 
-	teams, next, err := c.getTeamsPage(u.String(), organization)
+	teams, total, next, err := c.getTeamsPage(u.String(), organization)
 	if err != nil {
 		return nil, err
 	}
+	c.reportPage(len(teams), total)
 
 	for next != "" {
-		pageTeams, n, err := c.getTeamsPage(next, organization)
+		pageTeams, _, n, err := c.getTeamsPage(next, organization)
 		if err != nil {
 			return nil, err
 		}
 		next = n
 		teams = append(teams, pageTeams...)
+		c.reportPage(len(teams), total)
 	}
 
 	return teams, nil
@@ -93,47 +103,126 @@ func (c *Client) GetTeamsCount(organization string) (int, error) {
 	return hubResponse.Count, nil
 }
 
-func (c *Client) getTeamsPage(url, organization string) ([]Team, string, error) {
+func (c *Client) getTeamsPage(url, organization string) ([]Team, int, string, error) {
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
-		return nil, "", err
+		return nil, 0, "", err
 	}
 	response, err := c.doRequest(req, withHubToken(c.token))
 	if err != nil {
-		return nil, "", err
+		return nil, 0, "", err
 	}
 	var hubResponse hubGroupResponse
 	if err := json.Unmarshal(response, &hubResponse); err != nil {
-		return nil, "", err
+		return nil, 0, "", err
 	}
-	var teams []Team
+	teams := make([]Team, len(hubResponse.Results))
 	eg, _ := errgroup.WithContext(context.Background())
-	for _, result := range hubResponse.Results {
-		result := result
+	for i, result := range hubResponse.Results {
+		i, result := i, result
 		eg.Go(func() error {
 			members, err := c.GetMembersPerTeam(organization, result.Name)
 			if err != nil {
 				return err
 			}
-			team := Team{
-				Name:        result.Name,
-				Description: result.Description,
-				Members:     members,
-			}
-			teams = append(teams, team)
+			team := toTeam(result)
+			team.Members = members
+			team.MemberCount = len(members)
+			teams[i] = team
 			return nil
 		})
 	}
 
 	if err := eg.Wait(); err != nil {
-		return []Team{}, "", err
+		return []Team{}, 0, "", err
 	}
 
 	sort.Slice(teams, func(i, j int) bool {
 		return teams[i].Name < teams[j].Name
 	})
 
-	return teams, hubResponse.Next, nil
+	return teams, hubResponse.Count, hubResponse.Next, nil
+}
+
+//CreateTeam creates a team in an organization. An error wrapping
+//ErrTeamAlreadyExists is returned if the organization already has a team with
+//that name.
+func (c *Client) CreateTeam(organization, name, description string) (*Team, error) {
+	data, err := json.Marshal(hubGroupRequest{Name: name, Description: description})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("POST", c.domain+fmt.Sprintf(GroupsURL, organization), bytes.NewBuffer(data))
+	if err != nil {
+		return nil, err
+	}
+	response, err := c.doRequest(req, withHubToken(c.token))
+	if err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusConflict {
+			return nil, fmt.Errorf("%w: %s/%s", ErrTeamAlreadyExists, organization, name)
+		}
+		return nil, err
+	}
+	var result hubGroupResult
+	if err := json.Unmarshal(response, &result); err != nil {
+		return nil, err
+	}
+	team := toTeam(result)
+	return &team, nil
+}
+
+//DeleteTeam removes a team from an organization
+func (c *Client) DeleteTeam(organization, name string) error {
+	req, err := http.NewRequest("DELETE", c.domain+fmt.Sprintf(GroupURL, organization, name), nil)
+	if err != nil {
+		return err
+	}
+	_, err = c.doRequest(req, withHubToken(c.token))
+	return err
+}
+
+//AddTeamMember adds a member of an organization to one of its teams
+func (c *Client) AddTeamMember(organization, team, username string) error {
+	data, err := json.Marshal(hubGroupMemberRequest{Member: username})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", c.domain+fmt.Sprintf(MembersPerTeamURL, organization, team), bytes.NewBuffer(data))
+	if err != nil {
+		return err
+	}
+	_, err = c.doRequest(req, withHubToken(c.token))
+	return err
+}
+
+//RemoveTeamMember removes a member from a team, the user stays a member of
+//the organization
+func (c *Client) RemoveTeamMember(organization, team, username string) error {
+	req, err := http.NewRequest("DELETE", c.domain+fmt.Sprintf(GroupMemberURL, organization, team, username), nil)
+	if err != nil {
+		return err
+	}
+	_, err = c.doRequest(req, withHubToken(c.token))
+	return err
+}
+
+func toTeam(result hubGroupResult) Team {
+	return Team{
+		ID:          result.ID,
+		Name:        result.Name,
+		Description: result.Description,
+		MemberCount: result.MemberCount,
+	}
+}
+
+type hubGroupRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+type hubGroupMemberRequest struct {
+	Member string `json:"member"`
 }
 
 type hubGroupResponse struct {
@@ -147,4 +236,5 @@ type hubGroupResult struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
 	ID          int    `json:"id"`
+	MemberCount int    `json:"member_count"`
 }
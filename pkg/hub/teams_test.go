@@ -0,0 +1,99 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestGetTeams(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case fmt.Sprintf(GroupsURL, "org"):
+			fmt.Fprint(w, `{"count": 2, "results": [
+				{"id": 2, "name": "writers", "description": "Can push"},
+				{"id": 1, "name": "owners", "description": "Owners"}
+			]}`)
+		case fmt.Sprintf(MembersPerTeamURL, "org", "owners"):
+			fmt.Fprint(w, `[{"username": "alice"}]`)
+		case fmt.Sprintf(MembersPerTeamURL, "org", "writers"):
+			fmt.Fprint(w, `[{"username": "alice"}, {"username": "bob"}]`)
+		default:
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+	}))
+
+	teams, err := client.GetTeams("org")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, teams, []Team{
+		{ID: 1, Name: "owners", Description: "Owners", MemberCount: 1, Members: []Member{{Username: "alice"}}},
+		{ID: 2, Name: "writers", Description: "Can push", MemberCount: 2, Members: []Member{{Username: "alice"}, {Username: "bob"}}},
+	})
+}
+
+func TestCreateTeam(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, r.Method, http.MethodPost)
+		assert.Equal(t, r.URL.Path, fmt.Sprintf(GroupsURL, "org"))
+		var request hubGroupRequest
+		assert.NilError(t, json.NewDecoder(r.Body).Decode(&request))
+		if request.Name == "owners" {
+			w.WriteHeader(http.StatusConflict)
+			fmt.Fprint(w, `{"detail": "A group with this name already exists"}`)
+			return
+		}
+		assert.NilError(t, json.NewEncoder(w).Encode(hubGroupResult{ID: 3, Name: request.Name, Description: request.Description}))
+	}))
+
+	team, err := client.CreateTeam("org", "readers", "Can pull")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, team, &Team{ID: 3, Name: "readers", Description: "Can pull"})
+
+	_, err = client.CreateTeam("org", "owners", "")
+	assert.Assert(t, errors.Is(err, ErrTeamAlreadyExists))
+	assert.Error(t, err, "team already exists: org/owners")
+}
+
+func TestTeamMembership(t *testing.T) {
+	var requests []string
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		if r.Method == http.MethodPost {
+			var request hubGroupMemberRequest
+			assert.NilError(t, json.NewDecoder(r.Body).Decode(&request))
+			assert.Equal(t, request.Member, "bob")
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	assert.NilError(t, client.AddTeamMember("org", "writers", "bob"))
+	assert.NilError(t, client.RemoveTeamMember("org", "writers", "bob"))
+	assert.NilError(t, client.DeleteTeam("org", "writers"))
+	assert.DeepEqual(t, requests, []string{
+		"POST /v2/orgs/org/groups/writers/members/",
+		"DELETE /v2/orgs/org/groups/writers/members/bob/",
+		"DELETE /v2/orgs/org/groups/writers/",
+	})
+}
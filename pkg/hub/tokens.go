@@ -18,10 +18,17 @@ package hub
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -46,77 +53,303 @@ type Token struct {
 	IsActive    bool
 	Token       string
 	Description string
+	AllowedIPs  []string
+	Scopes      []string
+	ExpiresAt   time.Time
+}
+
+// CreateTokenOp represents an option given to CreateToken to customize the created token
+type CreateTokenOp func(*hubTokenRequest) error
+
+// WithAllowedIPs restricts the usage of the created token to the given source
+// IP ranges, expressed in CIDR notation
+func WithAllowedIPs(cidrs ...string) CreateTokenOp {
+	return func(r *hubTokenRequest) error {
+		for _, cidr := range cidrs {
+			if _, _, err := net.ParseCIDR(cidr); err != nil {
+				return fmt.Errorf("invalid allowed IP range %q: %s", cidr, err)
+			}
+		}
+		r.AllowedIPs = append(r.AllowedIPs, cidrs...)
+		return nil
+	}
+}
+
+// WithScopes sets the scopes granted to the created token, e.g. ScopeRepoRead
+func WithScopes(scopes ...string) CreateTokenOp {
+	return func(r *hubTokenRequest) error {
+		if err := validateScopes(scopes); err != nil {
+			return err
+		}
+		r.Scopes = scopes
+		return nil
+	}
+}
+
+// WithExpiration makes the created token expire at the given date
+func WithExpiration(expiresAt time.Time) CreateTokenOp {
+	return func(r *hubTokenRequest) error {
+		if !expiresAt.After(time.Now()) {
+			return fmt.Errorf("invalid token expiration %s: must be in the future", expiresAt.Format(time.RFC3339))
+		}
+		r.ExpiresAt = &expiresAt
+		return nil
+	}
 }
 
 // CreateToken creates a Personal Access Token and returns the token field only once
-func (c *Client) CreateToken(description string) (*Token, error) {
-	data, err := json.Marshal(hubTokenRequest{Description: description})
+func (c *Client) CreateToken(description string, ops ...CreateTokenOp) (*Token, error) {
+	return c.CreateTokenWithContext(context.Background(), description, ops...)
+}
+
+// CreateTokenWithContext is CreateToken, with a context to cancel the request
+func (c *Client) CreateTokenWithContext(ctx context.Context, description string, ops ...CreateTokenOp) (*Token, error) {
+	token, _, err := c.createToken(ctx, description, ops...)
+	return token, err
+}
+
+// CreateTokenRaw creates a Personal Access Token like CreateToken, and also
+// returns the raw Hub API response to capture all the server assigned fields.
+// Beware that the raw response contains the token secret.
+func (c *Client) CreateTokenRaw(description string, ops ...CreateTokenOp) (*Token, json.RawMessage, error) {
+	return c.createToken(context.Background(), description, ops...)
+}
+
+func (c *Client) createToken(ctx context.Context, description string, ops ...CreateTokenOp) (*Token, json.RawMessage, error) {
+	if err := c.validateDescription(description); err != nil {
+		return nil, nil, err
+	}
+	tokenRequest := hubTokenRequest{Description: description}
+	for _, op := range ops {
+		if err := op(&tokenRequest); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := c.checkTokenCapabilities(tokenRequest); err != nil {
+		return nil, nil, err
+	}
+	data, err := json.Marshal(tokenRequest)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	body := bytes.NewBuffer(data)
-	req, err := http.NewRequest("POST", c.domain+TokensURL, body)
+	req, err := http.NewRequestWithContext(ctx, "POST", c.domain+TokensURL, body)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	response, err := c.doRequest(req, withHubToken(c.token))
+	c.cache.invalidate(c.domain + TokensURL)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	var tokenResponse hubTokenResult
 	if err := json.Unmarshal(response, &tokenResponse); err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	if c.dryRun {
+		// The request was echoed back, fill what the Hub would assign
+		tokenResponse.UUID = uuid.Nil.String()
+		tokenResponse.IsActive = true
+		tokenResponse.CreatedAt = time.Now().UTC()
+		token, err := convertToken(tokenResponse)
+		if err != nil {
+			return nil, nil, err
+		}
+		return &token, json.RawMessage(response), nil
 	}
 	token, err := convertToken(tokenResponse)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	return &token, nil
+	if tokenRequest.sink != nil {
+		if err := tokenRequest.sink.WriteSecret(token.UUID.String(), token.Token); err != nil {
+			// The secret can't be read again, leave it to the caller
+			return &token, json.RawMessage(response), fmt.Errorf("token %s created but its secret could not be written: %w", token.UUID, err)
+		}
+		token.Token = ""
+		response, err = scrubSecret(response)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return &token, json.RawMessage(response), nil
 }
 
-//GetTokens calls the hub repo API and returns all the information on all tokens
+//GetTokens calls the hub repo API and returns all the information on all tokens.
+//When fetching all the elements, ErrIncompleteResults is returned with the
+//fetched tokens if there are fewer of them than the announced total.
 func (c *Client) GetTokens() ([]Token, int, error) {
-	u, err := url.Parse(c.domain + TokensURL)
+	return c.GetTokensWithContext(context.Background())
+}
+
+// GetTokensWithContext is GetTokens, with a context to cancel the requests.
+// Once the context is done, no further page is fetched.
+func (c *Client) GetTokensWithContext(ctx context.Context) ([]Token, int, error) {
+	tokens, total, err := c.getTokensWithContext(ctx, c.fetchAllElements)
 	if err != nil {
 		return nil, 0, err
 	}
-	q := url.Values{}
-	q.Add("page_size", fmt.Sprintf("%v", itemsPerPage))
-	q.Add("page", "1")
-	u.RawQuery = q.Encode()
+	if c.fetchAllElements && len(tokens) < total {
+		return tokens, total, ErrIncompleteResults
+	}
+	return tokens, total, nil
+}
 
-	tokens, total, next, err := c.getTokensPage(u.String())
+// GetTokensCreatedBetween returns all the tokens created between from and to,
+// both bounds included. A zero from or to leaves that side of the range open.
+func (c *Client) GetTokensCreatedBetween(from, to time.Time) ([]Token, error) {
+	tokens, _, err := c.getTokens(true)
+	if err != nil {
+		return nil, err
+	}
+	var matching []Token
+	for _, token := range tokens {
+		if !from.IsZero() && token.CreatedAt.Before(from) {
+			continue
+		}
+		if !to.IsZero() && token.CreatedAt.After(to) {
+			continue
+		}
+		matching = append(matching, token)
+	}
+	return matching, nil
+}
+
+// GetTokensEach fetches all the tokens page by page, calling fn with each page
+// as soon as it is fetched. Pagination stops at the first error returned by fn.
+func (c *Client) GetTokensEach(fn func(page []Token) error) error {
+	next, err := c.tokensURL()
+	if err != nil {
+		return err
+	}
+	fetched := 0
+	for next != "" {
+		var (
+			page  []Token
+			total int
+		)
+		page, total, next, err = c.getTokensPage(context.Background(), next)
+		if err != nil {
+			return err
+		}
+		fetched += len(page)
+		c.reportPage(fetched, total)
+		if err := fn(page); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Client) getTokens(all bool) ([]Token, int, error) {
+	return c.getTokensWithContext(context.Background(), all)
+}
+
+func (c *Client) getTokensWithContext(ctx context.Context, all bool) ([]Token, int, error) {
+	u, err := c.tokensURL()
 	if err != nil {
 		return nil, 0, err
 	}
-	if c.fetchAllElements {
+	tokens, total, next, err := c.getTokensPage(ctx, u)
+	if err != nil {
+		return nil, 0, err
+	}
+	c.reportPage(len(tokens), total)
+	if all && c.concurrentPages > 0 && next != "" && len(tokens) > 0 && total > len(tokens) {
+		return c.getTokenPagesConcurrently(ctx, tokens, total, next)
+	}
+	if all {
 		for next != "" {
-			pageTokens, _, n, err := c.getTokensPage(next)
+			if err := ctx.Err(); err != nil {
+				return nil, 0, err
+			}
+			pageTokens, _, n, err := c.getTokensPage(ctx, next)
 			if err != nil {
 				return nil, 0, err
 			}
 			next = n
 			tokens = append(tokens, pageTokens...)
+			c.reportPage(len(tokens), total)
 		}
 	}
 
 	return tokens, total, nil
 }
 
+// getTokenPagesConcurrently fetches the pages following the first one
+// concurrently, guessing their URL from the link to the second page, the page
+// size and the announced total. The tokens are returned in page order.
+func (c *Client) getTokenPagesConcurrently(ctx context.Context, firstPage []Token, total int, next string) ([]Token, int, error) {
+	nextURL, err := url.Parse(next)
+	if err != nil {
+		return nil, 0, err
+	}
+	pageSize := len(firstPage)
+	pageCount := (total + pageSize - 1) / pageSize
+	pages := make([][]Token, pageCount)
+	pages[0] = firstPage
+	errs := make([]error, pageCount)
+
+	var (
+		wg      sync.WaitGroup
+		lock    sync.Mutex
+		fetched = len(firstPage)
+	)
+	sem := make(chan struct{}, c.concurrentPages)
+	for page := 2; page <= pageCount; page++ {
+		if err := ctx.Err(); err != nil {
+			errs[page-1] = err
+			break
+		}
+		pageURL := *nextURL
+		q := pageURL.Query()
+		q.Set("page", strconv.Itoa(page))
+		pageURL.RawQuery = q.Encode()
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(index int, u string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			tokens, _, _, err := c.getTokensPage(ctx, u)
+			if err != nil {
+				errs[index] = err
+				return
+			}
+			pages[index] = tokens
+			lock.Lock()
+			fetched += len(tokens)
+			c.reportPage(fetched, total)
+			lock.Unlock()
+		}(page-1, pageURL.String())
+	}
+	wg.Wait()
+
+	var tokens []Token
+	for i, page := range pages {
+		if errs[i] != nil {
+			return nil, 0, errs[i]
+		}
+		tokens = append(tokens, page...)
+	}
+	return tokens, total, nil
+}
+
 //GetToken calls the hub repo API and returns the information on one token
 func (c *Client) GetToken(tokenUUID string) (*Token, error) {
-	req, err := http.NewRequest("GET", c.domain+fmt.Sprintf(TokenURL, tokenUUID), nil)
+	return c.GetTokenWithContext(context.Background(), tokenUUID)
+}
+
+// GetTokenWithContext is GetToken, with a context to cancel the request
+func (c *Client) GetTokenWithContext(ctx context.Context, tokenUUID string) (*Token, error) {
+	tokenUUID, err := validateTokenUUID(tokenUUID)
 	if err != nil {
 		return nil, err
 	}
-	response, err := c.doRequest(req, withHubToken(c.token))
+	tokenResponse, err := c.getTokenResult(ctx, tokenUUID)
 	if err != nil {
 		return nil, err
 	}
-	var tokenResponse hubTokenResult
-	if err := json.Unmarshal(response, &tokenResponse); err != nil {
-		return nil, err
-	}
 	token, err := convertToken(tokenResponse)
 	if err != nil {
 		return nil, err
@@ -124,26 +357,91 @@ func (c *Client) GetToken(tokenUUID string) (*Token, error) {
 	return &token, nil
 }
 
+func (c *Client) getTokenResult(ctx context.Context, tokenUUID string) (hubTokenResult, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.domain+fmt.Sprintf(TokenURL, tokenUUID), nil)
+	if err != nil {
+		return hubTokenResult{}, err
+	}
+	response, err := c.doCachedRequest(req, withHubToken(c.token))
+	if err != nil {
+		return hubTokenResult{}, err
+	}
+	var tokenResponse hubTokenResult
+	if err := json.Unmarshal(response, &tokenResponse); err != nil {
+		return hubTokenResult{}, err
+	}
+	return tokenResponse, nil
+}
+
 // UpdateToken updates a token's description and activeness
 func (c *Client) UpdateToken(tokenUUID, description string, isActive bool) (*Token, error) {
+	return c.UpdateTokenWithContext(context.Background(), tokenUUID, description, isActive)
+}
+
+// UpdateTokenWithContext is UpdateToken, with a context to cancel the request
+func (c *Client) UpdateTokenWithContext(ctx context.Context, tokenUUID, description string, isActive bool) (*Token, error) {
+	tokenUUID, err := validateTokenUUID(tokenUUID)
+	if err != nil {
+		return nil, err
+	}
 	tokenRequest := hubTokenRequest{IsActive: isActive}
 	if description != "" {
+		if err := c.validateDescription(description); err != nil {
+			return nil, err
+		}
 		tokenRequest.Description = description
 	}
-	data, err := json.Marshal(tokenRequest)
+	return c.patchToken(ctx, tokenUUID, tokenRequest)
+}
+
+// SetTokenActive activates or deactivates a token, leaving its description
+// untouched
+func (c *Client) SetTokenActive(tokenUUID string, active bool) (*Token, error) {
+	tokenUUID, err := validateTokenUUID(tokenUUID)
+	if err != nil {
+		return nil, err
+	}
+	return c.patchToken(context.Background(), tokenUUID, hubTokenPatch{IsActive: &active})
+}
+
+// UpdateTokenDescription changes the description of a token, leaving its
+// activeness untouched
+func (c *Client) UpdateTokenDescription(tokenUUID, description string) (*Token, error) {
+	tokenUUID, err := validateTokenUUID(tokenUUID)
+	if err != nil {
+		return nil, err
+	}
+	if description == "" {
+		return nil, errors.New("invalid token description: empty")
+	}
+	if err := c.validateDescription(description); err != nil {
+		return nil, err
+	}
+	return c.patchToken(context.Background(), tokenUUID, hubTokenPatch{Description: description})
+}
+
+func (c *Client) patchToken(ctx context.Context, tokenUUID string, patch interface{}) (*Token, error) {
+	data, err := json.Marshal(patch)
 	if err != nil {
 		return nil, err
 	}
 	body := bytes.NewBuffer(data)
-	req, err := http.NewRequest("PATCH", c.domain+fmt.Sprintf(TokenURL, tokenUUID), body)
+	req, err := http.NewRequestWithContext(ctx, "PATCH", c.domain+fmt.Sprintf(TokenURL, tokenUUID), body)
 	if err != nil {
 		return nil, err
 	}
 	response, err := c.doRequest(req, withHubToken(c.token))
+	c.cache.invalidate(c.domain + TokensURL)
 	if err != nil {
 		return nil, err
 	}
 	var tokenResponse hubTokenResult
+	if c.dryRun {
+		// The patch was echoed back, apply it to the current token
+		if tokenResponse, err = c.getTokenResult(ctx, tokenUUID); err != nil {
+			return nil, err
+		}
+	}
 	if err := json.Unmarshal(response, &tokenResponse); err != nil {
 		return nil, err
 	}
@@ -154,25 +452,241 @@ func (c *Client) UpdateToken(tokenUUID, description string, isActive bool) (*Tok
 	return &token, nil
 }
 
+// UpdateTokenScopes changes the scopes of an existing token. The Hub API only
+// allows to update the description and the activeness of a token, so once the
+// scopes are validated ErrScopesImmutable is always returned: the token must
+// be rotated, creating a new one with the wanted scopes.
+func (c *Client) UpdateTokenScopes(tokenUUID string, scopes []string) (*Token, error) {
+	tokenUUID, err := validateTokenUUID(tokenUUID)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateScopes(scopes); err != nil {
+		return nil, err
+	}
+	return nil, ErrScopesImmutable
+}
+
 //RemoveToken deletes a token from personal access token
 func (c *Client) RemoveToken(tokenUUID string) error {
+	return c.RemoveTokenWithContext(context.Background(), tokenUUID)
+}
+
+// RemoveTokenWithContext is RemoveToken, with a context to cancel the request
+func (c *Client) RemoveTokenWithContext(ctx context.Context, tokenUUID string) error {
 	//DELETE https://hub.docker.com/v2/api_tokens/8208674e-d08a-426f-b6f4-e3aba7058459 => 202
-	req, err := http.NewRequest("DELETE", c.domain+fmt.Sprintf(TokenURL, tokenUUID), nil)
+	tokenUUID, err := validateTokenUUID(tokenUUID)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "DELETE", c.domain+fmt.Sprintf(TokenURL, tokenUUID), nil)
 	if err != nil {
 		return err
 	}
 	_, err = c.doRequest(req, withHubToken(c.token))
+	c.cache.invalidate(c.domain + TokensURL)
 	return err
 }
 
-func (c *Client) getTokensPage(url string) ([]Token, int, string, error) {
-	req, err := http.NewRequest("GET", url, nil)
+// RevokeTokenByDescription removes the token whose description is exactly the
+// given one. Nothing is removed if no token or several tokens match, or if
+// the token list could not be fetched completely.
+func (c *Client) RevokeTokenByDescription(description string) error {
+	tokens, total, err := c.getTokens(true)
 	if err != nil {
-		return nil, 0, "", err
+		return err
 	}
-	response, err := c.doRequest(req, withHubToken(c.token))
+	if len(tokens) < total {
+		return fmt.Errorf("can't look up token %q: %w", description, ErrIncompleteResults)
+	}
+	var matching []string
+	for _, token := range tokens {
+		if token.Description == description {
+			matching = append(matching, token.UUID.String())
+		}
+	}
+	switch len(matching) {
+	case 0:
+		return fmt.Errorf("no token with description %q", description)
+	case 1:
+		return c.RemoveToken(matching[0])
+	default:
+		return fmt.Errorf("%d tokens have the description %q, revoke one of them by UUID: %s", len(matching), description, strings.Join(matching, ", "))
+	}
+}
+
+// DescriptionMatching returns a token description validator, to be used as
+// Client.DescriptionValidator, enforcing the given naming convention
+func DescriptionMatching(pattern *regexp.Regexp) func(string) error {
+	return func(description string) error {
+		if !pattern.MatchString(description) {
+			return fmt.Errorf("token description %q does not match %q", description, pattern)
+		}
+		return nil
+	}
+}
+
+// validateTokenUUID returns the canonical form of a token UUID, the one to
+// build the API paths with
+func validateTokenUUID(tokenUUID string) (string, error) {
+	u, err := uuid.Parse(tokenUUID)
 	if err != nil {
-		return nil, 0, "", err
+		return "", fmt.Errorf("invalid token UUID %q", tokenUUID)
+	}
+	return u.String(), nil
+}
+
+func (c *Client) validateDescription(description string) error {
+	if c.DescriptionValidator == nil {
+		return nil
+	}
+	return c.DescriptionValidator(description)
+}
+
+// TokenPage is a page of tokens, with the pagination metadata
+type TokenPage struct {
+	Tokens []Token
+	// Total is the number of tokens of all the pages
+	Total int
+	// NextPage is the number of the next page, 0 if this is the last one
+	NextPage int
+	HasMore  bool
+}
+
+// GetTokensPage fetches a single page of tokens, pages being numbered from 1.
+// The page size can't exceed 100 tokens.
+func (c *Client) GetTokensPage(page, pageSize int) (TokenPage, error) {
+	if page < 1 {
+		return TokenPage{}, fmt.Errorf("invalid page %d: pages are numbered from 1", page)
+	}
+	if err := validatePageSize(pageSize); err != nil {
+		return TokenPage{}, err
+	}
+	u, err := c.tokensURL(url.Values{
+		"page":      []string{strconv.Itoa(page)},
+		"page_size": []string{strconv.Itoa(pageSize)},
+	})
+	if err != nil {
+		return TokenPage{}, err
+	}
+	tokens, total, next, err := c.getTokensPage(context.Background(), u)
+	if err != nil {
+		return TokenPage{}, err
+	}
+	tokenPage := TokenPage{Tokens: tokens, Total: total, HasMore: next != ""}
+	if tokenPage.HasMore {
+		tokenPage.NextPage = page + 1
+		if nextURL, err := url.Parse(next); err == nil {
+			if n, err := strconv.Atoi(nextURL.Query().Get("page")); err == nil {
+				tokenPage.NextPage = n
+			}
+		}
+	}
+	return tokenPage, nil
+}
+
+// TokenFilter selects the tokens listed by ListTokensFiltered
+type TokenFilter struct {
+	// ActiveOnly selects the active tokens only
+	ActiveOnly bool
+	// InactiveOnly selects the inactive tokens only
+	InactiveOnly bool
+	// DescriptionContains selects the tokens whose description contains the
+	// given string, case sensitive
+	DescriptionContains string
+}
+
+// ListTokensFiltered lists all the tokens matching the filter. The activeness
+// filter is sent to the Hub API, and the description filter is applied to each
+// page as soon as it is fetched so only the matching tokens are kept.
+func (c *Client) ListTokensFiltered(opts TokenFilter) ([]Token, error) {
+	if opts.ActiveOnly && opts.InactiveOnly {
+		return nil, errors.New("invalid token filter: active only and inactive only are mutually exclusive")
+	}
+	next, err := c.tokensURL(tokenFilterQuery(opts))
+	if err != nil {
+		return nil, err
+	}
+	var matching []Token
+	fetched := 0
+	for next != "" {
+		var (
+			page  []Token
+			total int
+		)
+		page, total, next, err = c.getTokensPage(context.Background(), next)
+		if err != nil {
+			return nil, err
+		}
+		fetched += len(page)
+		c.reportPage(fetched, total)
+		for _, token := range page {
+			if opts.matches(token) {
+				matching = append(matching, token)
+			}
+		}
+	}
+	return matching, nil
+}
+
+func tokenFilterQuery(opts TokenFilter) url.Values {
+	q := url.Values{}
+	switch {
+	case opts.ActiveOnly:
+		q.Set("is_active", "true")
+	case opts.InactiveOnly:
+		q.Set("is_active", "false")
+	}
+	return q
+}
+
+// matches also checks the activeness, in case the Hub API ignored the filter
+func (f TokenFilter) matches(token Token) bool {
+	if (f.ActiveOnly && !token.IsActive) || (f.InactiveOnly && token.IsActive) {
+		return false
+	}
+	return strings.Contains(token.Description, f.DescriptionContains)
+}
+
+func (c *Client) tokensURL(filters ...url.Values) (string, error) {
+	u, err := url.Parse(c.domain + TokensURL)
+	if err != nil {
+		return "", err
+	}
+	q := url.Values{}
+	q.Add("page_size", fmt.Sprintf("%v", c.pageSizeOrDefault()))
+	q.Add("page", "1")
+	for _, filter := range filters {
+		for key, values := range filter {
+			q[key] = values
+		}
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// getTokensPage fetches a page of tokens. A page rejected by the Hub API rate
+// limit is retried according to the client retry policy, see WithRetries, or
+// after waiting for the rate limit to be lifted if there is none, so listing
+// many tokens doesn't fail halfway.
+func (c *Client) getTokensPage(ctx context.Context, url string) ([]Token, int, string, error) {
+	var response []byte
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+		if err != nil {
+			return nil, 0, "", err
+		}
+		response, err = c.doCachedRequest(req, withHubToken(c.token))
+		if err == nil {
+			break
+		}
+		retry, waitErr := c.waitRateLimited(ctx, err, attempt)
+		if waitErr != nil {
+			return nil, 0, "", waitErr
+		}
+		if !retry {
+			return nil, 0, "", err
+		}
 	}
 	var hubResponse hubTokenResponse
 	if err := json.Unmarshal(response, &hubResponse); err != nil {
@@ -190,8 +704,19 @@ func (c *Client) getTokensPage(url string) ([]Token, int, string, error) {
 }
 
 type hubTokenRequest struct {
+	Description string     `json:"token_label,omitempty"`
+	IsActive    bool       `json:"is_active"`
+	AllowedIPs  []string   `json:"allowed_ips,omitempty"`
+	Scopes      []string   `json:"scopes,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+
+	sink SecretSink
+}
+
+// hubTokenPatch only holds the token fields to change
+type hubTokenPatch struct {
 	Description string `json:"token_label,omitempty"`
-	IsActive    bool   `json:"is_active"`
+	IsActive    *bool  `json:"is_active,omitempty"`
 }
 
 type hubTokenResponse struct {
@@ -212,6 +737,9 @@ type hubTokenResult struct {
 	IsActive    bool      `json:"is_active"`
 	Token       string    `json:"token"`
 	TokenLabel  string    `json:"token_label"`
+	AllowedIPs  []string  `json:"allowed_ips,omitempty"`
+	Scopes      []string  `json:"scopes,omitempty"`
+	ExpiresAt   time.Time `json:"expires_at,omitempty"`
 }
 
 func convertToken(response hubTokenResult) (Token, error) {
@@ -230,5 +758,8 @@ func convertToken(response hubTokenResult) (Token, error) {
 		IsActive:    response.IsActive,
 		Token:       response.Token,
 		Description: response.TokenLabel,
+		AllowedIPs:  response.AllowedIPs,
+		Scopes:      response.Scopes,
+		ExpiresAt:   response.ExpiresAt,
 	}, nil
 }
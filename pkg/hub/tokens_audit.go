@@ -0,0 +1,149 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// GroupTokensByClientID groups the tokens by the client which created them.
+// Several tokens under the same ClientID may reveal automated re-creation or
+// a shared integration. Tokens without a ClientID are left out.
+func GroupTokensByClientID(tokens []Token) map[string][]Token {
+	groups := map[string][]Token{}
+	for _, token := range tokens {
+		if token.ClientID == "" {
+			continue
+		}
+		groups[token.ClientID] = append(groups[token.ClientID], token)
+	}
+	return groups
+}
+
+// TokensFromDeprecatedClients returns the tokens whose creator user agent
+// matches one of the given regular expressions, e.g. `hub-tool/v0\.[0-2]\.`.
+// An error is returned if one of the patterns is invalid.
+func TokensFromDeprecatedClients(tokens []Token, deprecatedUAPatterns []string) ([]Token, error) {
+	patterns := make([]*regexp.Regexp, len(deprecatedUAPatterns))
+	for i, pattern := range deprecatedUAPatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid user agent pattern %q: %s", pattern, err)
+		}
+		patterns[i] = re
+	}
+	var offenders []Token
+	for _, token := range tokens {
+		for _, pattern := range patterns {
+			if pattern.MatchString(token.CreatorUA) {
+				offenders = append(offenders, token)
+				break
+			}
+		}
+	}
+	return offenders, nil
+}
+
+// StaleSinceCreation returns the active tokens created more than grace ago
+// which have never been used, likely leftovers of an abandoned provisioning.
+func StaleSinceCreation(tokens []Token, grace time.Duration) []Token {
+	limit := time.Now().Add(-grace)
+	var stale []Token
+	for _, token := range tokens {
+		if token.IsActive && token.LastUsed.IsZero() && token.CreatedAt.Before(limit) {
+			stale = append(stale, token)
+		}
+	}
+	return stale
+}
+
+// TokenFindingReason is why AuditTokens flagged a token
+type TokenFindingReason string
+
+const (
+	// FindingStale flags a token not used for longer than the stale delay
+	FindingStale TokenFindingReason = "stale"
+	// FindingNeverUsed flags a token never used since its creation
+	FindingNeverUsed TokenFindingReason = "never-used"
+	// FindingInactive flags an inactive token which was not removed
+	FindingInactive TokenFindingReason = "inactive"
+)
+
+// TokenFinding is a token flagged by AuditTokens. A token flagged for
+// several reasons has one finding per reason.
+type TokenFinding struct {
+	Token  Token
+	Reason TokenFindingReason
+}
+
+// AuditTokensOp is an option of AuditTokens
+type AuditTokensOp func(*auditTokensOptions) error
+
+type auditTokensOptions struct {
+	now func() time.Time
+}
+
+// WithAuditClock sets the clock the staleness is computed against, time.Now
+// by default
+func WithAuditClock(now func() time.Time) AuditTokensOp {
+	return func(opts *auditTokensOptions) error {
+		if now == nil {
+			return fmt.Errorf("invalid audit clock: nil")
+		}
+		opts.now = now
+		return nil
+	}
+}
+
+// AuditTokens lists all the tokens and flags the ones not used for longer
+// than staleAfter, the ones never used, and the inactive ones.
+// StaleSinceCreation gives a grace period to the tokens never used yet.
+func (c *Client) AuditTokens(staleAfter time.Duration, ops ...AuditTokensOp) ([]TokenFinding, error) {
+	opts := auditTokensOptions{now: time.Now}
+	for _, op := range ops {
+		if err := op(&opts); err != nil {
+			return nil, err
+		}
+	}
+	if staleAfter <= 0 {
+		return nil, fmt.Errorf("invalid stale delay %s: must be positive", staleAfter)
+	}
+	tokens, _, err := c.getTokens(true)
+	if err != nil {
+		return nil, err
+	}
+	return auditTokens(tokens, staleAfter, opts.now()), nil
+}
+
+func auditTokens(tokens []Token, staleAfter time.Duration, now time.Time) []TokenFinding {
+	limit := now.Add(-staleAfter)
+	var findings []TokenFinding
+	for _, token := range tokens {
+		if !token.IsActive {
+			findings = append(findings, TokenFinding{Token: token, Reason: FindingInactive})
+		}
+		switch {
+		case token.LastUsed.IsZero():
+			findings = append(findings, TokenFinding{Token: token, Reason: FindingNeverUsed})
+		case token.LastUsed.Before(limit):
+			findings = append(findings, TokenFinding{Token: token, Reason: FindingStale})
+		}
+	}
+	return findings
+}
@@ -0,0 +1,126 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestGroupTokensByClientID(t *testing.T) {
+	tokens := []Token{
+		{ClientID: "ci", Description: "ci-1"},
+		{ClientID: "laptop", Description: "laptop"},
+		{ClientID: "ci", Description: "ci-2"},
+		{ClientID: "", Description: "unknown"},
+	}
+
+	groups := GroupTokensByClientID(tokens)
+	assert.Equal(t, len(groups), 2)
+	assert.DeepEqual(t, groups["ci"], []Token{tokens[0], tokens[2]})
+	assert.DeepEqual(t, groups["laptop"], []Token{tokens[1]})
+	_, ok := groups[""]
+	assert.Assert(t, !ok, "tokens without client ID should not be grouped")
+
+	assert.Equal(t, len(GroupTokensByClientID(nil)), 0)
+}
+
+func TestTokensFromDeprecatedClients(t *testing.T) {
+	tokens := []Token{
+		{CreatorUA: "hub-tool/v0.2.0", Description: "old"},
+		{CreatorUA: "hub-tool/v0.4.1", Description: "recent"},
+		{CreatorUA: "docker/19.03.12 go/go1.13.10", Description: "docker"},
+		{Description: "unknown"},
+	}
+
+	offenders, err := TokensFromDeprecatedClients(tokens, []string{`^hub-tool/v0\.[0-3]\.`, `^docker/19\.`})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, offenders, []Token{tokens[0], tokens[2]})
+
+	offenders, err = TokensFromDeprecatedClients(tokens, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, len(offenders), 0)
+
+	_, err = TokensFromDeprecatedClients(tokens, []string{`^hub-tool/(v0`})
+	assert.ErrorContains(t, err, "invalid user agent pattern")
+}
+
+func TestStaleSinceCreation(t *testing.T) {
+	now := time.Now()
+	old := now.Add(-48 * time.Hour)
+	recent := now.Add(-time.Hour)
+	tokens := []Token{
+		{Description: "stale", IsActive: true, CreatedAt: old},
+		{Description: "inactive", IsActive: false, CreatedAt: old},
+		{Description: "used", IsActive: true, CreatedAt: old, LastUsed: recent},
+		{Description: "recent", IsActive: true, CreatedAt: recent},
+	}
+
+	var descriptions []string
+	for _, token := range StaleSinceCreation(tokens, 24*time.Hour) {
+		descriptions = append(descriptions, token.Description)
+	}
+	assert.DeepEqual(t, descriptions, []string{"stale"})
+
+	descriptions = nil
+	for _, token := range StaleSinceCreation(tokens, 0) {
+		descriptions = append(descriptions, token.Description)
+	}
+	assert.DeepEqual(t, descriptions, []string{"stale", "recent"})
+}
+
+func TestAuditTokens(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2021, time.March, d, 0, 0, 0, 0, time.UTC) }
+	now := func() time.Time { return day(30) }
+
+	testCases := []struct {
+		name     string
+		token    hubTokenResult
+		expected []TokenFindingReason
+	}{
+		{name: "recent", token: hubTokenResult{IsActive: true, CreatedAt: day(1), LastUsed: day(25)}},
+		{name: "stale", token: hubTokenResult{IsActive: true, CreatedAt: day(1), LastUsed: day(10)}, expected: []TokenFindingReason{FindingStale}},
+		{name: "never used", token: hubTokenResult{IsActive: true, CreatedAt: day(1)}, expected: []TokenFindingReason{FindingNeverUsed}},
+		{name: "new", token: hubTokenResult{IsActive: true, CreatedAt: day(29)}, expected: []TokenFindingReason{FindingNeverUsed}},
+		{name: "inactive", token: hubTokenResult{CreatedAt: day(1), LastUsed: day(5)}, expected: []TokenFindingReason{FindingInactive, FindingStale}},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			client := newTokensTestClient(t, testCase.token)
+
+			findings, err := client.AuditTokens(14*24*time.Hour, WithAuditClock(now))
+			assert.NilError(t, err)
+			var reasons []TokenFindingReason
+			for _, finding := range findings {
+				assert.Equal(t, finding.Token.UUID.String(), testTokenUUID(1))
+				reasons = append(reasons, finding.Reason)
+			}
+			assert.DeepEqual(t, reasons, testCase.expected)
+		})
+	}
+}
+
+func TestAuditTokensValidation(t *testing.T) {
+	client := newTokensTestClient(t)
+
+	_, err := client.AuditTokens(0)
+	assert.ErrorContains(t, err, "invalid stale delay")
+	_, err = client.AuditTokens(time.Hour, WithAuditClock(nil))
+	assert.ErrorContains(t, err, "invalid audit clock")
+}
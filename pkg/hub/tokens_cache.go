@@ -0,0 +1,96 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type tokensCache struct {
+	FetchedAt time.Time `json:"fetched_at"`
+	Tokens    []Token   `json:"tokens"`
+}
+
+// GetTokensCached returns all the tokens like GetTokens, from the cache file
+// at path if it was written less than ttl ago. Otherwise the tokens are
+// fetched from the Hub API and the cache file is rewritten. Token secrets are
+// never written to the cache.
+func (c *Client) GetTokensCached(path string, ttl time.Duration) ([]Token, error) {
+	if tokens, ok := readTokensCache(path, ttl); ok {
+		return tokens, nil
+	}
+	tokens, _, err := c.getTokens(true)
+	if err != nil {
+		return tokens, err
+	}
+	if err := writeTokensCache(path, tokens); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+func readTokensCache(path string, ttl time.Duration) ([]Token, bool) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Debugf("Ignoring unreadable tokens cache %s: %s", path, err)
+		}
+		return nil, false
+	}
+	var cache tokensCache
+	if err := json.Unmarshal(data, &cache); err != nil {
+		log.Debugf("Ignoring corrupted tokens cache %s: %s", path, err)
+		return nil, false
+	}
+	if time.Since(cache.FetchedAt) >= ttl {
+		return nil, false
+	}
+	return cache.Tokens, true
+}
+
+func writeTokensCache(path string, tokens []Token) error {
+	redacted := make([]Token, len(tokens))
+	for i, token := range tokens {
+		token.Token = ""
+		redacted[i] = token
+	}
+	data, err := json.Marshal(tokensCache{FetchedAt: time.Now(), Tokens: redacted})
+	if err != nil {
+		return err
+	}
+	// Write to a temporary file first, so a concurrent reader never sees a
+	// partially written cache
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) //nolint:errcheck
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close() //nolint:errcheck
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,73 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestGetTokensCached(t *testing.T) {
+	calls := 0
+	tokens := serveTokens(t, hubTokenResult{UUID: "6b2e8c4a-1f0e-4c8e-9a4f-000000000001", TokenLabel: "ci", Token: "secret"})
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		tokens.ServeHTTP(w, r)
+	}))
+	path := filepath.Join(t.TempDir(), "tokens.json")
+
+	// Miss: the cache doesn't exist yet
+	fetched, err := client.GetTokensCached(path, time.Hour)
+	assert.NilError(t, err)
+	assert.Equal(t, len(fetched), 1)
+	assert.Equal(t, calls, 1)
+	data, err := ioutil.ReadFile(path)
+	assert.NilError(t, err)
+	assert.Assert(t, !strings.Contains(string(data), "secret"), "secrets must not be cached: %s", data)
+
+	// Hit
+	cached, err := client.GetTokensCached(path, time.Hour)
+	assert.NilError(t, err)
+	assert.Equal(t, calls, 1)
+	assert.Equal(t, cached[0].UUID, fetched[0].UUID)
+	assert.Equal(t, cached[0].Description, "ci")
+	assert.Equal(t, cached[0].Token, "")
+
+	// Expiry
+	var cache tokensCache
+	assert.NilError(t, json.Unmarshal(data, &cache))
+	cache.FetchedAt = time.Now().Add(-2 * time.Hour)
+	data, err = json.Marshal(cache)
+	assert.NilError(t, err)
+	assert.NilError(t, ioutil.WriteFile(path, data, 0600))
+	_, err = client.GetTokensCached(path, time.Hour)
+	assert.NilError(t, err)
+	assert.Equal(t, calls, 2)
+
+	// Corrupted cache
+	assert.NilError(t, ioutil.WriteFile(path, []byte("{"), 0600))
+	_, err = client.GetTokensCached(path, time.Hour)
+	assert.NilError(t, err)
+	assert.Equal(t, calls, 3)
+}
@@ -0,0 +1,244 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// maxConcurrentProvisioning bounds the number of tokens created in
+	// parallel by ProvisionFleet
+	maxConcurrentProvisioning = 4
+	// maxProvisioningRetries is the number of times the creation of a token
+	// is retried when the Hub API rate limit is reached
+	maxProvisioningRetries = 5
+	// maxConcurrentRevocations bounds the number of tokens revoked in
+	// parallel by RevokeTokens
+	maxConcurrentRevocations = 4
+)
+
+// provisioningBackoff is the initial delay before retrying the creation of a
+// rate limited token, doubled after each attempt
+var provisioningBackoff = time.Second
+
+// ProvisionFleet creates count tokens with the same scopes and expiration,
+// labeled labelPrefix-1 to labelPrefix-count. The tokens are created
+// concurrently and retried when the Hub API rate limit is reached. The created
+// tokens, holding their secret, are returned in label order along with an
+// error for each token which couldn't be created.
+func (c *Client) ProvisionFleet(count int, labelPrefix string, scopes []string, expiresAt time.Time) ([]*Token, []error) {
+	if count <= 0 {
+		return nil, []error{fmt.Errorf("invalid fleet size %d: must be positive", count)}
+	}
+	if labelPrefix == "" {
+		return nil, []error{errors.New("invalid fleet label prefix: empty")}
+	}
+	ops := []CreateTokenOp{WithScopes(scopes...), WithExpiration(expiresAt)}
+	// Validate the settings once, rather than failing for each token
+	for _, op := range ops {
+		if err := op(&hubTokenRequest{}); err != nil {
+			return nil, []error{err}
+		}
+	}
+
+	tokens := make([]*Token, count)
+	errs := make([]error, count)
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxConcurrentProvisioning)
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			label := fmt.Sprintf("%s-%d", labelPrefix, i+1)
+			token, err := c.createTokenWithRetries(label, ops...)
+			if err != nil {
+				errs[i] = fmt.Errorf("failed to create token %q: %w", label, err)
+				return
+			}
+			tokens[i] = token
+		}(i)
+	}
+	wg.Wait()
+
+	var created []*Token
+	var failures []error
+	for i := range tokens {
+		if errs[i] != nil {
+			failures = append(failures, errs[i])
+			continue
+		}
+		created = append(created, tokens[i])
+	}
+	return created, failures
+}
+
+// EnsureTokenCount converges the number of tokens labeled
+// descriptionPrefix-N, N being a number, to desired. Missing tokens are
+// created with the given scopes, using the lowest free N. Extra tokens are
+// revoked, inactive ones first and then the oldest. The resulting tokens are
+// returned in label order, only the newly created ones hold their secret. If
+// a revocation or a creation fails, the tokens at that point are returned
+// with the error so the secrets of the tokens already created aren't lost.
+// Nothing is changed if the token list could not be fetched completely.
+func (c *Client) EnsureTokenCount(descriptionPrefix string, desired int, scopes []string) ([]*Token, error) {
+	if desired < 0 {
+		return nil, fmt.Errorf("invalid token count %d: must not be negative", desired)
+	}
+	if descriptionPrefix == "" {
+		return nil, errors.New("invalid token label prefix: empty")
+	}
+	var ops []CreateTokenOp
+	if len(scopes) > 0 {
+		if err := validateScopes(scopes); err != nil {
+			return nil, err
+		}
+		ops = append(ops, WithScopes(scopes...))
+	}
+
+	tokens, total, err := c.getTokens(true)
+	if err != nil {
+		return nil, err
+	}
+	// Counting on a partial listing would create or revoke the wrong tokens
+	if len(tokens) < total {
+		return nil, fmt.Errorf("can't count the %q tokens: %w", descriptionPrefix, ErrIncompleteResults)
+	}
+	var current []*Token
+	labels := map[string]bool{}
+	for i := range tokens {
+		if !isFleetLabel(tokens[i].Description, descriptionPrefix) {
+			continue
+		}
+		token := tokens[i]
+		token.Token = ""
+		current = append(current, &token)
+		labels[token.Description] = true
+	}
+
+	if len(current) > desired {
+		sort.SliceStable(current, func(i, j int) bool {
+			if current[i].IsActive != current[j].IsActive {
+				return !current[i].IsActive
+			}
+			return current[i].CreatedAt.Before(current[j].CreatedAt)
+		})
+		extra := len(current) - desired
+		for i, token := range current[:extra] {
+			if err := c.RemoveToken(token.UUID.String()); err != nil {
+				return sortByLabel(current[i:]), fmt.Errorf("failed to revoke token %q: %w", token.Description, err)
+			}
+		}
+		current = current[extra:]
+	}
+
+	for n := 1; len(current) < desired; n++ {
+		label := fmt.Sprintf("%s-%d", descriptionPrefix, n)
+		if labels[label] {
+			continue
+		}
+		token, err := c.createTokenWithRetries(label, ops...)
+		if err != nil {
+			return sortByLabel(current), fmt.Errorf("failed to create token %q: %w", label, err)
+		}
+		current = append(current, token)
+	}
+	return sortByLabel(current), nil
+}
+
+// isFleetLabel reports if label is prefix-N, N being a number
+func isFleetLabel(label, prefix string) bool {
+	n := strings.TrimPrefix(label, prefix+"-")
+	if n == label || n == "" {
+		return false
+	}
+	for _, r := range n {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func sortByLabel(tokens []*Token) []*Token {
+	sort.SliceStable(tokens, func(i, j int) bool {
+		return tokens[i].Description < tokens[j].Description
+	})
+	return tokens
+}
+
+// RevokeResult is the outcome of the revocation of a token by RevokeTokens
+type RevokeResult struct {
+	UUID string
+	Err  error
+}
+
+// RevokeTokens revokes the given tokens concurrently and returns the result
+// of each revocation, in the order of the UUIDs. A failed revocation doesn't
+// stop the others; the returned error is only set if at least one failed.
+func (c *Client) RevokeTokens(uuids []string) ([]RevokeResult, error) {
+	results := make([]RevokeResult, len(uuids))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxConcurrentRevocations)
+	for i, id := range uuids {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, id string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i] = RevokeResult{UUID: id, Err: c.RemoveToken(id)}
+		}(i, id)
+	}
+	wg.Wait()
+
+	failed := 0
+	for _, result := range results {
+		if result.Err != nil {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return results, fmt.Errorf("failed to revoke %d of %d tokens", failed, len(uuids))
+	}
+	return results, nil
+}
+
+func (c *Client) createTokenWithRetries(label string, ops ...CreateTokenOp) (*Token, error) {
+	backoff := provisioningBackoff
+	for attempt := 0; ; attempt++ {
+		token, err := c.CreateToken(label, ops...)
+		if err == nil || attempt >= maxProvisioningRetries || !IsTooManyRequestsError(err) {
+			return token, err
+		}
+		ctx := c.Ctx
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		if err := sleep(ctx, backoff); err != nil {
+			return nil, err
+		}
+		backoff *= 2
+	}
+}
@@ -0,0 +1,304 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestProvisionFleet(t *testing.T) {
+	provisioningBackoff = time.Millisecond
+	defer func() { provisioningBackoff = time.Second }()
+
+	expiresAt := time.Now().Add(24 * time.Hour).UTC().Truncate(time.Second)
+	var lock sync.Mutex
+	attempts := map[string]int{}
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request hubTokenRequest
+		assert.NilError(t, json.NewDecoder(r.Body).Decode(&request))
+		assert.DeepEqual(t, request.Scopes, []string{ScopeRepoRead})
+		assert.Assert(t, request.ExpiresAt.Equal(expiresAt))
+
+		lock.Lock()
+		attempts[request.Description]++
+		attempt := attempts[request.Description]
+		lock.Unlock()
+		switch {
+		case request.Description == "agent-2" && attempt == 1:
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		case request.Description == "agent-3":
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		index := request.Description[len("agent-"):]
+		assert.NilError(t, json.NewEncoder(w).Encode(hubTokenResult{
+			UUID:       "6b2e8c4a-1f0e-4c8e-9a4f-00000000000" + index,
+			Token:      "secret-" + index,
+			TokenLabel: request.Description,
+			Scopes:     request.Scopes,
+			ExpiresAt:  *request.ExpiresAt,
+		}))
+	}))
+
+	tokens, errs := client.ProvisionFleet(4, "agent", []string{ScopeRepoRead}, expiresAt)
+	assert.Equal(t, len(errs), 1)
+	assert.ErrorContains(t, errs[0], `failed to create token "agent-3"`)
+
+	var labels []string
+	for _, token := range tokens {
+		labels = append(labels, token.Description)
+		assert.Equal(t, token.Token, "secret-"+token.Description[len("agent-"):])
+		assert.Assert(t, token.ExpiresAt.Equal(expiresAt))
+	}
+	assert.DeepEqual(t, labels, []string{"agent-1", "agent-2", "agent-4"})
+	assert.Equal(t, attempts["agent-2"], 2, "rate limited creation should have been retried")
+	assert.Equal(t, attempts["agent-3"], 1, "failed creation should not have been retried")
+}
+
+func TestProvisionFleetStopsRetryingWhenCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	attempts := 0
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		cancel()
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	assert.NilError(t, client.Update(WithContext(ctx)))
+
+	start := time.Now()
+	_, err := client.createTokenWithRetries("agent-1")
+	assert.Assert(t, errors.Is(err, context.Canceled), err)
+	assert.Equal(t, attempts, 1)
+	assert.Assert(t, time.Since(start) < provisioningBackoff, "the backoff should stop with the context")
+}
+
+func TestProvisionFleetValidation(t *testing.T) {
+	client, err := NewClient()
+	assert.NilError(t, err)
+	expiresAt := time.Now().Add(time.Hour)
+
+	testCases := []struct {
+		count    int
+		prefix   string
+		scopes   []string
+		expires  time.Time
+		expected string
+	}{
+		{0, "agent", []string{ScopeRepoRead}, expiresAt, "invalid fleet size 0"},
+		{1, "", []string{ScopeRepoRead}, expiresAt, "invalid fleet label prefix"},
+		{1, "agent", []string{"repo:all"}, expiresAt, `invalid scope "repo:all": unknown action "all"`},
+		{1, "agent", []string{ScopeRepoRead}, time.Now().Add(-time.Hour), "must be in the future"},
+	}
+	for _, testCase := range testCases {
+		_, errs := client.ProvisionFleet(testCase.count, testCase.prefix, testCase.scopes, testCase.expires)
+		assert.Equal(t, len(errs), 1)
+		assert.ErrorContains(t, errs[0], testCase.expected, fmt.Sprintf("case %+v", testCase))
+	}
+}
+
+func serveTokenFleet(t *testing.T, existing []hubTokenResult, created, removed *[]string) http.Handler {
+	t.Helper()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == TokensURL:
+			serveTokens(t, withTestTokenUUIDs(existing)...).ServeHTTP(w, r)
+		case r.Method == http.MethodPost && r.URL.Path == TokensURL:
+			var request hubTokenRequest
+			assert.NilError(t, json.NewDecoder(r.Body).Decode(&request))
+			assert.DeepEqual(t, request.Scopes, []string{ScopeRepoRead})
+			*created = append(*created, request.Description)
+			assert.NilError(t, json.NewEncoder(w).Encode(hubTokenResult{
+				UUID:       testTokenUUID(100 + len(*created)),
+				Token:      "secret",
+				TokenLabel: request.Description,
+				IsActive:   true,
+			}))
+		case r.Method == http.MethodDelete:
+			*removed = append(*removed, r.URL.Path[len(TokensURL)+1:])
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+	})
+}
+
+func TestEnsureTokenCount(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2020, 10, d, 0, 0, 0, 0, time.UTC) }
+
+	testCases := []struct {
+		name     string
+		existing []hubTokenResult
+		count    int
+		created  []string
+		removed  []string
+		labels   []string
+		secrets  []string
+	}{
+		{
+			name: "creates missing tokens",
+			existing: []hubTokenResult{
+				{TokenLabel: "agent-2", IsActive: true},
+				{TokenLabel: "other-1", IsActive: true},
+				{TokenLabel: "agents-1", IsActive: true},
+				{TokenLabel: "agent-deploy", IsActive: true},
+			},
+			count:   3,
+			created: []string{"agent-1", "agent-3"},
+			labels:  []string{"agent-1", "agent-2", "agent-3"},
+			secrets: []string{"secret", "", "secret"},
+		},
+		{
+			name: "revokes inactive then oldest tokens",
+			existing: []hubTokenResult{
+				{TokenLabel: "agent-1", IsActive: true, CreatedAt: day(1)},
+				{TokenLabel: "agent-2", IsActive: true, CreatedAt: day(2)},
+				{TokenLabel: "agent-3", IsActive: false, CreatedAt: day(3)},
+			},
+			count:   1,
+			removed: []string{testTokenUUID(3), testTokenUUID(1)},
+			labels:  []string{"agent-2"},
+			secrets: []string{""},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			var created, removed []string
+			client := newTestClient(t, serveTokenFleet(t, testCase.existing, &created, &removed))
+
+			tokens, err := client.EnsureTokenCount("agent", testCase.count, []string{ScopeRepoRead})
+			assert.NilError(t, err)
+			assert.DeepEqual(t, created, testCase.created)
+			assert.DeepEqual(t, removed, testCase.removed)
+
+			var labels, secrets []string
+			for _, token := range tokens {
+				labels = append(labels, token.Description)
+				secrets = append(secrets, token.Token)
+			}
+			assert.DeepEqual(t, labels, testCase.labels)
+			assert.DeepEqual(t, secrets, testCase.secrets)
+		})
+	}
+}
+
+func TestEnsureTokenCountKeepsCreatedTokensOnFailure(t *testing.T) {
+	var created []string
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			serveTokens(t).ServeHTTP(w, r)
+		case http.MethodPost:
+			if len(created) == 1 {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			created = append(created, "agent-1")
+			fmt.Fprintf(w, `{"uuid": %q, "token": "secret", "token_label": "agent-1"}`, testTokenUUID(101))
+		}
+	}))
+
+	tokens, err := client.EnsureTokenCount("agent", 2, nil)
+	assert.ErrorContains(t, err, `failed to create token "agent-2"`)
+	assert.Equal(t, len(tokens), 1)
+	assert.Equal(t, tokens[0].Description, "agent-1")
+	assert.Equal(t, tokens[0].Token, "secret")
+}
+
+func TestEnsureTokenCountIncompleteListing(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+		assert.NilError(t, json.NewEncoder(w).Encode(hubTokenResponse{
+			Count:   2,
+			Results: []hubTokenResult{{UUID: testTokenUUID(1), TokenLabel: "agent-1", IsActive: true}},
+		}))
+	}))
+
+	tokens, err := client.EnsureTokenCount("agent", 1, nil)
+	assert.Assert(t, errors.Is(err, ErrIncompleteResults))
+	assert.Equal(t, len(tokens), 0)
+}
+
+func TestIsFleetLabel(t *testing.T) {
+	testCases := []struct {
+		label    string
+		expected bool
+	}{
+		{"ci-1", true},
+		{"ci-42", true},
+		{"ci", false},
+		{"ci-", false},
+		{"ci-x", false},
+		{"cilium-deploy", false},
+		{"cilium-1", false},
+		{"ci-1-old", false},
+	}
+	for _, testCase := range testCases {
+		assert.Equal(t, isFleetLabel(testCase.label, "ci"), testCase.expected, testCase.label)
+	}
+}
+
+func TestEnsureTokenCountValidation(t *testing.T) {
+	client, err := NewClient()
+	assert.NilError(t, err)
+
+	_, err = client.EnsureTokenCount("agent", -1, nil)
+	assert.ErrorContains(t, err, "invalid token count -1")
+	_, err = client.EnsureTokenCount("", 1, nil)
+	assert.ErrorContains(t, err, "invalid token label prefix")
+	_, err = client.EnsureTokenCount("agent", 1, []string{"repo:all"})
+	assert.ErrorContains(t, err, `invalid scope "repo:all": unknown action "all"`)
+}
+
+func TestRevokeTokens(t *testing.T) {
+	revoked := testTokenUUID(1)
+	missing := testTokenUUID(2)
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, r.Method, http.MethodDelete)
+		switch r.URL.Path {
+		case fmt.Sprintf(TokenURL, revoked):
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	results, err := client.RevokeTokens([]string{revoked, missing, "not-a-uuid"})
+	assert.Error(t, err, "failed to revoke 2 of 3 tokens")
+	assert.Equal(t, len(results), 3)
+	assert.Equal(t, results[0].UUID, revoked)
+	assert.NilError(t, results[0].Err)
+	assert.Equal(t, results[1].UUID, missing)
+	assert.Assert(t, IsNotFoundError(results[1].Err))
+	assert.ErrorContains(t, results[2].Err, "not-a-uuid")
+
+	results, err = client.RevokeTokens([]string{revoked})
+	assert.NilError(t, err)
+	assert.NilError(t, results[0].Err)
+}
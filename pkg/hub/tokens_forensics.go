@@ -0,0 +1,101 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"fmt"
+	"time"
+)
+
+// IPResolver looks up the location and the network owner of an IP address,
+// e.g. with a GeoIP database
+type IPResolver interface {
+	Resolve(ip string) (IPInfo, error)
+}
+
+// IPInfo is where an IP address comes from. Unknown fields are left empty.
+type IPInfo struct {
+	Country      string
+	City         string
+	ASN          string
+	Organization string
+}
+
+// ForensicReport gathers what is known about the creation of a token
+type ForensicReport struct {
+	Token   Token
+	Summary string
+	// CreatorLocation is the location of the IP address the token was created
+	// from, as returned by the client IPResolver
+	CreatorLocation IPInfo
+}
+
+type noopResolver struct{}
+
+func (noopResolver) Resolve(string) (IPInfo, error) {
+	return IPInfo{}, nil
+}
+
+// WithIPResolver sets the resolver used to locate the IP addresses in the
+// forensic reports. By default IP addresses are not resolved.
+func WithIPResolver(resolver IPResolver) ClientOp {
+	return func(c *Client) error {
+		c.ipResolver = resolver
+		return nil
+	}
+}
+
+// ForensicSummary describes on one line who created the token, when, from
+// where and with which client
+func (t Token) ForensicSummary() string {
+	lastUsed := "never used"
+	if !t.LastUsed.IsZero() {
+		lastUsed = "last used " + t.LastUsed.UTC().Format(time.RFC3339)
+	}
+	return fmt.Sprintf("token %s %q created %s by %s from %s with %q, %s",
+		t.UUID, t.Description, t.CreatedAt.UTC().Format(time.RFC3339), orUnknown(t.GeneratedBy), orUnknown(t.CreatorIP), t.CreatorUA, lastUsed)
+}
+
+// ForensicReport fetches a token and locates the IP address it was created
+// from, using the client IPResolver
+func (c *Client) ForensicReport(tokenUUID string) (ForensicReport, error) {
+	token, err := c.GetToken(tokenUUID)
+	if err != nil {
+		return ForensicReport{}, err
+	}
+	report := ForensicReport{Token: *token, Summary: token.ForensicSummary()}
+	if token.CreatorIP == "" {
+		return report, nil
+	}
+	resolver := c.ipResolver
+	if resolver == nil {
+		resolver = noopResolver{}
+	}
+	location, err := resolver.Resolve(token.CreatorIP)
+	if err != nil {
+		return ForensicReport{}, fmt.Errorf("failed to resolve creator IP %s of token %s: %w", token.CreatorIP, token.UUID, err)
+	}
+	report.CreatorLocation = location
+	return report, nil
+}
+
+func orUnknown(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return s
+}
@@ -0,0 +1,80 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gotest.tools/v3/assert"
+)
+
+type fakeResolver map[string]IPInfo
+
+func (f fakeResolver) Resolve(ip string) (IPInfo, error) {
+	info, ok := f[ip]
+	if !ok {
+		return IPInfo{}, errors.New("unknown address")
+	}
+	return info, nil
+}
+
+func TestForensicSummary(t *testing.T) {
+	token := Token{
+		UUID:        uuid.MustParse("6b2e8c4a-1f0e-4c8e-9a4f-000000000001"),
+		Description: "ci",
+		CreatedAt:   time.Date(2020, 11, 2, 10, 0, 0, 0, time.UTC),
+		GeneratedBy: "manual",
+		CreatorIP:   "192.0.2.1",
+		CreatorUA:   "hub-tool/v0.2.0",
+	}
+	assert.Equal(t, token.ForensicSummary(), `token 6b2e8c4a-1f0e-4c8e-9a4f-000000000001 "ci" created 2020-11-02T10:00:00Z by manual from 192.0.2.1 with "hub-tool/v0.2.0", never used`)
+
+	token.LastUsed = time.Date(2020, 12, 1, 8, 30, 0, 0, time.UTC)
+	token.GeneratedBy, token.CreatorIP = "", ""
+	assert.Equal(t, token.ForensicSummary(), `token 6b2e8c4a-1f0e-4c8e-9a4f-000000000001 "ci" created 2020-11-02T10:00:00Z by unknown from unknown with "hub-tool/v0.2.0", last used 2020-12-01T08:30:00Z`)
+}
+
+func TestForensicReport(t *testing.T) {
+	id := "6b2e8c4a-1f0e-4c8e-9a4f-000000000001"
+	creatorIP := "192.0.2.1"
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, r.URL.Path, fmt.Sprintf(TokenURL, id))
+		assert.NilError(t, json.NewEncoder(w).Encode(hubTokenResult{UUID: id, TokenLabel: "ci", CreatorIP: creatorIP}))
+	}))
+
+	report, err := client.ForensicReport(id)
+	assert.NilError(t, err)
+	assert.Equal(t, report.Token.Description, "ci")
+	assert.Equal(t, report.Summary, report.Token.ForensicSummary())
+	assert.Equal(t, report.CreatorLocation, IPInfo{}, "IP addresses are not resolved by default")
+
+	location := IPInfo{Country: "FR", City: "Paris", ASN: "AS64496", Organization: "Example"}
+	assert.NilError(t, client.Update(WithIPResolver(fakeResolver{creatorIP: location})))
+	report, err = client.ForensicReport(id)
+	assert.NilError(t, err)
+	assert.Equal(t, report.CreatorLocation, location)
+
+	creatorIP = "198.51.100.1"
+	_, err = client.ForensicReport(id)
+	assert.ErrorContains(t, err, "failed to resolve creator IP 198.51.100.1")
+}
@@ -0,0 +1,118 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UnknownLabel is the value of the structured label fields missing from a
+// token label
+const UnknownLabel = "unknown"
+
+// TokenLabels is the metadata encoded in a token label, as
+// "team=<team>,env=<env>,owner=<owner>"
+type TokenLabels struct {
+	Team  string
+	Env   string
+	Owner string
+}
+
+// FleetEntry is a token of the fleet inventory with its decoded labels
+type FleetEntry struct {
+	UUID     uuid.UUID
+	Label    string
+	Labels   TokenLabels
+	LastUsed time.Time
+	IsActive bool
+}
+
+// EncodeTokenLabel encodes the metadata into a token label which can be
+// decoded with DecodeTokenLabel. Empty fields are omitted.
+func EncodeTokenLabel(labels TokenLabels) (string, error) {
+	var fields []string
+	for _, field := range []struct{ key, value string }{
+		{"team", labels.Team},
+		{"env", labels.Env},
+		{"owner", labels.Owner},
+	} {
+		if field.value == "" {
+			continue
+		}
+		if strings.ContainsAny(field.value, ",=") {
+			return "", fmt.Errorf("invalid %s label %q: must not contain ',' or '='", field.key, field.value)
+		}
+		fields = append(fields, field.key+"="+field.value)
+	}
+	return strings.Join(fields, ","), nil
+}
+
+// DecodeTokenLabel decodes a label encoded with EncodeTokenLabel. It returns
+// false if the label is not a structured label, in which case all the fields
+// are UnknownLabel. Missing fields are set to UnknownLabel too.
+func DecodeTokenLabel(label string) (TokenLabels, bool) {
+	labels := TokenLabels{Team: UnknownLabel, Env: UnknownLabel, Owner: UnknownLabel}
+	if strings.TrimSpace(label) == "" {
+		return labels, false
+	}
+	decoded := labels
+	for _, field := range strings.Split(label, ",") {
+		parts := strings.SplitN(field, "=", 2)
+		if len(parts) != 2 {
+			return labels, false
+		}
+		key, value := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		if value == "" {
+			continue
+		}
+		switch key {
+		case "team":
+			decoded.Team = value
+		case "env":
+			decoded.Env = value
+		case "owner":
+			decoded.Owner = value
+		}
+	}
+	return decoded, true
+}
+
+// FleetInventory lists all the tokens with the metadata decoded from their
+// labels. Tokens without a structured label are reported with UnknownLabel
+// fields.
+func (c *Client) FleetInventory() ([]FleetEntry, error) {
+	tokens, _, err := c.getTokens(true)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]FleetEntry, 0, len(tokens))
+	for _, token := range tokens {
+		labels, _ := DecodeTokenLabel(token.Description)
+		entries = append(entries, FleetEntry{
+			UUID:     token.UUID,
+			Label:    token.Description,
+			Labels:   labels,
+			LastUsed: token.LastUsed,
+			IsActive: token.IsActive,
+		})
+	}
+	return entries, nil
+}
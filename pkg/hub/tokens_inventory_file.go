@@ -0,0 +1,175 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// InventoryToken is a token described in an inventory file
+type InventoryToken struct {
+	Description string   `json:"description" yaml:"description"`
+	Scopes      []string `json:"scopes" yaml:"scopes"`
+}
+
+// TokenInventory is the content of an inventory file, listing the desired
+// tokens:
+//
+//	tokens:
+//	  - description: ci
+//	    scopes: [repo:read]
+type TokenInventory struct {
+	Tokens []InventoryToken `json:"tokens" yaml:"tokens"`
+}
+
+// ReconcileResult reports the differences between an inventory and the
+// existing tokens, and the changes applied
+type ReconcileResult struct {
+	// ToCreate lists the inventory tokens without a matching active token
+	ToCreate []InventoryToken
+	// Extra lists the existing tokens not in the inventory, candidates for
+	// revocation
+	Extra []Token
+	// Matching lists the existing tokens matching an inventory token
+	Matching []Token
+	// Created lists the tokens created, holding their secret
+	Created []*Token
+	// Revoked lists the tokens revoked
+	Revoked []Token
+}
+
+// ReconcileOp is an option given to ReconcileFromInventory
+type ReconcileOp func(*reconcileOptions)
+
+type reconcileOptions struct {
+	create bool
+	revoke bool
+}
+
+// WithCreateMissing makes ReconcileFromInventory create the missing tokens
+func WithCreateMissing() ReconcileOp {
+	return func(o *reconcileOptions) {
+		o.create = true
+	}
+}
+
+// WithRevokeExtra makes ReconcileFromInventory revoke the extra tokens
+func WithRevokeExtra() ReconcileOp {
+	return func(o *reconcileOptions) {
+		o.revoke = true
+	}
+}
+
+// ReconcileFromInventory compares the tokens described in a JSON or YAML
+// inventory file with the existing ones. An active token matches an inventory
+// token when they have the same description and equivalent scopes. As the
+// scopes of a token can't be changed, a token whose scopes differ is reported
+// as extra and the inventory token as to create. Nothing is changed unless
+// WithCreateMissing or WithRevokeExtra are given.
+func (c *Client) ReconcileFromInventory(path string, ops ...ReconcileOp) (ReconcileResult, error) {
+	var opts reconcileOptions
+	for _, op := range ops {
+		op(&opts)
+	}
+	inventory, err := readTokenInventory(path)
+	if err != nil {
+		return ReconcileResult{}, err
+	}
+	tokens, _, err := c.getTokens(true)
+	if err != nil {
+		return ReconcileResult{}, err
+	}
+
+	result := diffTokenInventory(inventory, tokens)
+	if opts.create {
+		for _, desired := range result.ToCreate {
+			token, err := c.CreateToken(desired.Description, WithScopes(desired.Scopes...))
+			if err != nil {
+				return result, fmt.Errorf("failed to create token %q: %w", desired.Description, err)
+			}
+			result.Created = append(result.Created, token)
+		}
+	}
+	if opts.revoke {
+		for _, token := range result.Extra {
+			if err := c.RemoveToken(token.UUID.String()); err != nil {
+				return result, fmt.Errorf("failed to revoke token %q: %w", token.Description, err)
+			}
+			result.Revoked = append(result.Revoked, token)
+		}
+	}
+	return result, nil
+}
+
+func diffTokenInventory(inventory TokenInventory, tokens []Token) ReconcileResult {
+	var result ReconcileResult
+	matched := make([]bool, len(tokens))
+	for _, desired := range inventory.Tokens {
+		found := false
+		for i, token := range tokens {
+			if matched[i] || !token.IsActive || token.Description != desired.Description {
+				continue
+			}
+			if TokensEquivalent(token, Token{Scopes: desired.Scopes}) {
+				matched[i] = true
+				found = true
+				result.Matching = append(result.Matching, token)
+				break
+			}
+		}
+		if !found {
+			result.ToCreate = append(result.ToCreate, desired)
+		}
+	}
+	for i, token := range tokens {
+		if !matched[i] {
+			result.Extra = append(result.Extra, token)
+		}
+	}
+	return result
+}
+
+func readTokenInventory(path string) (TokenInventory, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return TokenInventory{}, err
+	}
+	var inventory TokenInventory
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = json.Unmarshal(data, &inventory)
+	} else {
+		err = yaml.UnmarshalStrict(data, &inventory)
+	}
+	if err != nil {
+		return TokenInventory{}, fmt.Errorf("invalid token inventory %s: %w", path, err)
+	}
+	for i, token := range inventory.Tokens {
+		if token.Description == "" {
+			return TokenInventory{}, fmt.Errorf("invalid token inventory %s: token %d: description is required", path, i+1)
+		}
+		if err := validateScopes(token.Scopes); err != nil {
+			return TokenInventory{}, fmt.Errorf("invalid token inventory %s: token %q: %w", path, token.Description, err)
+		}
+	}
+	return inventory, nil
+}
@@ -0,0 +1,96 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+const testInventory = `tokens:
+  - description: ci
+    scopes: [repo:read]
+  - description: deploy
+    scopes: [repo:write, repo:read]
+  - description: release
+    scopes: [repo:admin]
+`
+
+func writeInventory(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	assert.NilError(t, ioutil.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestReconcileFromInventory(t *testing.T) {
+	var created, removed []string
+	client := newTestClient(t, serveTokenFleet(t, []hubTokenResult{
+		{UUID: "6b2e8c4a-1f0e-4c8e-9a4f-000000000001", TokenLabel: "ci", IsActive: true, Scopes: []string{ScopeRepoRead}},
+		{UUID: "6b2e8c4a-1f0e-4c8e-9a4f-000000000002", TokenLabel: "deploy", IsActive: true, Scopes: []string{ScopeRepoWrite}},
+		{UUID: "6b2e8c4a-1f0e-4c8e-9a4f-000000000003", TokenLabel: "release", IsActive: true, Scopes: []string{ScopeRepoWrite}},
+		{UUID: "6b2e8c4a-1f0e-4c8e-9a4f-000000000004", TokenLabel: "laptop", IsActive: true},
+	}, &created, &removed))
+	path := writeInventory(t, "tokens.yaml", testInventory)
+
+	result, err := client.ReconcileFromInventory(path)
+	assert.NilError(t, err)
+	var matching, extra []string
+	for _, token := range result.Matching {
+		matching = append(matching, token.Description)
+	}
+	for _, token := range result.Extra {
+		extra = append(extra, token.Description)
+	}
+	assert.DeepEqual(t, matching, []string{"ci", "deploy"})
+	assert.DeepEqual(t, result.ToCreate, []InventoryToken{{Description: "release", Scopes: []string{ScopeRepoAdmin}}})
+	assert.DeepEqual(t, extra, []string{"release", "laptop"})
+	assert.Equal(t, len(created)+len(removed), 0, "nothing should be changed by default")
+
+	// serveTokenFleet only creates tokens with the repo:read scope
+	path = writeInventory(t, "tokens.json", `{"tokens": [{"description": "ci", "scopes": ["repo:read"]}, {"description": "new", "scopes": ["repo:read"]}]}`)
+	result, err = client.ReconcileFromInventory(path, WithCreateMissing(), WithRevokeExtra())
+	assert.NilError(t, err)
+	assert.DeepEqual(t, created, []string{"new"})
+	assert.Equal(t, result.Created[0].Token, "secret")
+	assert.DeepEqual(t, removed, []string{
+		"6b2e8c4a-1f0e-4c8e-9a4f-000000000002",
+		"6b2e8c4a-1f0e-4c8e-9a4f-000000000003",
+		"6b2e8c4a-1f0e-4c8e-9a4f-000000000004",
+	})
+	assert.Equal(t, len(result.Revoked), 3)
+}
+
+func TestReadTokenInventoryErrors(t *testing.T) {
+	testCases := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{"tokens.yaml", "tokens:\n  - scopes: [repo:read]\n", "token 1: description is required"},
+		{"tokens.yaml", "tokens:\n  - description: ci\n    scopes: [repo:all]\n", `token "ci": invalid scope "repo:all": unknown action "all"`},
+		{"tokens.yaml", "tokens:\n  - description: ci\n    scope: [repo:read]\n", "field scope not found"},
+		{"tokens.json", `{"tokens": {}}`, "cannot unmarshal object"},
+	}
+	for _, tc := range testCases {
+		_, err := readTokenInventory(writeInventory(t, tc.name, tc.content))
+		assert.ErrorContains(t, err, tc.expected)
+	}
+}
@@ -0,0 +1,78 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestTokenLabelRoundTrip(t *testing.T) {
+	label, err := EncodeTokenLabel(TokenLabels{Team: "platform", Env: "prod", Owner: "jane"})
+	assert.NilError(t, err)
+	assert.Equal(t, label, "team=platform,env=prod,owner=jane")
+
+	labels, ok := DecodeTokenLabel(label)
+	assert.Assert(t, ok)
+	assert.DeepEqual(t, labels, TokenLabels{Team: "platform", Env: "prod", Owner: "jane"})
+
+	_, err = EncodeTokenLabel(TokenLabels{Team: "a,b"})
+	assert.Error(t, err, `invalid team label "a,b": must not contain ',' or '='`)
+}
+
+func TestDecodeTokenLabel(t *testing.T) {
+	unknown := TokenLabels{Team: UnknownLabel, Env: UnknownLabel, Owner: UnknownLabel}
+	testCases := []struct {
+		label    string
+		expected TokenLabels
+		ok       bool
+	}{
+		{"", unknown, false},
+		{"my laptop", unknown, false},
+		{"team=platform,my laptop", unknown, false},
+		{"team=platform", TokenLabels{Team: "platform", Env: UnknownLabel, Owner: UnknownLabel}, true},
+		{" env = ci , owner=bob,extra=1", TokenLabels{Team: UnknownLabel, Env: "ci", Owner: "bob"}, true},
+	}
+	for _, tc := range testCases {
+		labels, ok := DecodeTokenLabel(tc.label)
+		assert.Equal(t, ok, tc.ok, tc.label)
+		assert.DeepEqual(t, labels, tc.expected)
+	}
+}
+
+func TestFleetInventory(t *testing.T) {
+	lastUsed := time.Date(2020, 10, 1, 0, 0, 0, 0, time.UTC)
+	client := newTestClient(t, serveTokens(t,
+		hubTokenResult{UUID: "6b2e8c4a-1f0e-4c8e-9a4f-000000000001", TokenLabel: "team=platform,env=prod,owner=jane", LastUsed: lastUsed, IsActive: true},
+		hubTokenResult{UUID: "6b2e8c4a-1f0e-4c8e-9a4f-000000000002", TokenLabel: "legacy token"},
+	))
+
+	entries, err := client.FleetInventory()
+	assert.NilError(t, err)
+	assert.Equal(t, len(entries), 2)
+
+	assert.Equal(t, entries[0].UUID.String(), "6b2e8c4a-1f0e-4c8e-9a4f-000000000001")
+	assert.DeepEqual(t, entries[0].Labels, TokenLabels{Team: "platform", Env: "prod", Owner: "jane"})
+	assert.Equal(t, entries[0].LastUsed, lastUsed)
+	assert.Assert(t, entries[0].IsActive)
+
+	assert.Equal(t, entries[1].Label, "legacy token")
+	assert.DeepEqual(t, entries[1].Labels, TokenLabels{Team: UnknownLabel, Env: UnknownLabel, Owner: UnknownLabel})
+	assert.Assert(t, !entries[1].IsActive)
+}
@@ -0,0 +1,175 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// lastUseBuckets are the upper bounds, in days, of the histogram of the days
+// since the tokens were last used
+var lastUseBuckets = []float64{1, 7, 30, 90, 180, 365}
+
+// tokenStats are the figures exported by the token metrics
+type tokenStats struct {
+	total     int
+	inactive  int
+	neverUsed int
+	// lastUses are the tokens which were used at least once, with the number
+	// of days since their last use
+	lastUses []tokenLastUse
+}
+
+type tokenLastUse struct {
+	token Token
+	days  float64
+}
+
+func computeTokenStats(tokens []Token) tokenStats {
+	stats := tokenStats{total: len(tokens)}
+	now := time.Now()
+	for _, token := range tokens {
+		if !token.IsActive {
+			stats.inactive++
+		}
+		if token.LastUsed.IsZero() {
+			stats.neverUsed++
+			continue
+		}
+		stats.lastUses = append(stats.lastUses, tokenLastUse{token: token, days: now.Sub(token.LastUsed).Hours() / 24})
+	}
+	return stats
+}
+
+// bucket returns the number of tokens last used at most bound days ago, and
+// the first token last used between lower and bound days ago, to be used as
+// the exemplar of the bucket
+func (s tokenStats) bucket(lower, bound float64) (int, *tokenLastUse) {
+	count := 0
+	var exemplar *tokenLastUse
+	for i, lastUse := range s.lastUses {
+		if lastUse.days <= bound {
+			count++
+			if exemplar == nil && lastUse.days > lower {
+				exemplar = &s.lastUses[i]
+			}
+		}
+	}
+	return count, exemplar
+}
+
+func (s tokenStats) sum() float64 {
+	var sum float64
+	for _, lastUse := range s.lastUses {
+		sum += lastUse.days
+	}
+	return sum
+}
+
+// WriteTokenMetrics writes metrics about the tokens in the Prometheus text
+// exposition format, e.g. for the node exporter textfile collector. Tokens
+// which were never used are left out of the days since last use histogram.
+func WriteTokenMetrics(w io.Writer, tokens []Token) error {
+	stats := computeTokenStats(tokens)
+
+	out := bufio.NewWriter(w)
+	writeGauge(out, "hub_tokens_total", "Number of personal access tokens.", stats.total)
+	writeGauge(out, "hub_tokens_inactive", "Number of inactive personal access tokens.", stats.inactive)
+	writeGauge(out, "hub_tokens_never_used", "Number of personal access tokens never used.", stats.neverUsed)
+
+	const histogram = "hub_tokens_days_since_last_use"
+	fmt.Fprintf(out, "# HELP %s Days since the personal access tokens were last used.\n", histogram)
+	fmt.Fprintf(out, "# TYPE %s histogram\n", histogram)
+	for _, bound := range lastUseBuckets {
+		count, _ := stats.bucket(0, bound)
+		fmt.Fprintf(out, "%s_bucket{le=%q} %d\n", histogram, formatFloat(bound), count)
+	}
+	fmt.Fprintf(out, "%s_bucket{le=\"+Inf\"} %d\n", histogram, len(stats.lastUses))
+	fmt.Fprintf(out, "%s_sum %s\n", histogram, formatFloat(stats.sum()))
+	fmt.Fprintf(out, "%s_count %d\n", histogram, len(stats.lastUses))
+	return out.Flush()
+}
+
+// WriteTokenOpenMetrics writes the metrics of WriteTokenMetrics in the
+// OpenMetrics format. Each token is also described by an info metric labeled
+// with its UUID, which is used as exemplar of the histogram buckets.
+func WriteTokenOpenMetrics(w io.Writer, tokens []Token) error {
+	stats := computeTokenStats(tokens)
+
+	out := bufio.NewWriter(w)
+	fmt.Fprint(out, "# TYPE hub_token info\n")
+	fmt.Fprint(out, "# HELP hub_token Personal access token.\n")
+	for _, token := range tokens {
+		fmt.Fprintf(out, "hub_token_info{uuid=\"%s\",description=\"%s\",active=\"%t\"} 1\n",
+			token.UUID, escapeLabelValue(token.Description), token.IsActive)
+	}
+	writeOpenMetricsGauge(out, "hub_tokens", "Number of personal access tokens.", stats.total)
+	writeOpenMetricsGauge(out, "hub_tokens_inactive", "Number of inactive personal access tokens.", stats.inactive)
+	writeOpenMetricsGauge(out, "hub_tokens_never_used", "Number of personal access tokens never used.", stats.neverUsed)
+
+	const histogram = "hub_tokens_last_use_age_days"
+	fmt.Fprintf(out, "# TYPE %s histogram\n", histogram)
+	fmt.Fprintf(out, "# UNIT %s days\n", histogram)
+	fmt.Fprintf(out, "# HELP %s Days since the personal access tokens were last used.\n", histogram)
+	lower := math.Inf(-1)
+	for _, bound := range lastUseBuckets {
+		count, exemplar := stats.bucket(lower, bound)
+		fmt.Fprintf(out, "%s_bucket{le=\"%s\"} %d%s\n", histogram, strconv.FormatFloat(bound, 'f', 1, 64), count, formatExemplar(exemplar))
+		lower = bound
+	}
+	_, exemplar := stats.bucket(lower, math.Inf(1))
+	fmt.Fprintf(out, "%s_bucket{le=\"+Inf\"} %d%s\n", histogram, len(stats.lastUses), formatExemplar(exemplar))
+	fmt.Fprintf(out, "%s_sum %s\n", histogram, formatFloat(stats.sum()))
+	fmt.Fprintf(out, "%s_count %d\n", histogram, len(stats.lastUses))
+	fmt.Fprint(out, "# EOF\n")
+	return out.Flush()
+}
+
+func writeGauge(w io.Writer, name, help string, value int) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(w, "# TYPE %s gauge\n", name)
+	fmt.Fprintf(w, "%s %d\n", name, value)
+}
+
+func writeOpenMetricsGauge(w io.Writer, name, help string, value int) {
+	fmt.Fprintf(w, "# TYPE %s gauge\n", name)
+	fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(w, "%s %d\n", name, value)
+}
+
+func formatExemplar(lastUse *tokenLastUse) string {
+	if lastUse == nil {
+		return ""
+	}
+	return fmt.Sprintf(" # {token_uuid=\"%s\"} %s", lastUse.token.UUID, formatFloat(lastUse.days))
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(value string) string {
+	return labelValueEscaper.Replace(value)
+}
@@ -0,0 +1,137 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"bytes"
+	"math"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"gotest.tools/v3/assert"
+)
+
+func TestWriteTokenMetrics(t *testing.T) {
+	daysAgo := func(days int) time.Time {
+		return time.Now().Add(-time.Duration(days)*24*time.Hour - time.Hour)
+	}
+	tokens := []Token{
+		{IsActive: true, LastUsed: daysAgo(0)},
+		{IsActive: true, LastUsed: daysAgo(10)},
+		{IsActive: false, LastUsed: daysAgo(400)},
+		{IsActive: false},
+	}
+	var buf bytes.Buffer
+	assert.NilError(t, WriteTokenMetrics(&buf, tokens))
+
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(&buf)
+	assert.NilError(t, err)
+
+	gauge := func(name string) float64 {
+		family, ok := families[name]
+		assert.Assert(t, ok, "missing metric %s", name)
+		assert.Equal(t, family.GetType(), dto.MetricType_GAUGE)
+		return family.GetMetric()[0].GetGauge().GetValue()
+	}
+	assert.Equal(t, gauge("hub_tokens_total"), float64(4))
+	assert.Equal(t, gauge("hub_tokens_inactive"), float64(2))
+	assert.Equal(t, gauge("hub_tokens_never_used"), float64(1))
+
+	family, ok := families["hub_tokens_days_since_last_use"]
+	assert.Assert(t, ok)
+	histogram := family.GetMetric()[0].GetHistogram()
+	assert.Equal(t, histogram.GetSampleCount(), uint64(3))
+	counts := map[float64]uint64{}
+	for _, bucket := range histogram.GetBucket() {
+		counts[bucket.GetUpperBound()] = bucket.GetCumulativeCount()
+	}
+	assert.DeepEqual(t, counts, map[float64]uint64{1: 1, 7: 1, 30: 2, 90: 2, 180: 2, 365: 2, math.Inf(1): 3})
+}
+
+func TestWriteTokenOpenMetrics(t *testing.T) {
+	tokens := []Token{
+		{UUID: uuid.MustParse("6b2e8c4a-1f0e-4c8e-9a4f-000000000001"), Description: "ci", IsActive: true, LastUsed: time.Now().Add(-time.Hour)},
+		{UUID: uuid.MustParse("6b2e8c4a-1f0e-4c8e-9a4f-000000000002"), Description: "say \"hi\"\n", LastUsed: time.Now().Add(-20 * 24 * time.Hour)},
+		{UUID: uuid.MustParse("6b2e8c4a-1f0e-4c8e-9a4f-000000000003"), Description: "never used"},
+	}
+	var buf bytes.Buffer
+	assert.NilError(t, WriteTokenOpenMetrics(&buf, tokens))
+	output := buf.String()
+
+	assert.Assert(t, strings.HasSuffix(output, "\n# EOF\n"))
+	assert.Assert(t, strings.Contains(output, "# UNIT hub_tokens_last_use_age_days days\n"))
+	assert.Assert(t, strings.Contains(output, `hub_tokens_last_use_age_days_bucket{le="1.0"} 1 # {token_uuid="6b2e8c4a-1f0e-4c8e-9a4f-000000000001"}`), output)
+	assert.Assert(t, strings.Contains(output, `hub_tokens_last_use_age_days_bucket{le="30.0"} 2 # {token_uuid="6b2e8c4a-1f0e-4c8e-9a4f-000000000002"}`), output)
+	assert.Assert(t, strings.Contains(output, "hub_tokens_last_use_age_days_bucket{le=\"7.0\"} 1\n"), "empty buckets have no exemplar")
+
+	families := parseOpenMetrics(t, output)
+	info := families["hub_token_info"]
+	assert.Equal(t, info.GetType(), dto.MetricType_GAUGE)
+	assert.Equal(t, len(info.GetMetric()), 3)
+	labels := map[string]string{}
+	for _, label := range info.GetMetric()[1].GetLabel() {
+		labels[label.GetName()] = label.GetValue()
+	}
+	assert.DeepEqual(t, labels, map[string]string{
+		"uuid":        "6b2e8c4a-1f0e-4c8e-9a4f-000000000002",
+		"description": "say \"hi\"\n",
+		"active":      "false",
+	})
+	assert.Equal(t, families["hub_tokens_never_used"].GetMetric()[0].GetGauge().GetValue(), float64(1))
+
+	histogram := families["hub_tokens_last_use_age_days"].GetMetric()[0].GetHistogram()
+	assert.Equal(t, histogram.GetSampleCount(), uint64(2))
+	counts := map[float64]uint64{}
+	for _, bucket := range histogram.GetBucket() {
+		counts[bucket.GetUpperBound()] = bucket.GetCumulativeCount()
+	}
+	assert.DeepEqual(t, counts, map[float64]uint64{1: 1, 7: 1, 30: 2, 90: 2, 180: 2, 365: 2, math.Inf(1): 2})
+}
+
+// openMetricsExemplar matches the exemplar ending an OpenMetrics sample
+var openMetricsExemplar = regexp.MustCompile(` # \{[^}]*\} \S+$`)
+
+// parseOpenMetrics parses the OpenMetrics output with the Prometheus text
+// parser, once the OpenMetrics only syntax is translated: the exemplars, the
+// units and the EOF marker are dropped, the info families become gauges.
+func parseOpenMetrics(t *testing.T, output string) map[string]*dto.MetricFamily {
+	t.Helper()
+	var text strings.Builder
+	for _, line := range strings.Split(strings.TrimSuffix(output, "# EOF\n"), "\n") {
+		fields := strings.SplitN(line, " ", 4)
+		switch {
+		case len(fields) >= 3 && fields[0] == "#" && fields[1] == "UNIT":
+			continue
+		case len(fields) == 4 && fields[0] == "#" && fields[1] == "TYPE" && fields[3] == "info":
+			line = "# TYPE " + fields[2] + "_info gauge"
+		case len(fields) >= 3 && fields[0] == "#" && fields[1] == "HELP" && strings.Contains(output, "# TYPE "+fields[2]+" info\n"):
+			line = strings.Replace(line, fields[2], fields[2]+"_info", 1)
+		}
+		text.WriteString(openMetricsExemplar.ReplaceAllString(line, "") + "\n")
+	}
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(strings.NewReader(text.String()))
+	assert.NilError(t, err, text.String())
+	return families
+}
@@ -0,0 +1,108 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// EnforceMaxTokenAge removes all the tokens created more than maxAge ago and
+// returns their UUIDs. In dry-run mode, the tokens are only listed and nothing
+// is removed. On failure, the tokens removed so far are returned with the error.
+func (c *Client) EnforceMaxTokenAge(maxAge time.Duration, dryRun bool) ([]uuid.UUID, error) {
+	if maxAge <= 0 {
+		return nil, fmt.Errorf("invalid maximum token age %s: must be positive", maxAge)
+	}
+	tokens, _, err := c.getTokens(true)
+	if err != nil {
+		return nil, err
+	}
+	limit := time.Now().Add(-maxAge)
+	var revoked []uuid.UUID
+	for _, token := range tokens {
+		if !token.CreatedAt.Before(limit) {
+			continue
+		}
+		if !dryRun {
+			if err := c.RemoveToken(token.UUID.String()); err != nil {
+				return revoked, err
+			}
+		}
+		revoked = append(revoked, token.UUID)
+	}
+	return revoked, nil
+}
+
+// SnapshotTokenStates returns the active state of all the tokens, to be
+// restored later with RestoreTokenStates
+func (c *Client) SnapshotTokenStates() (map[uuid.UUID]bool, error) {
+	tokens, _, err := c.getTokens(true)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := make(map[uuid.UUID]bool, len(tokens))
+	for _, token := range tokens {
+		snapshot[token.UUID] = token.IsActive
+	}
+	return snapshot, nil
+}
+
+// RestoreTokenStates activates or deactivates the tokens to match the given
+// snapshot. Only the tokens whose state changed are updated, and their
+// descriptions are left untouched. Tokens removed since the snapshot was
+// taken are reported in the returned error once all the others are restored.
+func (c *Client) RestoreTokenStates(snapshot map[uuid.UUID]bool) error {
+	tokens, _, err := c.getTokens(true)
+	if err != nil {
+		return err
+	}
+	current := make(map[uuid.UUID]bool, len(tokens))
+	for _, token := range tokens {
+		current[token.UUID] = token.IsActive
+	}
+	// Restore in a stable order so the updates and the missing tokens don't
+	// change between runs
+	tokenUUIDs := make([]uuid.UUID, 0, len(snapshot))
+	for tokenUUID := range snapshot {
+		tokenUUIDs = append(tokenUUIDs, tokenUUID)
+	}
+	sort.Slice(tokenUUIDs, func(i, j int) bool { return tokenUUIDs[i].String() < tokenUUIDs[j].String() })
+	var missing []string
+	for _, tokenUUID := range tokenUUIDs {
+		isActive := snapshot[tokenUUID]
+		wasActive, ok := current[tokenUUID]
+		if !ok {
+			missing = append(missing, tokenUUID.String())
+			continue
+		}
+		if wasActive == isActive {
+			continue
+		}
+		if _, err := c.UpdateToken(tokenUUID.String(), "", isActive); err != nil {
+			return err
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("tokens not found, their state could not be restored: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gotest.tools/v3/assert"
+)
+
+func TestEnforceMaxTokenAge(t *testing.T) {
+	old := hubTokenResult{UUID: "6b2e8c4a-1f0e-4c8e-9a4f-000000000001", CreatedAt: time.Now().Add(-48 * time.Hour)}
+	recent := hubTokenResult{UUID: "6b2e8c4a-1f0e-4c8e-9a4f-000000000002", CreatedAt: time.Now().Add(-time.Hour)}
+	list := serveTokens(t, old, recent)
+
+	for _, dryRun := range []bool{true, false} {
+		var deleted []string
+		client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodDelete {
+				deleted = append(deleted, strings.TrimPrefix(r.URL.Path, TokensURL+"/"))
+				w.WriteHeader(http.StatusAccepted)
+				return
+			}
+			list.ServeHTTP(w, r)
+		}))
+
+		revoked, err := client.EnforceMaxTokenAge(24*time.Hour, dryRun)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, revoked, []uuid.UUID{uuid.MustParse(old.UUID)})
+		if dryRun {
+			assert.Equal(t, len(deleted), 0, "dry run must not remove tokens")
+		} else {
+			assert.DeepEqual(t, deleted, []string{old.UUID})
+		}
+	}
+}
+
+func TestEnforceMaxTokenAgeInvalid(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+	}))
+
+	for _, maxAge := range []time.Duration{0, -time.Hour} {
+		revoked, err := client.EnforceMaxTokenAge(maxAge, false)
+		assert.ErrorContains(t, err, "invalid maximum token age")
+		assert.Equal(t, len(revoked), 0)
+	}
+}
+
+func TestSnapshotAndRestoreTokenStates(t *testing.T) {
+	tokens := map[string]*hubTokenResult{
+		"6b2e8c4a-1f0e-4c8e-9a4f-000000000001": {UUID: "6b2e8c4a-1f0e-4c8e-9a4f-000000000001", IsActive: true, TokenLabel: "first"},
+		"6b2e8c4a-1f0e-4c8e-9a4f-000000000002": {UUID: "6b2e8c4a-1f0e-4c8e-9a4f-000000000002", IsActive: false, TokenLabel: "second"},
+	}
+	patches := 0
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPatch {
+			patches++
+			var request map[string]interface{}
+			assert.NilError(t, json.NewDecoder(r.Body).Decode(&request))
+			_, ok := request["token_label"]
+			assert.Assert(t, !ok, "the description must not be sent")
+			token := tokens[strings.TrimPrefix(r.URL.Path, TokensURL+"/")]
+			token.IsActive = request["is_active"].(bool)
+			assert.NilError(t, json.NewEncoder(w).Encode(token))
+			return
+		}
+		var results []hubTokenResult
+		for _, id := range []string{"6b2e8c4a-1f0e-4c8e-9a4f-000000000001", "6b2e8c4a-1f0e-4c8e-9a4f-000000000002"} {
+			results = append(results, *tokens[id])
+		}
+		assert.NilError(t, json.NewEncoder(w).Encode(hubTokenResponse{Count: len(results), Results: results}))
+	}))
+
+	snapshot, err := client.SnapshotTokenStates()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, snapshot, map[uuid.UUID]bool{
+		uuid.MustParse("6b2e8c4a-1f0e-4c8e-9a4f-000000000001"): true,
+		uuid.MustParse("6b2e8c4a-1f0e-4c8e-9a4f-000000000002"): false,
+	})
+
+	// Maintenance: everything is deactivated
+	for _, token := range tokens {
+		token.IsActive = false
+	}
+
+	assert.NilError(t, client.RestoreTokenStates(snapshot))
+	assert.Equal(t, patches, 1)
+	assert.Assert(t, tokens["6b2e8c4a-1f0e-4c8e-9a4f-000000000001"].IsActive)
+	assert.Assert(t, !tokens["6b2e8c4a-1f0e-4c8e-9a4f-000000000002"].IsActive)
+	assert.Equal(t, tokens["6b2e8c4a-1f0e-4c8e-9a4f-000000000001"].TokenLabel, "first")
+
+	snapshot[uuid.MustParse("6b2e8c4a-1f0e-4c8e-9a4f-000000000005")] = true
+	snapshot[uuid.MustParse("6b2e8c4a-1f0e-4c8e-9a4f-000000000004")] = true
+	snapshot[uuid.MustParse("6b2e8c4a-1f0e-4c8e-9a4f-000000000003")] = true
+	err = client.RestoreTokenStates(snapshot)
+	assert.Error(t, err, "tokens not found, their state could not be restored: 6b2e8c4a-1f0e-4c8e-9a4f-000000000003, 6b2e8c4a-1f0e-4c8e-9a4f-000000000004, 6b2e8c4a-1f0e-4c8e-9a4f-000000000005")
+}
@@ -0,0 +1,96 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"fmt"
+	"sort"
+)
+
+// TokenSortField is a field tokens can be sorted by
+type TokenSortField string
+
+const (
+	// SortByCreatedAt sorts the tokens by creation date
+	SortByCreatedAt = TokenSortField("created_at")
+	// SortByLastUsed sorts the tokens by last use date. Never used tokens
+	// come first in ascending order, last in descending order.
+	SortByLastUsed = TokenSortField("last_used")
+	// SortByDescription sorts the tokens by description
+	SortByDescription = TokenSortField("description")
+)
+
+// GetTokensSorted fetches all the tokens and sorts them by the given field,
+// in descending order if desc is true. Tokens with the same value are sorted
+// by creation date, then by UUID, so the order is always the same. The Hub
+// API doesn't sort the tokens, so they are sorted once all fetched.
+func (c *Client) GetTokensSorted(by TokenSortField, desc bool) ([]Token, error) {
+	less, err := tokenLess(by)
+	if err != nil {
+		return nil, err
+	}
+	tokens, _, err := c.getTokens(true)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(tokens, func(i, j int) bool {
+		if desc {
+			return less(tokens[j], tokens[i])
+		}
+		return less(tokens[i], tokens[j])
+	})
+	return tokens, nil
+}
+
+func tokenLess(by TokenSortField) (func(a, b Token) bool, error) {
+	var compare func(a, b Token) int
+	switch by {
+	case SortByCreatedAt:
+		compare = func(a, b Token) int { return 0 }
+	case SortByLastUsed:
+		compare = func(a, b Token) int {
+			switch {
+			case a.LastUsed.Before(b.LastUsed):
+				return -1
+			case a.LastUsed.After(b.LastUsed):
+				return 1
+			}
+			return 0
+		}
+	case SortByDescription:
+		compare = func(a, b Token) int {
+			switch {
+			case a.Description < b.Description:
+				return -1
+			case a.Description > b.Description:
+				return 1
+			}
+			return 0
+		}
+	default:
+		return nil, fmt.Errorf("unknown token sort field %q", by)
+	}
+	return func(a, b Token) bool {
+		if c := compare(a, b); c != 0 {
+			return c < 0
+		}
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return a.UUID.String() < b.UUID.String()
+	}, nil
+}
@@ -0,0 +1,60 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestGetTokensSorted(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2020, 10, d, 0, 0, 0, 0, time.UTC) }
+	client := newTestClient(t, serveTokens(t,
+		hubTokenResult{UUID: "6b2e8c4a-1f0e-4c8e-9a4f-000000000001", TokenLabel: "b", CreatedAt: day(3), LastUsed: day(10)},
+		hubTokenResult{UUID: "6b2e8c4a-1f0e-4c8e-9a4f-000000000002", TokenLabel: "c", CreatedAt: day(1)},
+		hubTokenResult{UUID: "6b2e8c4a-1f0e-4c8e-9a4f-000000000003", TokenLabel: "a", CreatedAt: day(2), LastUsed: day(5)},
+		hubTokenResult{UUID: "6b2e8c4a-1f0e-4c8e-9a4f-000000000004", TokenLabel: "a", CreatedAt: day(4)},
+	))
+
+	testCases := []struct {
+		by       TokenSortField
+		desc     bool
+		expected []string
+	}{
+		{SortByCreatedAt, false, []string{"2", "3", "1", "4"}},
+		{SortByCreatedAt, true, []string{"4", "1", "3", "2"}},
+		// Never used tokens are grouped, sorted by creation date
+		{SortByLastUsed, false, []string{"2", "4", "3", "1"}},
+		{SortByLastUsed, true, []string{"1", "3", "4", "2"}},
+		{SortByDescription, false, []string{"3", "4", "1", "2"}},
+	}
+	for _, tc := range testCases {
+		tokens, err := client.GetTokensSorted(tc.by, tc.desc)
+		assert.NilError(t, err)
+		var ids []string
+		for _, token := range tokens {
+			id := token.UUID.String()
+			ids = append(ids, id[len(id)-1:])
+		}
+		assert.DeepEqual(t, ids, tc.expected)
+	}
+
+	_, err := client.GetTokensSorted("expires_at", false)
+	assert.Error(t, err, `unknown token sort field "expires_at"`)
+}
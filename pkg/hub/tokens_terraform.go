@@ -0,0 +1,57 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	// TerraformTokenResource is the Terraform resource type tokens are imported as
+	TerraformTokenResource = "dockerhub_token"
+)
+
+// FormatTokensTerraform writes a Terraform import block and a matching resource
+// stub for each token, so existing tokens can be brought under Terraform
+// management. The resources target the "dockerhub_token" resource of the
+// BarnabyShearer/dockerhub provider, which is imported by token UUID and
+// describes a token with its "label" attribute. Each resource is addressed
+// as "token_<uuid>". The token secrets are never written.
+func FormatTokensTerraform(w io.Writer, tokens []Token) error {
+	for _, token := range tokens {
+		address := terraformTokenAddress(token)
+		if _, err := fmt.Fprintf(w, `import {
+  to = %s.%s
+  id = %q
+}
+
+resource %q %q {
+  label = %q
+}
+
+`, TerraformTokenResource, address, token.UUID, TerraformTokenResource, address, token.Description); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func terraformTokenAddress(token Token) string {
+	return "token_" + strings.ReplaceAll(token.UUID.String(), "-", "_")
+}
@@ -0,0 +1,609 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/golden"
+)
+
+func newTestClient(t *testing.T, handler http.Handler) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	client, err := NewClient(WithHubToken("token"))
+	assert.NilError(t, err)
+	client.domain = server.URL
+	return client
+}
+
+func serveTokens(t *testing.T, results ...hubTokenResult) http.Handler {
+	t.Helper()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, r.URL.Path, TokensURL)
+		assert.NilError(t, json.NewEncoder(w).Encode(hubTokenResponse{
+			Count:   len(results),
+			Results: results,
+		}))
+	})
+}
+
+// testTokenUUID returns the UUID of the nth token of the test fixtures
+func testTokenUUID(n int) string {
+	return fmt.Sprintf("6b2e8c4a-1f0e-4c8e-9a4f-%012d", n)
+}
+
+// withTestTokenUUIDs gives the tokens without UUID the one of their position,
+// starting at 1
+func withTestTokenUUIDs(results []hubTokenResult) []hubTokenResult {
+	for i := range results {
+		if results[i].UUID == "" {
+			results[i].UUID = testTokenUUID(i + 1)
+		}
+	}
+	return results
+}
+
+// newTokensTestClient returns a client listing the given tokens, see
+// withTestTokenUUIDs for their UUIDs
+func newTokensTestClient(t *testing.T, results ...hubTokenResult) *Client {
+	t.Helper()
+	return newTestClient(t, serveTokens(t, withTestTokenUUIDs(results)...))
+}
+
+func TestGetTokensCreatedBetween(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2021, time.January, d, 0, 0, 0, 0, time.UTC) }
+	client := newTestClient(t, serveTokens(t,
+		hubTokenResult{UUID: "6b2e8c4a-1f0e-4c8e-9a4f-000000000001", CreatedAt: day(1), TokenLabel: "first"},
+		hubTokenResult{UUID: "6b2e8c4a-1f0e-4c8e-9a4f-000000000002", CreatedAt: day(2), TokenLabel: "second"},
+		hubTokenResult{UUID: "6b2e8c4a-1f0e-4c8e-9a4f-000000000003", CreatedAt: day(3), TokenLabel: "third"},
+	))
+
+	testCases := []struct {
+		name     string
+		from     time.Time
+		to       time.Time
+		expected []string
+	}{
+		{name: "inclusive bounds", from: day(1), to: day(2), expected: []string{"first", "second"}},
+		{name: "single day", from: day(2), to: day(2), expected: []string{"second"}},
+		{name: "open start", to: day(1), expected: []string{"first"}},
+		{name: "open end", from: day(3), expected: []string{"third"}},
+		{name: "open range", expected: []string{"first", "second", "third"}},
+		{name: "empty range", from: day(4), to: day(5)},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			tokens, err := client.GetTokensCreatedBetween(testCase.from, testCase.to)
+			assert.NilError(t, err)
+			var descriptions []string
+			for _, token := range tokens {
+				descriptions = append(descriptions, token.Description)
+			}
+			assert.DeepEqual(t, descriptions, testCase.expected)
+		})
+	}
+}
+
+func TestGetTokensEachStopsEarly(t *testing.T) {
+	requests := 0
+	var client *Client
+	client = newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		page := r.URL.Query().Get("page")
+		assert.NilError(t, json.NewEncoder(w).Encode(hubTokenResponse{
+			Count: 2,
+			Next:  client.domain + TokensURL + "?page=2",
+			Results: []hubTokenResult{
+				{UUID: "6b2e8c4a-1f0e-4c8e-9a4f-00000000000" + page, TokenLabel: "page " + page},
+			},
+		}))
+	}))
+
+	stop := errors.New("stop")
+	var pages [][]Token
+	err := client.GetTokensEach(func(page []Token) error {
+		pages = append(pages, page)
+		return stop
+	})
+	assert.Equal(t, err, stop)
+	assert.Equal(t, requests, 1)
+	assert.Equal(t, len(pages), 1)
+	assert.Equal(t, pages[0][0].Description, "page 1")
+}
+
+func TestFormatTokensTerraform(t *testing.T) {
+	tokens := []Token{
+		{UUID: uuid.MustParse("6b2e8c4a-1f0e-4c8e-9a4f-000000000001"), Description: "ci", Token: "secret"},
+		{UUID: uuid.MustParse("6b2e8c4a-1f0e-4c8e-9a4f-000000000002"), Description: `my "laptop"`},
+	}
+	buf := bytes.NewBuffer(nil)
+	assert.NilError(t, FormatTokensTerraform(buf, tokens))
+	assert.Assert(t, !strings.Contains(buf.String(), "secret"))
+	golden.Assert(t, buf.String(), "tokens-terraform.golden")
+}
+
+func TestGetTokensDetectsIncompleteResults(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NilError(t, json.NewEncoder(w).Encode(hubTokenResponse{
+			Count:   3,
+			Results: []hubTokenResult{{UUID: "6b2e8c4a-1f0e-4c8e-9a4f-000000000001"}},
+		}))
+	}))
+
+	tokens, total, err := client.GetTokens()
+	assert.NilError(t, err, "a single page is expected to be partial")
+	assert.Equal(t, len(tokens), 1)
+	assert.Equal(t, total, 3)
+
+	assert.NilError(t, client.Update(WithAllElements()))
+	tokens, total, err = client.GetTokens()
+	assert.Equal(t, err, ErrIncompleteResults)
+	assert.Equal(t, len(tokens), 1)
+	assert.Equal(t, total, 3)
+}
+
+func TestCreateTokenWithAllowedIPs(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, r.Method, http.MethodPost)
+		var request hubTokenRequest
+		assert.NilError(t, json.NewDecoder(r.Body).Decode(&request))
+		assert.NilError(t, json.NewEncoder(w).Encode(hubTokenResult{
+			UUID:       "6b2e8c4a-1f0e-4c8e-9a4f-000000000001",
+			TokenLabel: request.Description,
+			AllowedIPs: request.AllowedIPs,
+		}))
+	}))
+
+	token, err := client.CreateToken("ci", WithAllowedIPs("192.0.2.0/24", "2001:db8::/32"))
+	assert.NilError(t, err)
+	assert.DeepEqual(t, token.AllowedIPs, []string{"192.0.2.0/24", "2001:db8::/32"})
+
+	for _, invalid := range []string{"192.0.2.1", "192.0.2.0/33", "2001:db8::/129", "not-an-ip/8"} {
+		_, err := client.CreateToken("ci", WithAllowedIPs(invalid))
+		assert.ErrorContains(t, err, "invalid allowed IP range")
+	}
+}
+
+func TestCreateTokenWithExpiration(t *testing.T) {
+	var requests []map[string]interface{}
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request map[string]interface{}
+		assert.NilError(t, json.NewDecoder(r.Body).Decode(&request))
+		requests = append(requests, request)
+		result := hubTokenResult{UUID: "6b2e8c4a-1f0e-4c8e-9a4f-000000000001"}
+		if expiresAt, ok := request["expires_at"].(string); ok {
+			var err error
+			result.ExpiresAt, err = time.Parse(time.RFC3339, expiresAt)
+			assert.NilError(t, err)
+		}
+		assert.NilError(t, json.NewEncoder(w).Encode(result))
+	}))
+
+	expiresAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	token, err := client.CreateToken("ci", WithExpiration(expiresAt))
+	assert.NilError(t, err)
+	assert.Assert(t, token.ExpiresAt.Equal(expiresAt))
+	assert.Equal(t, requests[0]["expires_at"], expiresAt.Format(time.RFC3339))
+
+	token, err = client.CreateToken("ci")
+	assert.NilError(t, err)
+	assert.Assert(t, token.ExpiresAt.IsZero())
+	_, ok := requests[1]["expires_at"]
+	assert.Assert(t, !ok, "no expiration should be sent by default")
+
+	_, err = client.CreateToken("ci", WithExpiration(time.Now().Add(-time.Minute)))
+	assert.ErrorContains(t, err, "must be in the future")
+	assert.Equal(t, len(requests), 2, "an invalid expiration should not be sent")
+}
+
+func TestDescriptionValidator(t *testing.T) {
+	requests := 0
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		assert.NilError(t, json.NewEncoder(w).Encode(hubTokenResult{UUID: "6b2e8c4a-1f0e-4c8e-9a4f-000000000001"}))
+	}))
+	client.DescriptionValidator = DescriptionMatching(regexp.MustCompile(`^(ci|svc)-`))
+
+	_, err := client.CreateToken("laptop")
+	assert.Error(t, err, `token description "laptop" does not match "^(ci|svc)-"`)
+	_, err = client.UpdateToken("6b2e8c4a-1f0e-4c8e-9a4f-000000000001", "laptop", true)
+	assert.ErrorContains(t, err, "does not match")
+	assert.Equal(t, requests, 0)
+
+	_, err = client.CreateToken("ci-build")
+	assert.NilError(t, err)
+	_, err = client.UpdateToken("6b2e8c4a-1f0e-4c8e-9a4f-000000000001", "", false)
+	assert.NilError(t, err, "keeping the description should not validate it")
+	assert.Equal(t, requests, 2)
+}
+
+func TestCreateTokenRaw(t *testing.T) {
+	raw := `{"uuid": "6b2e8c4a-1f0e-4c8e-9a4f-000000000001", "client_id": "hub-tool", "token": "secret", "server_only": 42}`
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(raw))
+	}))
+
+	token, response, err := client.CreateTokenRaw("ci")
+	assert.NilError(t, err)
+	assert.Equal(t, token.ClientID, "hub-tool")
+	assert.Equal(t, string(response), raw)
+}
+
+func TestTokenUUIDValidation(t *testing.T) {
+	requests := 0
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		assert.Equal(t, r.URL.Path, fmt.Sprintf(TokenURL, "6b2e8c4a-1f0e-4c8e-9a4f-000000000001"))
+		assert.NilError(t, json.NewEncoder(w).Encode(hubTokenResult{UUID: "6b2e8c4a-1f0e-4c8e-9a4f-000000000001"}))
+	}))
+
+	testCases := []struct {
+		name          string
+		uuid          string
+		expectedError string
+	}{
+		{name: "empty", uuid: "", expectedError: `invalid token UUID ""`},
+		{name: "malformed", uuid: "6b2e8c4a-1f0e", expectedError: `invalid token UUID "6b2e8c4a-1f0e"`},
+		{name: "typo", uuid: "6b2e8c4a-1f0e-4c8e-9a4f-00000000000z", expectedError: `invalid token UUID "6b2e8c4a-1f0e-4c8e-9a4f-00000000000z"`},
+		{name: "path injection", uuid: "../users", expectedError: `invalid token UUID "../users"`},
+		{name: "valid", uuid: "6b2e8c4a-1f0e-4c8e-9a4f-000000000001"},
+		{name: "valid upper case", uuid: "6B2E8C4A-1F0E-4C8E-9A4F-000000000001"},
+		{name: "valid with braces", uuid: "{6b2e8c4a-1f0e-4c8e-9a4f-000000000001}"},
+		{name: "valid urn", uuid: "urn:uuid:6b2e8c4a-1f0e-4c8e-9a4f-000000000001"},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			requests = 0
+			_, getErr := client.GetToken(testCase.uuid)
+			_, updateErr := client.UpdateToken(testCase.uuid, "", true)
+			removeErr := client.RemoveToken(testCase.uuid)
+			if testCase.expectedError == "" {
+				assert.NilError(t, getErr)
+				assert.NilError(t, updateErr)
+				assert.NilError(t, removeErr)
+				assert.Equal(t, requests, 3)
+				return
+			}
+			assert.Error(t, getErr, testCase.expectedError)
+			assert.Error(t, updateErr, testCase.expectedError)
+			assert.Error(t, removeErr, testCase.expectedError)
+			assert.Equal(t, requests, 0)
+		})
+	}
+}
+
+func TestDeprecationWarnings(t *testing.T) {
+	list := serveTokens(t)
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Sunset", "Sat, 01 Jan 2022 00:00:00 GMT")
+		list.ServeHTTP(w, r)
+	}))
+	assert.Equal(t, len(client.Warnings()), 0)
+
+	for i := 0; i < 2; i++ {
+		_, _, err := client.GetTokens()
+		assert.NilError(t, err)
+	}
+	assert.DeepEqual(t, client.Warnings(), []DeprecationWarning{{
+		Method:      http.MethodGet,
+		Path:        TokensURL,
+		Deprecation: "true",
+		Sunset:      "Sat, 01 Jan 2022 00:00:00 GMT",
+	}})
+}
+
+func TestDeprecationWarningsPerRoute(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", "true")
+		w.WriteHeader(http.StatusNotFound)
+	}))
+
+	// The same endpoint is reported once whatever the token
+	for i := 1; i <= 3; i++ {
+		_, _ = client.GetToken(testTokenUUID(i))
+	}
+	assert.DeepEqual(t, client.Warnings(), []DeprecationWarning{{
+		Method:      http.MethodGet,
+		Path:        TokenURL,
+		Deprecation: "true",
+	}})
+
+	// The unknown endpoints are recorded by path, up to a limit
+	for i := 0; i < 2*maxDeprecationWarnings; i++ {
+		req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/v2/unknown/%d", client.domain, i), nil)
+		assert.NilError(t, err)
+		_, _ = client.doRequest(req)
+	}
+	assert.Equal(t, len(client.Warnings()), maxDeprecationWarnings)
+}
+
+func TestGetTokensWithContextStopsPagination(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	calls := 0
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		// Cancel while the first page is being served
+		cancel()
+		assert.NilError(t, json.NewEncoder(w).Encode(hubTokenResponse{
+			Count:   2,
+			Next:    server.URL + TokensURL + "?page=2",
+			Results: []hubTokenResult{{UUID: "6b2e8c4a-1f0e-4c8e-9a4f-000000000001"}},
+		}))
+	}))
+	t.Cleanup(server.Close)
+	client, err := NewClient(WithHubToken("token"), WithAllElements())
+	assert.NilError(t, err)
+	client.domain = server.URL
+
+	_, _, err = client.GetTokensWithContext(ctx)
+	assert.Assert(t, errors.Is(err, context.Canceled))
+	assert.Equal(t, calls, 1)
+}
+
+func TestTokenMethodsWithCanceledContext(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+	}))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	id := "6b2e8c4a-1f0e-4c8e-9a4f-000000000001"
+
+	_, err := client.CreateTokenWithContext(ctx, "ci")
+	assert.Assert(t, errors.Is(err, context.Canceled))
+	_, err = client.GetTokenWithContext(ctx, id)
+	assert.Assert(t, errors.Is(err, context.Canceled))
+	_, err = client.UpdateTokenWithContext(ctx, id, "ci", true)
+	assert.Assert(t, errors.Is(err, context.Canceled))
+	assert.Assert(t, errors.Is(client.RemoveTokenWithContext(ctx, id), context.Canceled))
+}
+
+func serveTokenPages(t *testing.T, server **httptest.Server, total, pageSize int, inFlight, maxInFlight *int32) http.Handler {
+	t.Helper()
+	var lock sync.Mutex
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		*inFlight++
+		if *inFlight > *maxInFlight {
+			*maxInFlight = *inFlight
+		}
+		lock.Unlock()
+		defer func() {
+			lock.Lock()
+			*inFlight--
+			lock.Unlock()
+		}()
+		// Let concurrent requests overlap
+		time.Sleep(10 * time.Millisecond)
+
+		page, err := strconv.Atoi(r.URL.Query().Get("page"))
+		assert.NilError(t, err)
+		response := hubTokenResponse{Count: total}
+		for i := (page - 1) * pageSize; i < page*pageSize && i < total; i++ {
+			response.Results = append(response.Results, hubTokenResult{UUID: testTokenUUID(i)})
+		}
+		if page*pageSize < total {
+			response.Next = fmt.Sprintf("%s%s?page=%d&page_size=%d", (*server).URL, TokensURL, page+1, pageSize)
+		}
+		assert.NilError(t, json.NewEncoder(w).Encode(response))
+	})
+}
+
+func TestGetTokensConcurrentPages(t *testing.T) {
+	var (
+		server                *httptest.Server
+		inFlight, maxInFlight int32
+	)
+	server = httptest.NewServer(serveTokenPages(t, &server, 11, 2, &inFlight, &maxInFlight))
+	t.Cleanup(server.Close)
+	client, err := NewClient(WithHubToken("token"), WithAllElements(), WithConcurrentPages(3))
+	assert.NilError(t, err)
+	client.domain = server.URL
+
+	tokens, total, err := client.GetTokens()
+	assert.NilError(t, err)
+	assert.Equal(t, total, 11)
+	assert.Equal(t, len(tokens), 11)
+	for i, token := range tokens {
+		assert.Equal(t, token.UUID.String(), fmt.Sprintf("6b2e8c4a-1f0e-4c8e-9a4f-%012d", i), "tokens should be in page order")
+	}
+	assert.Assert(t, maxInFlight > 1, "pages should be fetched concurrently")
+	assert.Assert(t, maxInFlight <= 3, "no more than 3 pages should be fetched at once")
+
+	_, err = NewClient(WithConcurrentPages(-1))
+	assert.Error(t, err, "invalid concurrent pages limit -1: must be positive")
+	client, err = NewClient(WithConcurrentPages(0))
+	assert.NilError(t, err)
+	assert.Equal(t, client.concurrentPages, defaultConcurrentPages)
+}
+
+func TestGetTokensSequentialPages(t *testing.T) {
+	var (
+		server                *httptest.Server
+		inFlight, maxInFlight int32
+	)
+	server = httptest.NewServer(serveTokenPages(t, &server, 5, 2, &inFlight, &maxInFlight))
+	t.Cleanup(server.Close)
+	client, err := NewClient(WithHubToken("token"), WithAllElements())
+	assert.NilError(t, err)
+	client.domain = server.URL
+
+	tokens, _, err := client.GetTokens()
+	assert.NilError(t, err)
+	assert.Equal(t, len(tokens), 5)
+	assert.Equal(t, maxInFlight, int32(1))
+}
+
+func TestTokensURLWithFilter(t *testing.T) {
+	client, err := NewClient()
+	assert.NilError(t, err)
+	testCases := []struct {
+		filter   TokenFilter
+		expected string
+	}{
+		{TokenFilter{}, "https://hub.docker.com/v2/api_tokens?page=1&page_size=100"},
+		{TokenFilter{ActiveOnly: true}, "https://hub.docker.com/v2/api_tokens?is_active=true&page=1&page_size=100"},
+		{TokenFilter{InactiveOnly: true, DescriptionContains: "ci"}, "https://hub.docker.com/v2/api_tokens?is_active=false&page=1&page_size=100"},
+	}
+	for _, tc := range testCases {
+		u, err := client.tokensURL(tokenFilterQuery(tc.filter))
+		assert.NilError(t, err)
+		assert.Equal(t, u, tc.expected)
+	}
+}
+
+func TestWithPageSize(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, r.URL.Query().Get("page_size"), "10")
+		assert.NilError(t, json.NewEncoder(w).Encode(hubTokenResponse{
+			Count:   1,
+			Results: []hubTokenResult{{UUID: "6b2e8c4a-1f0e-4c8e-9a4f-000000000001"}},
+		}))
+	}))
+	assert.NilError(t, client.Update(WithPageSize(10)))
+
+	_, _, err := client.GetTokens()
+	assert.NilError(t, err)
+	u, err := client.repositoriesURL("org")
+	assert.NilError(t, err)
+	assert.Assert(t, strings.Contains(u, "page_size=10"), u)
+
+	for _, size := range []int{0, -1, 101} {
+		_, err := NewClient(WithPageSize(size))
+		assert.ErrorContains(t, err, fmt.Sprintf("invalid page size %d: must be between 1 and 100", size))
+	}
+}
+
+func TestListTokensFiltered(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, r.URL.Query().Get("is_active"), "false")
+		assert.NilError(t, json.NewEncoder(w).Encode(hubTokenResponse{
+			Count: 3,
+			Results: []hubTokenResult{
+				{UUID: "6b2e8c4a-1f0e-4c8e-9a4f-000000000001", TokenLabel: "ci-old"},
+				{UUID: "6b2e8c4a-1f0e-4c8e-9a4f-000000000002", TokenLabel: "laptop"},
+				// The Hub API may ignore the filter
+				{UUID: "6b2e8c4a-1f0e-4c8e-9a4f-000000000003", TokenLabel: "ci-new", IsActive: true},
+			},
+		}))
+	}))
+
+	tokens, err := client.ListTokensFiltered(TokenFilter{InactiveOnly: true, DescriptionContains: "ci"})
+	assert.NilError(t, err)
+	assert.Equal(t, len(tokens), 1)
+	assert.Equal(t, tokens[0].Description, "ci-old")
+
+	_, err = client.ListTokensFiltered(TokenFilter{ActiveOnly: true, InactiveOnly: true})
+	assert.ErrorContains(t, err, "mutually exclusive")
+}
+
+func TestGetTokensPage(t *testing.T) {
+	var (
+		server                *httptest.Server
+		inFlight, maxInFlight int32
+	)
+	server = httptest.NewServer(serveTokenPages(t, &server, 5, 2, &inFlight, &maxInFlight))
+	t.Cleanup(server.Close)
+	client, err := NewClient(WithHubToken("token"))
+	assert.NilError(t, err)
+	client.domain = server.URL
+
+	page, err := client.GetTokensPage(2, 2)
+	assert.NilError(t, err)
+	assert.Equal(t, len(page.Tokens), 2)
+	assert.Equal(t, page.Tokens[0].UUID.String(), "6b2e8c4a-1f0e-4c8e-9a4f-000000000002")
+	assert.Equal(t, page.Total, 5)
+	assert.Equal(t, page.NextPage, 3)
+	assert.Assert(t, page.HasMore)
+
+	page, err = client.GetTokensPage(3, 2)
+	assert.NilError(t, err)
+	assert.Equal(t, len(page.Tokens), 1)
+	assert.Equal(t, page.NextPage, 0)
+	assert.Assert(t, !page.HasMore)
+
+	_, err = client.GetTokensPage(0, 2)
+	assert.Error(t, err, "invalid page 0: pages are numbered from 1")
+	_, err = client.GetTokensPage(1, 101)
+	assert.Error(t, err, "invalid page size 101: must be between 1 and 100")
+}
+
+func TestSetTokenActiveAndUpdateTokenDescription(t *testing.T) {
+	var bodies []map[string]interface{}
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, r.Method, "PATCH")
+		var body map[string]interface{}
+		assert.NilError(t, json.NewDecoder(r.Body).Decode(&body))
+		bodies = append(bodies, body)
+		assert.NilError(t, json.NewEncoder(w).Encode(hubTokenResult{UUID: "6b2e8c4a-1f0e-4c8e-9a4f-000000000001"}))
+	}))
+	id := "6b2e8c4a-1f0e-4c8e-9a4f-000000000001"
+
+	_, err := client.SetTokenActive(id, false)
+	assert.NilError(t, err)
+	_, err = client.UpdateTokenDescription(id, "renamed")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, bodies, []map[string]interface{}{
+		{"is_active": false},
+		{"token_label": "renamed"},
+	})
+
+	_, err = client.UpdateTokenDescription(id, "")
+	assert.Error(t, err, "invalid token description: empty")
+	_, err = client.SetTokenActive("nope", true)
+	assert.ErrorContains(t, err, "nope")
+}
+
+func TestRevokeTokenByDescription(t *testing.T) {
+	existing := []hubTokenResult{
+		{UUID: "6b2e8c4a-1f0e-4c8e-9a4f-000000000001", TokenLabel: "ci"},
+		{UUID: "6b2e8c4a-1f0e-4c8e-9a4f-000000000002", TokenLabel: "laptop"},
+		{UUID: "6b2e8c4a-1f0e-4c8e-9a4f-000000000003", TokenLabel: "laptop"},
+	}
+	var created, removed []string
+	client := newTestClient(t, serveTokenFleet(t, existing, &created, &removed))
+
+	assert.NilError(t, client.RevokeTokenByDescription("ci"))
+	assert.DeepEqual(t, removed, []string{"6b2e8c4a-1f0e-4c8e-9a4f-000000000001"})
+
+	removed = nil
+	err := client.RevokeTokenByDescription("laptop")
+	assert.Error(t, err, `2 tokens have the description "laptop", revoke one of them by UUID: 6b2e8c4a-1f0e-4c8e-9a4f-000000000002, 6b2e8c4a-1f0e-4c8e-9a4f-000000000003`)
+	assert.Equal(t, len(removed), 0)
+
+	err = client.RevokeTokenByDescription("Laptop")
+	assert.Error(t, err, `no token with description "Laptop"`)
+	assert.Equal(t, len(removed), 0)
+}
@@ -0,0 +1,91 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"sort"
+	"time"
+)
+
+// UsageEntry is a group of tokens created from the same user agent and IP
+// address. The tokens never used are grouped apart from the used ones.
+type UsageEntry struct {
+	CreatorUA string
+	CreatorIP string
+	// NeverUsed is set on the groups of the tokens which were never used
+	NeverUsed bool
+	// Count is the number of tokens in the group
+	Count int
+	// LastUsed is the most recent use of a token of the group, zero when
+	// NeverUsed is set
+	LastUsed time.Time
+}
+
+// TokenUsageReport lists all the tokens and groups them by the user agent
+// and the IP address they were created from
+func (c *Client) TokenUsageReport() ([]UsageEntry, error) {
+	tokens, _, err := c.getTokens(true)
+	if err != nil {
+		return nil, err
+	}
+	return GroupTokenUsage(tokens), nil
+}
+
+// GroupTokenUsage groups the tokens by the user agent and the IP address
+// they were created from, the tokens never used in their own groups. The
+// most recently used groups come first, the never used ones last.
+func GroupTokenUsage(tokens []Token) []UsageEntry {
+	type usageKey struct {
+		ua, ip    string
+		neverUsed bool
+	}
+	groups := map[usageKey]*UsageEntry{}
+	for _, token := range tokens {
+		key := usageKey{ua: token.CreatorUA, ip: token.CreatorIP, neverUsed: token.LastUsed.IsZero()}
+		entry, ok := groups[key]
+		if !ok {
+			entry = &UsageEntry{CreatorUA: key.ua, CreatorIP: key.ip, NeverUsed: key.neverUsed}
+			groups[key] = entry
+		}
+		entry.Count++
+		if token.LastUsed.After(entry.LastUsed) {
+			entry.LastUsed = token.LastUsed
+		}
+	}
+
+	entries := make([]UsageEntry, 0, len(groups))
+	for _, entry := range groups {
+		entries = append(entries, *entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.NeverUsed != b.NeverUsed {
+			return !a.NeverUsed
+		}
+		if !a.LastUsed.Equal(b.LastUsed) {
+			return a.LastUsed.After(b.LastUsed)
+		}
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		if a.CreatorUA != b.CreatorUA {
+			return a.CreatorUA < b.CreatorUA
+		}
+		return a.CreatorIP < b.CreatorIP
+	})
+	return entries
+}
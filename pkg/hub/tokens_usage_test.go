@@ -0,0 +1,59 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestGroupTokenUsage(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2021, time.January, d, 0, 0, 0, 0, time.UTC) }
+	tokens := []Token{
+		{CreatorUA: "hub-tool/v0.3.0", CreatorIP: "10.0.0.1", LastUsed: day(2)},
+		{CreatorUA: "hub-tool/v0.3.0", CreatorIP: "10.0.0.1", LastUsed: day(5)},
+		{CreatorUA: "hub-tool/v0.3.0", CreatorIP: "10.0.0.1"},
+		{CreatorUA: "hub-tool/v0.3.0", CreatorIP: "10.0.0.2", LastUsed: day(3)},
+		{CreatorUA: "Mozilla/5.0", CreatorIP: "10.0.0.1", LastUsed: day(1)},
+		{CreatorUA: "Mozilla/5.0", CreatorIP: "10.0.0.1"},
+		{CreatorUA: "Mozilla/5.0", CreatorIP: "10.0.0.1"},
+	}
+
+	assert.DeepEqual(t, GroupTokenUsage(tokens), []UsageEntry{
+		{CreatorUA: "hub-tool/v0.3.0", CreatorIP: "10.0.0.1", Count: 2, LastUsed: day(5)},
+		{CreatorUA: "hub-tool/v0.3.0", CreatorIP: "10.0.0.2", Count: 1, LastUsed: day(3)},
+		{CreatorUA: "Mozilla/5.0", CreatorIP: "10.0.0.1", Count: 1, LastUsed: day(1)},
+		{CreatorUA: "Mozilla/5.0", CreatorIP: "10.0.0.1", NeverUsed: true, Count: 2},
+		{CreatorUA: "hub-tool/v0.3.0", CreatorIP: "10.0.0.1", NeverUsed: true, Count: 1},
+	})
+	assert.Equal(t, len(GroupTokenUsage(nil)), 0)
+}
+
+func TestTokenUsageReport(t *testing.T) {
+	client := newTokensTestClient(t,
+		hubTokenResult{CreatorUA: "ci", CreatorIP: "10.0.0.1", LastUsed: time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		hubTokenResult{CreatorUA: "ci", CreatorIP: "10.0.0.1"},
+	)
+
+	entries, err := client.TokenUsageReport()
+	assert.NilError(t, err)
+	assert.Equal(t, len(entries), 2)
+	assert.Equal(t, entries[0].NeverUsed, false)
+	assert.Equal(t, entries[1].NeverUsed, true)
+}
@@ -0,0 +1,83 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// WatchNewTokens polls the tokens every interval and sends on the returned
+// channel the tokens created since the watch started, without their secret.
+// The tokens existing when the watch starts are not sent. Polling errors are
+// sent on the error channel and polling goes on. Both channels are closed once
+// the context is canceled.
+func (c *Client) WatchNewTokens(ctx context.Context, interval time.Duration) (<-chan Token, <-chan error) {
+	tokens := make(chan Token)
+	errs := make(chan error, 1)
+	go func() {
+		defer close(errs)
+		defer close(tokens)
+
+		if interval <= 0 {
+			errs <- fmt.Errorf("invalid watch interval %s: must be positive", interval)
+			return
+		}
+		var known map[string]bool
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			current, _, err := c.getTokensWithContext(ctx, true)
+			if err != nil && ctx.Err() == nil {
+				select {
+				case errs <- err:
+				case <-ctx.Done():
+					return
+				}
+			}
+			if err == nil {
+				// The first successful poll only records the existing tokens
+				first := known == nil
+				if first {
+					known = map[string]bool{}
+				}
+				for _, token := range current {
+					if known[token.UUID.String()] {
+						continue
+					}
+					known[token.UUID.String()] = true
+					if first {
+						continue
+					}
+					token.Token = ""
+					select {
+					case tokens <- token:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return tokens, errs
+}
@@ -0,0 +1,75 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestWatchNewTokens(t *testing.T) {
+	all := []hubTokenResult{
+		{UUID: "6b2e8c4a-1f0e-4c8e-9a4f-000000000001", TokenLabel: "existing"},
+		{UUID: "6b2e8c4a-1f0e-4c8e-9a4f-000000000002", TokenLabel: "second", Token: "secret"},
+		{UUID: "6b2e8c4a-1f0e-4c8e-9a4f-000000000003", TokenLabel: "third"},
+	}
+	var lock sync.Mutex
+	polls := 0
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		polls++
+		if polls == 2 {
+			lock.Unlock()
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		// A new token appears on every poll
+		results := all[:minInt(polls, len(all))]
+		lock.Unlock()
+		assert.NilError(t, json.NewEncoder(w).Encode(hubTokenResponse{Count: len(results), Results: results}))
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	tokens, errs := client.WatchNewTokens(ctx, 5*time.Millisecond)
+
+	assert.ErrorContains(t, <-errs, "500")
+	var labels []string
+	for token := range tokens {
+		assert.Equal(t, token.Token, "", "the secret should be redacted")
+		labels = append(labels, token.Description)
+		if len(labels) == 2 {
+			cancel()
+		}
+	}
+	assert.DeepEqual(t, labels, []string{"second", "third"})
+	_, ok := <-errs
+	assert.Assert(t, !ok, "the error channel should be closed")
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,119 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"regexp"
+)
+
+const redacted = "[REDACTED]"
+
+var (
+	// sensitiveHeaders are replaced as a whole in the traces
+	sensitiveHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Csrftoken"}
+	// sensitiveFields matches the JSON fields holding credentials in the Hub
+	// API requests and responses, e.g. the login payload or a new token
+	sensitiveFields = regexp.MustCompile(`("(?:password|token|refresh_token|access_token|login_2fa_token|code|secret)"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+)
+
+// WithTrace writes every HTTP exchange of the client, headers and bodies
+// included, to the given writer. Credentials are redacted from the trace so
+// it can be attached to a bug report. The trace wraps the transport when the
+// requests are sent, so it is kept whatever the order of the options
+// changing the HTTP client or its transport.
+func WithTrace(w io.Writer) ClientOp {
+	return func(c *Client) error {
+		c.trace = w
+		return nil
+	}
+}
+
+// httpClient returns the HTTP client sending the requests, tracing them if
+// WithTrace was used
+func (c *Client) httpClient() *http.Client {
+	if c.trace == nil {
+		return c.client
+	}
+	base := c.client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	client := *c.client
+	client.Transport = &traceTransport{base: base, client: c}
+	return &client
+}
+
+type traceTransport struct {
+	base   http.RoundTripper
+	client *Client
+}
+
+func (t *traceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// The request is dumped before being sent, as its body can only be read
+	// once. DumpRequestOut restores the body it reads.
+	redactedReq := req.Clone(req.Context())
+	redactHeaders(redactedReq.Header)
+	dumpedReq, err := httputil.DumpRequestOut(redactedReq, true)
+	if err != nil {
+		return nil, err
+	}
+	req.Body = redactedReq.Body
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		t.write(dumpedReq, []byte(fmt.Sprintf("error: %s\n", err)))
+		return nil, err
+	}
+	header := resp.Header
+	resp.Header = header.Clone()
+	redactHeaders(resp.Header)
+	dumpedResp, err := httputil.DumpResponse(resp, true)
+	resp.Header = header
+	if err != nil {
+		resp.Body.Close() //nolint:errcheck
+		return nil, err
+	}
+	t.write(dumpedReq, dumpedResp)
+	return resp, nil
+}
+
+func (t *traceTransport) write(exchange ...[]byte) {
+	dump := bytes.Join(exchange, []byte("\n"))
+	dump = sensitiveFields.ReplaceAll(dump, []byte(`$1"`+redacted+`"`))
+	for _, secret := range []string{t.client.token, t.client.refreshToken, t.client.password} {
+		if secret != "" {
+			dump = bytes.ReplaceAll(dump, []byte(secret), []byte(redacted))
+		}
+	}
+
+	t.client.traceLock.Lock()
+	defer t.client.traceLock.Unlock()
+	fmt.Fprintf(t.client.trace, "%s\n\n", dump)
+}
+
+func redactHeaders(header http.Header) {
+	for _, name := range sensitiveHeaders {
+		if header.Get(name) != "" {
+			header.Set(name, redacted)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestTraceRedactsSecrets(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v2/users/login", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "token", Value: "cookie-secret"})
+		fmt.Fprint(w, `{"token": "jwt-secret", "refresh_token": "refresh-secret"}`)
+	})
+	mux.HandleFunc(TokensURL, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"uuid": "6b2e8c4a-1f0e-4c8e-9a4f-000000000001", "token": "pat-secret", "token_label": "ci"}`)
+	})
+	client := newTestClient(t, mux)
+	client.token = "hub-secret"
+	client.password = "password-secret"
+	var trace bytes.Buffer
+	assert.NilError(t, client.Update(WithTrace(&trace)))
+
+	_, _, err := client.Login("user", "password-secret", nil)
+	assert.NilError(t, err)
+	token, err := client.CreateToken("ci")
+	assert.NilError(t, err)
+	assert.Equal(t, token.Token, "pat-secret", "the response body must be left untouched")
+
+	output := trace.String()
+	assert.Assert(t, strings.Contains(output, "POST /v2/users/login"))
+	assert.Assert(t, strings.Contains(output, `"token_label": "ci"`))
+	for _, secret := range []string{"hub-secret", "password-secret", "jwt-secret", "refresh-secret", "cookie-secret", "pat-secret"} {
+		assert.Assert(t, !strings.Contains(output, secret), "%q leaked in the trace:\n%s", secret, output)
+	}
+}
+
+func TestTraceIgnoresOptionsOrder(t *testing.T) {
+	testCases := []struct {
+		name string
+		ops  func(trace *bytes.Buffer) []ClientOp
+	}{
+		{name: "connection pool after trace", ops: func(trace *bytes.Buffer) []ClientOp {
+			return []ClientOp{WithTrace(trace), WithConnectionPool(10, 2, time.Minute)}
+		}},
+		{name: "transport after trace", ops: func(trace *bytes.Buffer) []ClientOp {
+			return []ClientOp{WithTrace(trace), WithTransport(&http.Transport{})}
+		}},
+		{name: "HTTP client after trace", ops: func(trace *bytes.Buffer) []ClientOp {
+			return []ClientOp{WithTrace(trace), WithHTTPClient(&http.Client{})}
+		}},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			client := newTestClient(t, serveTokens(t))
+			client.token = "hub-secret"
+			var trace bytes.Buffer
+			assert.NilError(t, client.Update(testCase.ops(&trace)...))
+
+			_, _, err := client.GetTokens()
+			assert.NilError(t, err)
+			assert.Assert(t, strings.Contains(trace.String(), "GET "+TokensURL), trace.String())
+		})
+	}
+}
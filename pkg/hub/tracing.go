@@ -0,0 +1,130 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Tracer starts the spans of the Hub API calls. It is a minimal subset of
+// an OpenTelemetry tracer, so hub doesn't depend on OpenTelemetry: wrap a
+// trace.Tracer to record the calls as children of the span carried by the
+// context given to the context-aware methods.
+type Tracer interface {
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is a span started by a Tracer
+type Span interface {
+	SetAttribute(key string, value interface{})
+	RecordError(err error)
+	End()
+}
+
+// WithTracer starts a span with the Tracer around every HTTP call of the
+// client. Without a tracer no span is started.
+func WithTracer(tracer Tracer) ClientOp {
+	return func(c *Client) error {
+		c.tracer = tracer
+		return nil
+	}
+}
+
+// routes are the API paths the spans are named after, so the names don't
+// carry the token UUIDs or repository names of the calls
+var routes = newRoutes(
+	LoginURL, TwoFactorLoginURL, DeviceCodeURL, DeviceTokenURL, UserURL,
+	TokensURL, TokenURL, AuditLogsURL, HubPlanURL,
+	OrganizationsURL, OrganizationInfoURL, MembersURL, MemberURL,
+	GroupsURL, GroupURL, MembersPerTeamURL, GroupMemberURL,
+	RepositoriesURL, RepositoryURL, TagsURL, DeleteTagURL, WebhooksURL, WebhookURL,
+	RegistryBaseURL, RegistryManifestURL, RegistryBlobURL, RegistryBlobMountURL,
+)
+
+type route struct {
+	template string
+	pattern  *regexp.Regexp
+}
+
+// newRoutes compiles the path templates, the longest first so the most
+// specific template matches. A parameter is a single path segment, or two
+// for the repositories.
+func newRoutes(templates ...string) []route {
+	compiled := make([]route, len(templates))
+	for i, template := range templates {
+		template = strings.SplitN(template, "?", 2)[0]
+		parts := strings.Split(template, "%s")
+		for j := range parts {
+			parts[j] = regexp.QuoteMeta(parts[j])
+		}
+		compiled[i] = route{
+			template: template,
+			pattern:  regexp.MustCompile("^" + strings.Join(parts, "[^/]+(?:/[^/]+)?") + "$"),
+		}
+	}
+	sort.SliceStable(compiled, func(i, j int) bool { return len(compiled[i].template) > len(compiled[j].template) })
+	return compiled
+}
+
+// routeTemplate returns the template of the API path of a request, without
+// the base path of the Hub domain, or an empty string if it is unknown
+func (c *Client) routeTemplate(req *http.Request) string {
+	path := req.URL.Path
+	if domain, err := url.Parse(c.domain); err == nil && req.URL.Host == domain.Host {
+		path = "/" + strings.TrimPrefix(strings.TrimPrefix(path, strings.TrimSuffix(domain.Path, "/")), "/")
+	}
+	for _, r := range routes {
+		if r.pattern.MatchString(path) {
+			return r.template
+		}
+	}
+	return ""
+}
+
+// startSpan starts the span of a call, named after the method and the route
+// template of the path, or only the method if the route is unknown. The
+// returned request carries the context of the span so the transport can
+// propagate it.
+func (c *Client) startSpan(req *http.Request) (*http.Request, Span) {
+	name := req.Method
+	template := c.routeTemplate(req)
+	if template != "" {
+		name += " " + template
+	}
+	ctx, span := c.tracer.Start(req.Context(), name)
+	span.SetAttribute("http.method", req.Method)
+	if template != "" {
+		span.SetAttribute("http.route", template)
+	}
+	span.SetAttribute("url.path", req.URL.Path)
+	return req.WithContext(ctx), span
+}
+
+func endSpan(span Span, resp *http.Response, err error) {
+	if resp != nil {
+		span.SetAttribute("http.status_code", resp.StatusCode)
+	}
+	if err != nil {
+		span.RecordError(err)
+	}
+	span.End()
+}
@@ -0,0 +1,140 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+type spanKey struct{}
+
+type testSpan struct {
+	name       string
+	parent     interface{}
+	attributes map[string]interface{}
+	errs       []error
+	ended      bool
+}
+
+func (s *testSpan) SetAttribute(key string, value interface{}) { s.attributes[key] = value }
+func (s *testSpan) RecordError(err error)                      { s.errs = append(s.errs, err) }
+func (s *testSpan) End()                                       { s.ended = true }
+
+type testTracer struct {
+	spans []*testSpan
+	lock  sync.Mutex
+}
+
+func (t *testTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	span := &testSpan{name: name, parent: ctx.Value(spanKey{}), attributes: map[string]interface{}{}}
+	t.spans = append(t.spans, span)
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+func TestWithTracer(t *testing.T) {
+	client := newTestClient(t, serveTokens(t, hubTokenResult{UUID: "6b2e8c4a-1f0e-4c8e-9a4f-000000000001"}))
+	tracer := &testTracer{}
+	assert.NilError(t, client.Update(WithTracer(tracer)))
+
+	ctx := context.WithValue(context.Background(), spanKey{}, "parent")
+	_, _, err := client.GetTokensWithContext(ctx)
+	assert.NilError(t, err)
+
+	assert.Equal(t, len(tracer.spans), 1)
+	span := tracer.spans[0]
+	assert.Equal(t, span.name, "GET "+TokensURL)
+	assert.Equal(t, span.parent, "parent")
+	assert.DeepEqual(t, span.attributes, map[string]interface{}{
+		"http.method":      http.MethodGet,
+		"http.route":       TokensURL,
+		"url.path":         TokensURL,
+		"http.status_code": http.StatusOK,
+	})
+	assert.Equal(t, len(span.errs), 0)
+	assert.Assert(t, span.ended)
+}
+
+func TestWithTracerRecordsErrors(t *testing.T) {
+	client := newTestClient(t, serveTokens(t))
+	tracer := &testTracer{}
+	assert.NilError(t, client.Update(WithTracer(tracer)))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _, err := client.GetTokensWithContext(ctx)
+	assert.Assert(t, errors.Is(err, context.Canceled))
+
+	assert.Equal(t, len(tracer.spans), 1)
+	span := tracer.spans[0]
+	assert.Equal(t, len(span.errs), 1)
+	assert.Assert(t, span.ended)
+	_, ok := span.attributes["http.status_code"]
+	assert.Assert(t, !ok)
+}
+
+func TestWithTracerNamesSpansAfterRoutes(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NilError(t, json.NewEncoder(w).Encode(hubTokenResult{UUID: "6b2e8c4a-1f0e-4c8e-9a4f-000000000001"}))
+	}))
+	tracer := &testTracer{}
+	assert.NilError(t, client.Update(WithTracer(tracer)))
+
+	_, err := client.GetToken("6b2e8c4a-1f0e-4c8e-9a4f-000000000001")
+	assert.NilError(t, err)
+
+	span := tracer.spans[0]
+	assert.Equal(t, span.name, "GET "+TokenURL)
+	assert.Equal(t, span.attributes["http.route"], TokenURL)
+	assert.Equal(t, span.attributes["url.path"], "/v2/api_tokens/6b2e8c4a-1f0e-4c8e-9a4f-000000000001")
+}
+
+func TestRouteTemplate(t *testing.T) {
+	client, err := NewClient(WithDomain("https://example.com/hub"))
+	assert.NilError(t, err)
+
+	testCases := []struct {
+		url      string
+		expected string
+	}{
+		{url: "https://example.com/hub/v2/api_tokens?page=1", expected: TokensURL},
+		{url: "https://example.com/hub/v2/api_tokens/6b2e8c4a-1f0e-4c8e-9a4f-000000000001", expected: TokenURL},
+		{url: "https://example.com/hub/v2/repositories/org/img/", expected: RepositoryURL},
+		{url: "https://example.com/hub/v2/repositories/org/img/tags/latest/", expected: DeleteTagURL},
+		{url: "https://example.com/hub/v2/repositories/org/img/webhook_pipeline/", expected: WebhooksURL},
+		{url: "https://example.com/hub/v2/orgs/org/groups/owners/members/", expected: MembersPerTeamURL},
+		{url: "https://example.com/hub/v2/orgs/org", expected: OrganizationInfoURL},
+		{url: "https://registry-1.docker.io/v2/org/img/manifests/latest", expected: RegistryManifestURL},
+		{url: "https://registry-1.docker.io/v2/org/img/blobs/uploads/?mount=sha256:123&from=org/other", expected: "/v2/%s/blobs/uploads/"},
+		{url: "https://example.com/hub/v2/unknown/org/img", expected: ""},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.url, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, testCase.url, nil)
+			assert.NilError(t, err)
+			assert.Equal(t, client.routeTemplate(req), testCase.expected)
+		})
+	}
+}
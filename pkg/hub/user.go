@@ -20,12 +20,27 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 )
 
 const (
 	//UserURL path to user informations
 	UserURL = "/v2/user/"
+
+	patPrefix = "dckr_pat_"
+)
+
+//TokenType is the kind of credentials a client is authenticated with
+type TokenType string
+
+const (
+	//TokenTypePAT is a personal access token
+	TokenTypePAT = TokenType("pat")
+	//TokenTypeSession is a session opened with the account password
+	TokenTypeSession = TokenType("session")
+	//TokenTypeUnknown is used when the kind of credentials can't be told
+	TokenTypeUnknown = TokenType("unknown")
 )
 
 //Account represents a user or organization information
@@ -36,6 +51,10 @@ type Account struct {
 	Location string
 	Company  string
 	Joined   time.Time
+	//Namespace is the namespace of the account repositories
+	Namespace string
+	//TokenType is only set by WhoAmI
+	TokenType TokenType
 }
 
 //GetUserInfo returns the information on the user retrieved from Hub
@@ -63,9 +82,62 @@ func (c *Client) GetUserInfo() (*Account, error) {
 		Location: hubResponse.Location,
 		Company:  hubResponse.Company,
 		Joined:   hubResponse.DateJoined,
+		// User namespaces are named after the username
+		Namespace: hubResponse.UserName,
 	}, nil
 }
 
+//WhoAmI returns the account the client is authenticated as, along with the
+//kind of credentials used. The result is cached for the lifetime of the
+//session.
+func (c *Client) WhoAmI() (*Account, error) {
+	c.whoAmILock.Lock()
+	defer c.whoAmILock.Unlock()
+	if c.whoAmI == nil {
+		account, err := c.GetUserInfo()
+		if err != nil {
+			return nil, err
+		}
+		account.TokenType = c.tokenType()
+		c.whoAmI = account
+	}
+	account := *c.whoAmI
+	return &account, nil
+}
+
+//tokenType guesses the kind of credentials from their format: personal
+//access tokens are either prefixed or plain UUIDs, while logging in with a
+//password gives a JWT
+func (c *Client) tokenType() TokenType {
+	credentials := c.password
+	if credentials == "" {
+		credentials = c.token
+	}
+	_, uuidErr := validateTokenUUID(credentials)
+	switch {
+	case strings.HasPrefix(credentials, patPrefix):
+		return TokenTypePAT
+	case uuidErr == nil:
+		return TokenTypePAT
+	case c.password != "" || strings.Count(credentials, ".") == 2:
+		return TokenTypeSession
+	}
+	return TokenTypeUnknown
+}
+
+//SameAccount checks whether both clients are authenticated as the same account
+func SameAccount(a, b *Client) (bool, error) {
+	first, err := a.WhoAmI()
+	if err != nil {
+		return false, err
+	}
+	second, err := b.WhoAmI()
+	if err != nil {
+		return false, err
+	}
+	return strings.EqualFold(first.Name, second.Name), nil
+}
+
 type hubUserResponse struct {
 	ID            string    `json:"id"`
 	UserName      string    `json:"username"`
@@ -0,0 +1,107 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func serveUser(t *testing.T, username string, calls *int) http.Handler {
+	t.Helper()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, r.URL.Path, UserURL)
+		*calls++
+		fmt.Fprintf(w, `{"id": "id-%s", "username": %q}`, username, username)
+	})
+}
+
+func TestWhoAmIIsCached(t *testing.T) {
+	calls := 0
+	client := newTestClient(t, serveUser(t, "alice", &calls))
+
+	for i := 0; i < 2; i++ {
+		account, err := client.WhoAmI()
+		assert.NilError(t, err)
+		assert.Equal(t, account.Name, "alice")
+	}
+	assert.Equal(t, calls, 1)
+
+	assert.NilError(t, client.Update(WithHubToken("other")))
+	_, err := client.WhoAmI()
+	assert.NilError(t, err)
+	assert.Equal(t, calls, 2, "changing the token should invalidate the cache")
+}
+
+func TestSameAccount(t *testing.T) {
+	var calls int
+	alice := newTestClient(t, serveUser(t, "alice", &calls))
+	otherAlice := newTestClient(t, serveUser(t, "Alice", &calls))
+	bob := newTestClient(t, serveUser(t, "bob", &calls))
+
+	same, err := SameAccount(alice, otherAlice)
+	assert.NilError(t, err)
+	assert.Assert(t, same)
+
+	same, err = SameAccount(alice, bob)
+	assert.NilError(t, err)
+	assert.Assert(t, !same)
+}
+
+func TestWhoAmI(t *testing.T) {
+	calls := 0
+	client := newTestClient(t, serveUser(t, "alice", &calls))
+
+	account, err := client.WhoAmI()
+	assert.NilError(t, err)
+	assert.Equal(t, account.ID, "id-alice")
+	assert.Equal(t, account.Name, "alice")
+	assert.Equal(t, account.Namespace, "alice")
+
+	account.Name = "mallory"
+	account, err = client.WhoAmI()
+	assert.NilError(t, err)
+	assert.Equal(t, account.Name, "alice", "the cached account should not be shared with callers")
+}
+
+func TestWhoAmITokenType(t *testing.T) {
+	testCases := []struct {
+		name      string
+		ops       []ClientOp
+		tokenType TokenType
+	}{
+		{name: "prefixed personal access token", ops: []ClientOp{WithHubToken("dckr_pat_secret")}, tokenType: TokenTypePAT},
+		{name: "legacy personal access token", ops: []ClientOp{WithHubToken("6b2e8c4a-1f0e-4c8e-9a4f-000000000001")}, tokenType: TokenTypePAT},
+		{name: "session", ops: []ClientOp{WithHubToken("header.payload.signature")}, tokenType: TokenTypeSession},
+		{name: "logged in with a password", ops: []ClientOp{WithHubToken("header.payload.signature"), WithPassword("hunter2")}, tokenType: TokenTypeSession},
+		{name: "logged in with a personal access token", ops: []ClientOp{WithHubToken("header.payload.signature"), WithPassword("dckr_pat_secret")}, tokenType: TokenTypePAT},
+		{name: "unknown", ops: []ClientOp{WithHubToken("opaque")}, tokenType: TokenTypeUnknown},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			calls := 0
+			client := newTestClient(t, serveUser(t, "alice", &calls))
+			assert.NilError(t, client.Update(testCase.ops...))
+			account, err := client.WhoAmI()
+			assert.NilError(t, err)
+			assert.Equal(t, account.TokenType, testCase.tokenType)
+		})
+	}
+}
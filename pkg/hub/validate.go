@@ -0,0 +1,102 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// opaqueTokenPattern matches the tokens which are not JWTs, like the
+// personal access tokens
+var opaqueTokenPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Validate checks the client configuration, so a misconfiguration is reported
+// when the client is created rather than on its first request
+func (c *Client) Validate() error {
+	if c.client == nil {
+		return errors.New("invalid client configuration: no HTTP client")
+	}
+	if err := validateBaseURL("Hub API", c.domain); err != nil {
+		return err
+	}
+	if err := validateBaseURL("registry", c.registry); err != nil {
+		return err
+	}
+	if c.token != "" {
+		if err := validateTokenFormat(c.token); err != nil {
+			return err
+		}
+	}
+	if c.pageSize != 0 {
+		if err := validatePageSize(c.pageSize); err != nil {
+			return err
+		}
+	}
+	if c.maxRetries > 0 && c.retryBackoff == 0 {
+		return fmt.Errorf("invalid client configuration: %d retries are configured without any backoff", c.maxRetries)
+	}
+	return nil
+}
+
+func validateBaseURL(name, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid %s URL %q: %s", name, rawURL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("invalid %s URL %q: the scheme must be http or https", name, rawURL)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("invalid %s URL %q: no host", name, rawURL)
+	}
+	return nil
+}
+
+// validateTokenFormat checks a token is either a JWT, as returned by the
+// login, or an opaque token like a personal access token
+func validateTokenFormat(token string) error {
+	parts := strings.Split(token, ".")
+	if len(parts) == 1 {
+		if !opaqueTokenPattern.MatchString(token) {
+			return errors.New("invalid token: unexpected characters")
+		}
+		return nil
+	}
+	if len(parts) != 3 {
+		return fmt.Errorf("invalid token: a JWT has 3 parts, found %d", len(parts))
+	}
+	for i, name := range []string{"header", "payload"} {
+		decoded, err := base64.RawURLEncoding.DecodeString(parts[i])
+		if err != nil {
+			return fmt.Errorf("invalid token: JWT %s is not base64 encoded: %s", name, err)
+		}
+		var claims map[string]interface{}
+		if err := json.Unmarshal(decoded, &claims); err != nil {
+			return fmt.Errorf("invalid token: JWT %s is not a JSON object", name)
+		}
+	}
+	if _, err := base64.RawURLEncoding.DecodeString(parts[2]); err != nil {
+		return fmt.Errorf("invalid token: JWT signature is not base64 encoded: %s", err)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestValidate(t *testing.T) {
+	jwt := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256"}`)) + "." +
+		base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"user"}`)) + ".c2lnbmF0dXJl"
+
+	testCases := []struct {
+		name     string
+		setup    func(c *Client)
+		expected string
+	}{
+		{name: "default", setup: func(c *Client) {}},
+		{name: "jwt", setup: func(c *Client) { c.token = jwt }},
+		{name: "personal access token", setup: func(c *Client) { c.token = "6b2e8c4a-1f0e-4c8e-9a4f-000000000001" }},
+		{
+			name:     "no http client",
+			setup:    func(c *Client) { c.client = nil },
+			expected: "no HTTP client",
+		},
+		{
+			name:     "domain without scheme",
+			setup:    func(c *Client) { c.domain = "hub.docker.com" },
+			expected: `invalid Hub API URL "hub.docker.com": the scheme must be http or https`,
+		},
+		{
+			name:     "domain without host",
+			setup:    func(c *Client) { c.domain = "https://" },
+			expected: `invalid Hub API URL "https://": no host`,
+		},
+		{
+			name:     "malformed domain",
+			setup:    func(c *Client) { c.domain = "https://hub docker.com\n" },
+			expected: "invalid Hub API URL",
+		},
+		{
+			name:     "registry",
+			setup:    func(c *Client) { c.registry = "ftp://registry" },
+			expected: `invalid registry URL "ftp://registry"`,
+		},
+		{
+			name:     "token with spaces",
+			setup:    func(c *Client) { c.token = "Bearer abc" },
+			expected: "invalid token: unexpected characters",
+		},
+		{
+			name:     "truncated jwt",
+			setup:    func(c *Client) { c.token = "abc.def" },
+			expected: "a JWT has 3 parts, found 2",
+		},
+		{
+			name:     "jwt not encoded",
+			setup:    func(c *Client) { c.token = "a!b.c.d" },
+			expected: "JWT header is not base64 encoded",
+		},
+		{
+			name:     "jwt not json",
+			setup:    func(c *Client) { c.token = "YWJj.YWJj.YWJj" },
+			expected: "JWT header is not a JSON object",
+		},
+		{
+			name:     "page size too large",
+			setup:    func(c *Client) { c.pageSize = 500 },
+			expected: "invalid page size 500",
+		},
+		{
+			name:     "retries without backoff",
+			setup:    func(c *Client) { c.maxRetries, c.retryBackoff = 3, 0 },
+			expected: "3 retries are configured without any backoff",
+		},
+		{
+			name:  "retries with backoff",
+			setup: func(c *Client) { c.maxRetries, c.retryBackoff = 3, time.Second },
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			client, err := NewClient()
+			assert.NilError(t, err)
+			testCase.setup(client)
+			err = client.Validate()
+			if testCase.expected == "" {
+				assert.NilError(t, err)
+			} else {
+				assert.ErrorContains(t, err, testCase.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DeprecationWarning reports a Hub API endpoint flagged as deprecated through
+// the Deprecation or Sunset response headers
+type DeprecationWarning struct {
+	Method string
+	// Path is the route template of the endpoint, like TokenURL, or the path
+	// of the request if the endpoint is unknown
+	Path string
+	// Deprecation is the value of the Deprecation header, usually "true" or
+	// the date the endpoint was deprecated
+	Deprecation string
+	// Sunset is the value of the Sunset header, the date after which the
+	// endpoint may be removed
+	Sunset string
+}
+
+// maxDeprecationWarnings bounds the number of warnings kept by a client, the
+// unknown endpoints being recorded by path
+const maxDeprecationWarnings = 100
+
+// Warnings returns the deprecation warnings reported by the Hub API since the
+// client creation, once per endpoint and at most maxDeprecationWarnings
+func (c *Client) Warnings() []DeprecationWarning {
+	c.warningsLock.Lock()
+	defer c.warningsLock.Unlock()
+	warnings := make([]DeprecationWarning, len(c.warnings))
+	copy(warnings, c.warnings)
+	return warnings
+}
+
+func (c *Client) recordDeprecation(req *http.Request, resp *http.Response) {
+	deprecation := resp.Header.Get("Deprecation")
+	sunset := resp.Header.Get("Sunset")
+	if deprecation == "" && sunset == "" {
+		return
+	}
+	path := c.routeTemplate(req)
+	if path == "" {
+		path = req.URL.Path
+	}
+	warning := DeprecationWarning{
+		Method:      req.Method,
+		Path:        path,
+		Deprecation: deprecation,
+		Sunset:      sunset,
+	}
+
+	c.warningsLock.Lock()
+	defer c.warningsLock.Unlock()
+	if len(c.warnings) >= maxDeprecationWarnings {
+		return
+	}
+	for _, w := range c.warnings {
+		if w == warning {
+			return
+		}
+	}
+	log.Debugf("Hub API endpoint %s %s is deprecated (deprecation: %q, sunset: %q)", req.Method, path, deprecation, sunset)
+	c.warnings = append(c.warnings, warning)
+}
@@ -0,0 +1,212 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+const (
+	//WebhooksURL path to the Hub API listing the webhooks of a repository
+	WebhooksURL = "/v2/repositories/%s/webhook_pipeline/"
+	//WebhookURL path to the Hub API managing a webhook of a repository
+	WebhookURL = "/v2/repositories/%s/webhook_pipeline/%s/"
+
+	webhookRegistry = "registry-1.docker.io"
+)
+
+//Webhook is called by Hub each time an image is pushed to the repository
+type Webhook struct {
+	ID     int
+	Name   string
+	URL    string
+	Active bool
+
+	slug string
+}
+
+//GetWebhooks lists all the webhooks of a repository
+func (c *Client) GetWebhooks(repository string) ([]Webhook, error) {
+	repoPath, err := getRepoPath(repository)
+	if err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(c.domain + fmt.Sprintf(WebhooksURL, repoPath))
+	if err != nil {
+		return nil, err
+	}
+	q := url.Values{}
+	q.Add("page_size", fmt.Sprintf("%v", c.pageSizeOrDefault()))
+	q.Add("page", "1")
+	u.RawQuery = q.Encode()
+
+	var webhooks []Webhook
+	next := u.String()
+	for next != "" {
+		var (
+			page  []Webhook
+			total int
+		)
+		page, total, next, err = c.getWebhooksPage(next)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, page...)
+		c.reportPage(len(webhooks), total)
+	}
+	return webhooks, nil
+}
+
+//CreateWebhook adds a webhook to a repository. The webhook URL must be an
+//absolute http or https URL, it is checked before calling the Hub API.
+func (c *Client) CreateWebhook(repository, name, webhookURL string) (*Webhook, error) {
+	if name == "" {
+		return nil, fmt.Errorf("invalid webhook name: empty")
+	}
+	if err := validateWebhookURL(webhookURL); err != nil {
+		return nil, err
+	}
+	repoPath, err := getRepoPath(repository)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(hubWebhookRequest{
+		Name:     name,
+		Webhooks: []hubWebhookURL{{Name: name, HookURL: webhookURL}},
+		Registry: webhookRegistry,
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("POST", c.domain+fmt.Sprintf(WebhooksURL, repoPath), bytes.NewBuffer(data))
+	if err != nil {
+		return nil, err
+	}
+	response, err := c.doRequest(req, withHubToken(c.token))
+	if err != nil {
+		return nil, err
+	}
+	var result hubWebhookResult
+	if err := json.Unmarshal(response, &result); err != nil {
+		return nil, err
+	}
+	webhook := toWebhook(result)
+	return &webhook, nil
+}
+
+//DeleteWebhook removes a webhook from a repository. The Hub API deletes the
+//webhooks by name, so the webhooks are listed to find the one with this ID.
+func (c *Client) DeleteWebhook(repository string, id int) error {
+	repoPath, err := getRepoPath(repository)
+	if err != nil {
+		return err
+	}
+	webhooks, err := c.GetWebhooks(repoPath)
+	if err != nil {
+		return err
+	}
+	for _, webhook := range webhooks {
+		if webhook.ID != id {
+			continue
+		}
+		req, err := http.NewRequest("DELETE", c.domain+fmt.Sprintf(WebhookURL, repoPath, webhook.slug), nil)
+		if err != nil {
+			return err
+		}
+		_, err = c.doRequest(req, withHubToken(c.token))
+		return err
+	}
+	return fmt.Errorf("%w: %d in %s", ErrWebhookNotFound, id, repoPath)
+}
+
+func (c *Client) getWebhooksPage(url string) ([]Webhook, int, string, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	response, err := c.doRequest(req, withHubToken(c.token))
+	if err != nil {
+		return nil, 0, "", err
+	}
+	var hubResponse hubWebhookResponse
+	if err := json.Unmarshal(response, &hubResponse); err != nil {
+		return nil, 0, "", err
+	}
+	var webhooks []Webhook
+	for _, result := range hubResponse.Results {
+		webhooks = append(webhooks, toWebhook(result))
+	}
+	return webhooks, hubResponse.Count, hubResponse.Next, nil
+}
+
+func validateWebhookURL(webhookURL string) error {
+	u, err := url.Parse(webhookURL)
+	if err != nil {
+		return fmt.Errorf("invalid webhook URL %q: %s", webhookURL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("invalid webhook URL %q: the scheme must be http or https", webhookURL)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("invalid webhook URL %q: missing host", webhookURL)
+	}
+	return nil
+}
+
+func toWebhook(result hubWebhookResult) Webhook {
+	webhook := Webhook{
+		ID:     result.ID,
+		Name:   result.Name,
+		Active: result.Active,
+		slug:   result.Slug,
+	}
+	if len(result.Webhooks) > 0 {
+		webhook.URL = result.Webhooks[0].HookURL
+	}
+	return webhook
+}
+
+type hubWebhookRequest struct {
+	Name                string          `json:"name"`
+	ExpectFinalCallback bool            `json:"expect_final_callback"`
+	Webhooks            []hubWebhookURL `json:"webhooks"`
+	Registry            string          `json:"registry"`
+}
+
+type hubWebhookURL struct {
+	Name    string `json:"name"`
+	HookURL string `json:"hook_url"`
+}
+
+type hubWebhookResponse struct {
+	Count    int                `json:"count"`
+	Next     string             `json:"next,omitempty"`
+	Previous string             `json:"previous,omitempty"`
+	Results  []hubWebhookResult `json:"results,omitempty"`
+}
+
+type hubWebhookResult struct {
+	ID       int             `json:"id"`
+	Name     string          `json:"name"`
+	Slug     string          `json:"slug"`
+	Active   bool            `json:"active"`
+	Webhooks []hubWebhookURL `json:"webhooks"`
+}
@@ -0,0 +1,86 @@
+/*
+   Copyright 2020 Docker Hub Tool authors
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package hub
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func serveWebhooks(t *testing.T, deleted *[]string) http.Handler {
+	t.Helper()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == fmt.Sprintf(WebhooksURL, "org/img"):
+			fmt.Fprint(w, `{"count": 2, "results": [
+				{"id": 1, "name": "ci", "slug": "ci", "active": true, "webhooks": [{"name": "ci", "hook_url": "https://ci.example.com/hook"}]},
+				{"id": 2, "name": "Deploy Prod", "slug": "deploy-prod", "active": false, "webhooks": [{"name": "Deploy Prod", "hook_url": "https://deploy.example.com"}]}
+			]}`)
+		case r.Method == http.MethodPost && r.URL.Path == fmt.Sprintf(WebhooksURL, "org/img"):
+			var request hubWebhookRequest
+			assert.NilError(t, json.NewDecoder(r.Body).Decode(&request))
+			assert.Equal(t, request.Registry, "registry-1.docker.io")
+			assert.DeepEqual(t, request.Webhooks, []hubWebhookURL{{Name: request.Name, HookURL: "https://ci.example.com/hook"}})
+			assert.NilError(t, json.NewEncoder(w).Encode(hubWebhookResult{ID: 3, Name: request.Name, Active: true, Webhooks: request.Webhooks}))
+		case r.Method == http.MethodDelete:
+			*deleted = append(*deleted, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Fatalf("unexpected request %s %s", r.Method, r.URL)
+		}
+	})
+}
+
+func TestGetWebhooks(t *testing.T) {
+	client := newTestClient(t, serveWebhooks(t, nil))
+
+	webhooks, err := client.GetWebhooks("org/img")
+	assert.NilError(t, err)
+	assert.Equal(t, len(webhooks), 2)
+	assert.Equal(t, webhooks[0], Webhook{ID: 1, Name: "ci", URL: "https://ci.example.com/hook", Active: true, slug: "ci"})
+	assert.Equal(t, webhooks[1], Webhook{ID: 2, Name: "Deploy Prod", URL: "https://deploy.example.com", slug: "deploy-prod"})
+}
+
+func TestCreateWebhook(t *testing.T) {
+	client := newTestClient(t, serveWebhooks(t, nil))
+
+	webhook, err := client.CreateWebhook("org/img", "ci", "https://ci.example.com/hook")
+	assert.NilError(t, err)
+	assert.Equal(t, webhook.ID, 3)
+	assert.Equal(t, webhook.URL, "https://ci.example.com/hook")
+
+	for _, invalid := range []string{"", "ci.example.com/hook", "ftp://ci.example.com", "https://"} {
+		_, err := client.CreateWebhook("org/img", "ci", invalid)
+		assert.ErrorContains(t, err, fmt.Sprintf("invalid webhook URL %q", invalid))
+	}
+}
+
+func TestDeleteWebhook(t *testing.T) {
+	var deleted []string
+	client := newTestClient(t, serveWebhooks(t, &deleted))
+
+	assert.NilError(t, client.DeleteWebhook("org/img", 2))
+	assert.DeepEqual(t, deleted, []string{"/v2/repositories/org/img/webhook_pipeline/deploy-prod/"})
+
+	err := client.DeleteWebhook("org/img", 42)
+	assert.Assert(t, errors.Is(err, ErrWebhookNotFound))
+}